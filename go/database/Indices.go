@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Indice que la aplicacion necesita en una coleccion
+type indice struct {
+	Coleccion string
+	Nombre    string
+	Campos    bson.D
+	Unico     bool
+	//Si tiene filtro, el indice solo incluye los documentos que lo cumplen
+	Filtro bson.D
+}
+
+// Indice tal como esta en la base de datos
+type indiceExistente struct {
+	Nombre string `bson:"name"`
+	Campos bson.D `bson:"key"`
+	Unico  bool   `bson:"unique"`
+	Filtro bson.D `bson:"partialFilterExpression"`
+}
+
+// Indices de la base de datos. Para agregar uno, se declara aca y se crea al levantar la aplicacion
+var indicesDeclarados = []indice{
+	//Los camiones dados de baja conservan la patente, asi que solo no se pueden repetir entre los activos
+	{Coleccion: "camiones", Nombre: "patente_unica", Campos: bson.D{{Key: "patente", Value: 1}}, Unico: true, Filtro: bson.D{{Key: "esta_activo", Value: true}}},
+	{Coleccion: "envios", Nombre: "estado", Campos: bson.D{{Key: "estado", Value: 1}}},
+	{Coleccion: "envios", Nombre: "patente_camion", Campos: bson.D{{Key: "patente_camion", Value: 1}}},
+	{Coleccion: "envios", Nombre: "fecha_creacion", Campos: bson.D{{Key: "fecha_creacion", Value: 1}}},
+	{Coleccion: "pedidos", Nombre: "estado", Campos: bson.D{{Key: "estado", Value: 1}}},
+	{Coleccion: "pedidos", Nombre: "fecha_creacion", Campos: bson.D{{Key: "fecha_creacion", Value: 1}}},
+	{Coleccion: "pedidos", Nombre: "codigo_producto", Campos: bson.D{{Key: "productos_elegidos.codigo_producto", Value: 1}}},
+	//Las ciudades y las distancias se guardan con upsert por estas claves, asi que los indices unicos evitan duplicados
+	{Coleccion: "ciudades", Nombre: "clave_unica", Campos: bson.D{{Key: "clave", Value: 1}}, Unico: true},
+	{Coleccion: "distancias", Nombre: "origen_destino_unico", Campos: bson.D{{Key: "origen", Value: 1}, {Key: "destino", Value: 1}}, Unico: true},
+	{Coleccion: "asignaciones_camiones", Nombre: "patente_camion", Campos: bson.D{{Key: "patente_camion", Value: 1}}},
+	{Coleccion: "asignaciones_camiones", Nombre: "codigo_conductor", Campos: bson.D{{Key: "codigo_conductor", Value: 1}}},
+	{Coleccion: "conductores", Nombre: "codigo_usuario", Campos: bson.D{{Key: "codigo_usuario", Value: 1}}},
+	{Coleccion: "movimientos_stock", Nombre: "codigo_producto", Campos: bson.D{{Key: "codigo_producto", Value: 1}}},
+	{Coleccion: "mantenimientos", Nombre: "patente_camion", Campos: bson.D{{Key: "patente_camion", Value: 1}}},
+}
+
+// Crea los indices declarados que todavia no existen y devuelve las diferencias entre los declarados y los que ya
+// estaban en la base de datos. Los indices distintos a los declarados no se tocan, solo se informan, y los que no
+// se pueden crear tambien se informan para que la aplicacion levante igual
+func (mongoDB *MongoDB) InicializarIndices() ([]string, error) {
+	if mongoDB.Client == nil {
+		return nil, errors.New("no hay conexion con la base de datos")
+	}
+
+	ctx := context.Background()
+	db := mongoDB.Client.Database("empresa")
+
+	//Agrupamos los indices por coleccion, respetando el orden en que se declararon
+	colecciones := make([]string, 0)
+	indicesPorColeccion := make(map[string][]indice)
+	for _, declarado := range indicesDeclarados {
+		if _, existe := indicesPorColeccion[declarado.Coleccion]; !existe {
+			colecciones = append(colecciones, declarado.Coleccion)
+		}
+		indicesPorColeccion[declarado.Coleccion] = append(indicesPorColeccion[declarado.Coleccion], declarado)
+	}
+
+	diferencias := make([]string, 0)
+
+	for _, coleccion := range colecciones {
+		collection := db.Collection(coleccion)
+
+		existentes, err := obtenerIndicesExistentes(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+
+		diferenciasColeccion, err := crearIndicesColeccion(ctx, collection, indicesPorColeccion[coleccion], existentes)
+		if err != nil {
+			return nil, err
+		}
+
+		diferencias = append(diferencias, diferenciasColeccion...)
+	}
+
+	return diferencias, nil
+}
+
+// Crea los indices de la coleccion que falten, y compara los demas con los que ya existen
+func crearIndicesColeccion(ctx context.Context, collection *mongo.Collection, declarados []indice, existentes []indiceExistente) ([]string, error) {
+	diferencias := make([]string, 0)
+	nombresDeclarados := make(map[string]bool)
+
+	for _, declarado := range declarados {
+		nombresDeclarados[declarado.Nombre] = true
+
+		existente := buscarIndice(existentes, func(existente indiceExistente) bool { return existente.Nombre == declarado.Nombre })
+		if existente != nil {
+			if !mismosCampos(existente.Campos, declarado.Campos) || existente.Unico != declarado.Unico || !mismosCampos(existente.Filtro, declarado.Filtro) {
+				diferencias = append(diferencias, fmt.Sprintf("el indice %s de %s es %s y se declaro como %s", declarado.Nombre, collection.Name(), describirIndice(existente.Campos, existente.Unico, existente.Filtro), describirIndice(declarado.Campos, declarado.Unico, declarado.Filtro)))
+			}
+			continue
+		}
+
+		//Mongo no deja crear un indice con los mismos campos que otro, aunque tenga otro nombre
+		existente = buscarIndice(existentes, func(existente indiceExistente) bool { return mismosCampos(existente.Campos, declarado.Campos) })
+		if existente != nil {
+			diferencias = append(diferencias, fmt.Sprintf("el indice %s de %s existe con el nombre %s", declarado.Nombre, collection.Name(), existente.Nombre))
+			if existente.Unico != declarado.Unico || !mismosCampos(existente.Filtro, declarado.Filtro) {
+				diferencias = append(diferencias, fmt.Sprintf("el indice %s de %s es %s y se declaro como %s", existente.Nombre, collection.Name(), describirIndice(existente.Campos, existente.Unico, existente.Filtro), describirIndice(declarado.Campos, declarado.Unico, declarado.Filtro)))
+			}
+			continue
+		}
+
+		//Si ya hay documentos repetidos el indice unico no se puede crear, asi que se informan y se sigue con los demas
+		if declarado.Unico {
+			repetidos, err := obtenerClavesRepetidas(ctx, collection, declarado)
+			if err != nil {
+				return nil, err
+			}
+			if len(repetidos) > 0 {
+				diferencias = append(diferencias, fmt.Sprintf("no se creo el indice %s de %s porque hay documentos repetidos: %s", declarado.Nombre, collection.Name(), strings.Join(repetidos, ", ")))
+				continue
+			}
+		}
+
+		opciones := options.Index().SetName(declarado.Nombre)
+		if declarado.Unico {
+			opciones.SetUnique(true)
+		}
+		if len(declarado.Filtro) > 0 {
+			opciones.SetPartialFilterExpression(declarado.Filtro)
+		}
+
+		_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: declarado.Campos, Options: opciones})
+		if err != nil {
+			diferencias = append(diferencias, fmt.Sprintf("no se pudo crear el indice %s de %s: %s", declarado.Nombre, collection.Name(), err.Error()))
+		}
+	}
+
+	//El indice de _id lo crea mongo en todas las colecciones
+	for _, existente := range existentes {
+		if existente.Nombre != "_id_" && !nombresDeclarados[existente.Nombre] && !esIndiceDeclaradoConOtroNombre(existente, declarados) {
+			diferencias = append(diferencias, fmt.Sprintf("el indice %s de %s no esta declarado", existente.Nombre, collection.Name()))
+		}
+	}
+
+	return diferencias, nil
+}
+
+// Devuelve las claves del indice que se repiten entre los documentos que cubre, con la cantidad de cada una
+func obtenerClavesRepetidas(ctx context.Context, collection *mongo.Collection, declarado indice) ([]string, error) {
+	//Se agrupa por un array con los valores de los campos, porque los nombres pueden tener puntos
+	clave := make(bson.A, 0, len(declarado.Campos))
+	for _, campo := range declarado.Campos {
+		clave = append(clave, "$"+campo.Key)
+	}
+
+	pipeline := mongo.Pipeline{}
+	if len(declarado.Filtro) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: declarado.Filtro}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: clave}, {Key: "cantidad", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		bson.D{{Key: "$match", Value: bson.D{{Key: "cantidad", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var grupos []struct {
+		Clave    bson.A `bson:"_id"`
+		Cantidad int    `bson:"cantidad"`
+	}
+	err = cursor.All(ctx, &grupos)
+	if err != nil {
+		return nil, err
+	}
+
+	repetidos := make([]string, 0, len(grupos))
+	for _, grupo := range grupos {
+		valores := make(bson.D, 0, len(declarado.Campos))
+		for i, campo := range declarado.Campos {
+			valores = append(valores, bson.E{Key: campo.Key, Value: grupo.Clave[i]})
+		}
+		repetidos = append(repetidos, fmt.Sprintf("%s (%d documentos)", describirCampos(valores), grupo.Cantidad))
+	}
+
+	return repetidos, nil
+}
+
+func obtenerIndicesExistentes(ctx context.Context, collection *mongo.Collection) ([]indiceExistente, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		//Si la coleccion todavia no existe, no tiene indices
+		var errorComando mongo.CommandError
+		if errors.As(err, &errorComando) && errorComando.Code == 26 {
+			return []indiceExistente{}, nil
+		}
+		return nil, err
+	}
+
+	existentes := make([]indiceExistente, 0)
+	err = cursor.All(ctx, &existentes)
+	if err != nil {
+		return nil, err
+	}
+
+	return existentes, nil
+}
+
+func buscarIndice(existentes []indiceExistente, coincide func(indiceExistente) bool) *indiceExistente {
+	for i := range existentes {
+		if coincide(existentes[i]) {
+			return &existentes[i]
+		}
+	}
+	return nil
+}
+
+func esIndiceDeclaradoConOtroNombre(existente indiceExistente, declarados []indice) bool {
+	for _, declarado := range declarados {
+		if mismosCampos(existente.Campos, declarado.Campos) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compara los campos y el sentido de dos indices. Mongo puede guardar el sentido como int32, int64 o double
+func mismosCampos(campos bson.D, otros bson.D) bool {
+	if len(campos) != len(otros) {
+		return false
+	}
+	for i := range campos {
+		if campos[i].Key != otros[i].Key || fmt.Sprint(campos[i].Value) != fmt.Sprint(otros[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func describirIndice(campos bson.D, unico bool, filtro bson.D) string {
+	descripcion := describirCampos(campos)
+	if unico {
+		descripcion += " unico"
+	}
+	if len(filtro) > 0 {
+		descripcion += " para " + describirCampos(filtro)
+	}
+	return descripcion
+}
+
+func describirCampos(campos bson.D) string {
+	descripcion := ""
+	for i, campo := range campos {
+		if i > 0 {
+			descripcion += ", "
+		}
+		descripcion += fmt.Sprint(campo.Key, ": ", campo.Value)
+	}
+	return "{" + descripcion + "}"
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -8,4 +10,5 @@ type DB interface {
 	Connect() error
 	Disconnect() error
 	GetClient() *mongo.Client
+	EjecutarTransaccion(func(ctx context.Context) error) error
 }
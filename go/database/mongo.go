@@ -24,7 +24,7 @@ func (mongoDB *MongoDB) GetClient() *mongo.Client {
 
 // La dejamos privada, se ejecuta cuando se crea el objeto
 func (mongoDB *MongoDB) Connect() error {
-	clientOptions := options.Client().ApplyURI("mongodb://mongodb:27017")
+	clientOptions := options.Client().ApplyURI("mongodb://mongodb:27017/?replicaSet=rs0")
 
 	client, err := mongo.Connect(context.Background(), clientOptions)
 
@@ -45,3 +45,21 @@ func (mongoDB *MongoDB) Connect() error {
 func (mongoDB *MongoDB) Disconnect() error {
 	return mongoDB.Client.Disconnect(context.Background())
 }
+
+// Ejecuta la operacion dentro de una transaccion multi-documento.
+// Todas las escrituras que usen el contexto recibido se confirman juntas, o se deshacen si la operacion devuelve un error.
+func (mongoDB *MongoDB) EjecutarTransaccion(operacion func(ctx context.Context) error) error {
+	sesion, err := mongoDB.Client.StartSession()
+	if err != nil {
+		return err
+	}
+
+	defer sesion.EndSession(context.Background())
+
+	//WithTransaction se encarga del commit, del abort y de reintentar ante errores transitorios
+	_, err = sesion.WithTransaction(context.Background(), func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, operacion(ctx)
+	})
+
+	return err
+}
@@ -0,0 +1,8 @@
+package dto
+
+// Archivo subido o descargado por la API, por ejemplo la firma o foto de una entrega
+type Archivo struct {
+	Nombre    string
+	Tipo      string
+	Contenido []byte
+}
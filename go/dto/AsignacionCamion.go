@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"time"
+)
+
+type AsignacionCamion struct {
+	Id              string     `json:"id"`
+	PatenteCamion   string     `json:"patente_camion"`
+	CodigoConductor string     `json:"codigo_conductor"`
+	FechaDesde      time.Time  `json:"fecha_desde"`
+	FechaHasta      *time.Time `json:"fecha_hasta,omitempty"`
+	FechaCreacion   time.Time  `json:"fecha_creacion"`
+	IdCreador       string     `json:"id_creador"`
+}
+
+// Crea el dto a partir del modelo. Si la asignacion no tiene fin, no se informa la fecha hasta
+func NewAsignacionCamion(asignacion *model.AsignacionCamion) *AsignacionCamion {
+	return &AsignacionCamion{
+		Id:              utils.GetStringIDFromObjectID(asignacion.ObjectId),
+		PatenteCamion:   asignacion.PatenteCamion,
+		CodigoConductor: asignacion.CodigoConductor,
+		FechaDesde:      asignacion.FechaDesde,
+		FechaHasta:      newFechaOpcional(asignacion.FechaHasta),
+		FechaCreacion:   asignacion.FechaCreacion,
+		IdCreador:       asignacion.IdCreador,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (asignacion AsignacionCamion) GetModel() *model.AsignacionCamion {
+	return &model.AsignacionCamion{
+		ObjectId:        utils.GetObjectIDFromStringID(asignacion.Id),
+		PatenteCamion:   asignacion.PatenteCamion,
+		CodigoConductor: asignacion.CodigoConductor,
+		FechaDesde:      asignacion.FechaDesde,
+		FechaHasta:      getFechaOpcional(asignacion.FechaHasta),
+		FechaCreacion:   asignacion.FechaCreacion,
+		IdCreador:       asignacion.IdCreador,
+	}
+}
@@ -0,0 +1,7 @@
+package dto
+
+// El nombre del dia es la fecha en formato 2006-01-02
+type BeneficioDiario struct {
+	Dia   string  `json:"Nombre"`
+	Monto float64 `json:"Monto"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+// El nombre de la semana es el año y el numero de semana ISO, por ejemplo 2024-W09
+type BeneficioSemanal struct {
+	Semana string  `json:"Nombre"`
+	Monto  float64 `json:"Monto"`
+}
@@ -3,4 +3,6 @@ package dto
 type BeneficioTemporal struct {
 	BeneficiosAnuales []BeneficioAnual `json:"anios"`
 	BeneficiosMensuales []BeneficioMensual `json:"meses"`
+	BeneficiosSemanales []BeneficioSemanal `json:"semanas"`
+	BeneficiosDiarios []BeneficioDiario `json:"dias"`
 }
\ No newline at end of file
@@ -6,24 +6,30 @@ import (
 )
 
 type Camion struct {
-	Patente                  string    `json:"patente"`
-	PesoMaximo               int       `json:"peso_maximo"`
-	CostoPorKilometro        float64   `json:"costo_por_kilometro"`
-	FechaCreacion            time.Time `json:"fecha_creacion"`
-	FechaUltimaActualizacion time.Time `json:"fecha_ultima_actualizacion"`
-	IdCreador                string    `json:"id_creador"`
-	EstaActivo               bool      `json:"esta_activo"`
+	Patente                  string            `json:"patente"`
+	PesoMaximo               int               `json:"peso_maximo"`
+	VolumenMaximo            float64           `json:"volumen_maximo"`
+	CostoPorKilometro        float64           `json:"costo_por_kilometro"`
+	FechaCreacion            time.Time         `json:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time         `json:"fecha_ultima_actualizacion"`
+	IdCreador                string            `json:"id_creador"`
+	EstaActivo               bool              `json:"esta_activo"`
+	Documentos               []DocumentoCamion `json:"documentos"`
+	Version                  int               `json:"version"`
 }
 
 func NewCamion(camion model.Camion) *Camion {
 	return &Camion{
 		Patente:                  camion.Patente,
 		PesoMaximo:               camion.PesoMaximo,
+		VolumenMaximo:            camion.VolumenMaximo,
 		CostoPorKilometro:        camion.CostoPorKilometro,
 		FechaCreacion:            camion.FechaCreacion,
 		FechaUltimaActualizacion: camion.FechaUltimaActualizacion,
 		IdCreador:                camion.IdCreador,
 		EstaActivo:               camion.EstaActivo,
+		Documentos:               NewDocumentosCamion(camion.Documentos),
+		Version:                  camion.Version,
 	}
 }
 
@@ -31,10 +37,22 @@ func (camion Camion) GetModel() *model.Camion {
 	return &model.Camion{
 		Patente:                  camion.Patente,
 		PesoMaximo:               camion.PesoMaximo,
+		VolumenMaximo:            camion.VolumenMaximo,
 		CostoPorKilometro:        camion.CostoPorKilometro,
 		FechaCreacion:            camion.FechaCreacion,
 		FechaUltimaActualizacion: camion.FechaUltimaActualizacion,
 		IdCreador:                camion.IdCreador,
 		EstaActivo:               camion.EstaActivo,
+		Documentos:               camion.getDocumentos(),
+		Version:                  camion.Version,
 	}
 }
+
+// Metodo para convertir la lista de documentos del dto a una lista de documentos del modelo
+func (camion Camion) getDocumentos() []model.DocumentoCamion {
+	var documentos []model.DocumentoCamion
+	for _, documento := range camion.Documentos {
+		documentos = append(documentos, documento.GetModel())
+	}
+	return documentos
+}
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+type Ciudad struct {
+	Nombre                   string    `json:"nombre"`
+	FechaCreacion            time.Time `json:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time `json:"fecha_ultima_actualizacion"`
+	IdCreador                string    `json:"id_creador"`
+}
+
+// Crea el dto a partir del modelo
+func NewCiudad(ciudad *model.Ciudad) *Ciudad {
+	return &Ciudad{
+		Nombre:                   ciudad.Nombre,
+		FechaCreacion:            ciudad.FechaCreacion,
+		FechaUltimaActualizacion: ciudad.FechaUltimaActualizacion,
+		IdCreador:                ciudad.IdCreador,
+	}
+}
@@ -0,0 +1,11 @@
+package dto
+
+// Resultado de comparar el stock guardado en el producto con el que surge de sumar sus movimientos
+type ConciliacionStock struct {
+	CodigoProducto                 string `json:"codigo_producto"`
+	StockActual                    int    `json:"stock_actual"`
+	StockActualSegunMovimientos    int    `json:"stock_actual_segun_movimientos"`
+	StockReservado                 int    `json:"stock_reservado"`
+	StockReservadoSegunMovimientos int    `json:"stock_reservado_segun_movimientos"`
+	Coincide                       bool   `json:"coincide"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+type Conductor struct {
+	CodigoUsuario            string    `json:"codigo_usuario"`
+	Nombre                   string    `json:"nombre"`
+	Licencia                 string    `json:"licencia"`
+	FechaCreacion            time.Time `json:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time `json:"fecha_ultima_actualizacion"`
+	IdCreador                string    `json:"id_creador"`
+	EstaActivo               bool      `json:"esta_activo"`
+	Version                  int       `json:"version"`
+}
+
+// Crea el dto a partir del modelo
+func NewConductor(conductor *model.Conductor) *Conductor {
+	return &Conductor{
+		CodigoUsuario:            conductor.CodigoUsuario,
+		Nombre:                   conductor.Nombre,
+		Licencia:                 conductor.Licencia,
+		FechaCreacion:            conductor.FechaCreacion,
+		FechaUltimaActualizacion: conductor.FechaUltimaActualizacion,
+		IdCreador:                conductor.IdCreador,
+		EstaActivo:               conductor.EstaActivo,
+		Version:                  conductor.Version,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (conductor Conductor) GetModel() *model.Conductor {
+	return &model.Conductor{
+		CodigoUsuario:            conductor.CodigoUsuario,
+		Nombre:                   conductor.Nombre,
+		Licencia:                 conductor.Licencia,
+		FechaCreacion:            conductor.FechaCreacion,
+		FechaUltimaActualizacion: conductor.FechaUltimaActualizacion,
+		IdCreador:                conductor.IdCreador,
+		EstaActivo:               conductor.EstaActivo,
+		Version:                  conductor.Version,
+	}
+}
@@ -0,0 +1,92 @@
+package dto
+
+import "TPIntegrador/model"
+
+// Datos que manda el cliente para que se repartan los pedidos aceptados entre los camiones
+type SolicitudConsolidacionEnvios struct {
+	CiudadOrigen string `json:"ciudad_origen"`
+}
+
+// Resultado de la consolidacion: los envios propuestos y los pedidos que no se pudieron asignar
+type ConsolidacionEnvios struct {
+	Envios            []EnvioPropuesto   `json:"envios"`
+	PedidosSinAsignar []PedidoSinAsignar `json:"pedidos_sin_asignar"`
+	KmTotales         int                `json:"km_totales"`
+	CostoTotal        float64            `json:"costo_total"`
+}
+
+// Borrador de un envio. Se confirma creando un envio con la patente y los pedidos, que lleva el conductor asignado al camion
+type EnvioPropuesto struct {
+	PatenteCamion   string              `json:"patente_camion"`
+	CodigoConductor string              `json:"codigo_conductor"`
+	Pedidos         []string            `json:"pedidos"`
+	Paradas         []ParadaPlanificada `json:"paradas"`
+	PesoTotal       float64             `json:"peso_total"`
+	PesoMaximo      int                 `json:"peso_maximo"`
+	VolumenTotal    float64             `json:"volumen_total"`
+	VolumenMaximo   float64             `json:"volumen_maximo"`
+	KmTotales       int                 `json:"km_totales"`
+	CostoEstimado   float64             `json:"costo_estimado"`
+}
+
+type PedidoSinAsignar struct {
+	IdPedido string  `json:"id_pedido"`
+	Ciudad   string  `json:"ciudad"`
+	Peso     float64 `json:"peso"`
+	Volumen  float64 `json:"volumen"`
+	Motivo   string  `json:"motivo"`
+}
+
+// Resultado de confirmar un envio propuesto
+type ResultadoConfirmacionEnvio struct {
+	PatenteCamion   string `json:"patente_camion"`
+	IdEnvio         string `json:"id_envio,omitempty"`
+	CodigoConductor string `json:"codigo_conductor,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Crea el dto a partir del envio consolidado del modelo
+func NewEnvioPropuesto(envio *model.EnvioConsolidado) *EnvioPropuesto {
+	//Agrupamos los pedidos por ciudad para mostrarlos en cada parada
+	pedidosPorCiudad := make(map[string][]string)
+	pedidos := make([]string, 0)
+
+	for _, pedido := range envio.Pedidos {
+		clave := model.ObtenerClaveCiudad(pedido.Ciudad)
+		pedidosPorCiudad[clave] = append(pedidosPorCiudad[clave], pedido.IdPedido)
+		pedidos = append(pedidos, pedido.IdPedido)
+	}
+
+	paradas := make([]ParadaPlanificada, 0)
+
+	for _, parada := range envio.Paradas {
+		paradas = append(paradas, ParadaPlanificada{
+			Ciudad:       parada.Ciudad,
+			KmRecorridos: parada.KmRecorridos,
+			Pedidos:      pedidosPorCiudad[model.ObtenerClaveCiudad(parada.Ciudad)],
+		})
+	}
+
+	return &EnvioPropuesto{
+		PatenteCamion: envio.Camion.Patente,
+		Pedidos:       pedidos,
+		Paradas:       paradas,
+		PesoTotal:     envio.Peso,
+		PesoMaximo:    envio.Camion.PesoMaximo,
+		VolumenTotal:  envio.Volumen,
+		VolumenMaximo: envio.Camion.VolumenMaximo,
+		KmTotales:     envio.Km,
+		CostoEstimado: envio.Costo,
+	}
+}
+
+// Crea el dto a partir del pedido sin asignar del modelo
+func NewPedidoSinAsignar(pedido *model.PedidoSinAsignar) *PedidoSinAsignar {
+	return &PedidoSinAsignar{
+		IdPedido: pedido.IdPedido,
+		Ciudad:   pedido.Ciudad,
+		Peso:     pedido.Peso,
+		Volumen:  pedido.Volumen,
+		Motivo:   pedido.Motivo,
+	}
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+type Devolucion struct {
+	Productos     []ProductoPedido `json:"productos"`
+	Motivo        string           `json:"motivo"`
+	FechaCreacion time.Time        `json:"fecha_creacion"`
+	IdCreador     string           `json:"id_creador"`
+}
+
+// Crea el dto a partir del modelo
+func NewDevolucion(devolucion *model.Devolucion) *Devolucion {
+	return &Devolucion{
+		Productos:     NewProductosPedido(devolucion.Productos),
+		Motivo:        devolucion.Motivo,
+		FechaCreacion: devolucion.FechaCreacion,
+		IdCreador:     devolucion.IdCreador,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (devolucion Devolucion) GetModel() model.Devolucion {
+	var productos []model.ProductoPedido
+	for _, producto := range devolucion.Productos {
+		productos = append(productos, producto.GetModel())
+	}
+
+	return model.Devolucion{
+		Productos:     productos,
+		Motivo:        devolucion.Motivo,
+		FechaCreacion: devolucion.FechaCreacion,
+		IdCreador:     devolucion.IdCreador,
+	}
+}
+
+// Metodo para convertir una lista de Devoluciones del modelo a una lista de Devoluciones del dto
+func NewDevoluciones(devoluciones []model.Devolucion) []Devolucion {
+	var devolucionesDto []Devolucion
+	for _, devolucion := range devoluciones {
+		devolucionesDto = append(devolucionesDto, *NewDevolucion(&devolucion))
+	}
+	return devolucionesDto
+}
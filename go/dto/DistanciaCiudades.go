@@ -0,0 +1,25 @@
+package dto
+
+import "TPIntegrador/model"
+
+// Una fila del archivo de distancias, ya sea CSV o JSON
+type DistanciaCiudades struct {
+	Origen  string `json:"origen"`
+	Destino string `json:"destino"`
+	Km      int    `json:"km"`
+}
+
+// Crea el modelo a partir del dto
+func (distancia DistanciaCiudades) GetModel() *model.DistanciaCiudades {
+	return &model.DistanciaCiudades{
+		Origen:  distancia.Origen,
+		Destino: distancia.Destino,
+		Km:      distancia.Km,
+	}
+}
+
+// Resultado de importar un archivo de distancias
+type ImportacionDistancias struct {
+	Ciudades   int `json:"ciudades"`
+	Distancias int `json:"distancias"`
+}
@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+type DocumentoCamion struct {
+	Tipo             model.TipoDocumentoCamion `json:"tipo"`
+	Numero           string                    `json:"numero"`
+	FechaEmision     *time.Time                `json:"fecha_emision,omitempty"`
+	FechaVencimiento time.Time                 `json:"fecha_vencimiento"`
+}
+
+// Documento que vence dentro de la ventana consultada, que ya esta vencido, o que es obligatorio y falta cargar
+type DocumentoPorVencer struct {
+	PatenteCamion    string                    `json:"patente_camion"`
+	Tipo             model.TipoDocumentoCamion `json:"tipo"`
+	Numero           string                    `json:"numero"`
+	FechaVencimiento *time.Time                `json:"fecha_vencimiento,omitempty"`
+	DiasRestantes    int                       `json:"dias_restantes"`
+	Vencido          bool                      `json:"vencido"`
+	Faltante         bool                      `json:"faltante"`
+}
+
+// Crea el dto a partir del modelo
+func NewDocumentoCamion(documento model.DocumentoCamion) DocumentoCamion {
+	return DocumentoCamion{
+		Tipo:             documento.Tipo,
+		Numero:           documento.Numero,
+		FechaEmision:     newFechaOpcional(documento.FechaEmision),
+		FechaVencimiento: documento.FechaVencimiento,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (documento DocumentoCamion) GetModel() model.DocumentoCamion {
+	return model.DocumentoCamion{
+		Tipo:             documento.Tipo,
+		Numero:           documento.Numero,
+		FechaEmision:     getFechaOpcional(documento.FechaEmision),
+		FechaVencimiento: documento.FechaVencimiento,
+	}
+}
+
+// Crea el dto del documento por vencer, contando los dias que faltan desde la fecha indicada
+func NewDocumentoPorVencer(patente string, documento model.DocumentoCamion, fecha time.Time) *DocumentoPorVencer {
+	return &DocumentoPorVencer{
+		PatenteCamion:    patente,
+		Tipo:             documento.Tipo,
+		Numero:           documento.Numero,
+		FechaVencimiento: newFechaOpcional(documento.FechaVencimiento),
+		DiasRestantes:    int(documento.FechaVencimiento.Sub(fecha).Hours() / 24),
+		Vencido:          documento.EstaVencido(fecha),
+	}
+}
+
+// Crea el dto de un documento obligatorio que el camion no tiene cargado
+func NewDocumentoFaltante(patente string, tipo model.TipoDocumentoCamion) *DocumentoPorVencer {
+	return &DocumentoPorVencer{
+		PatenteCamion: patente,
+		Tipo:          tipo,
+		Faltante:      true,
+	}
+}
+
+// Metodo para convertir una lista de documentos del modelo a una lista de documentos del dto
+func NewDocumentosCamion(documentos []model.DocumentoCamion) []DocumentoCamion {
+	documentosCamion := make([]DocumentoCamion, 0, len(documentos))
+	for _, documento := range documentos {
+		documentosCamion = append(documentosCamion, NewDocumentoCamion(documento))
+	}
+	return documentosCamion
+}
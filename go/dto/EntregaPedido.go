@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+type EntregaPedido struct {
+	IdEnvio       string    `json:"id_envio"`
+	Ciudad        string    `json:"ciudad"`
+	FechaEntrega  time.Time `json:"fecha_entrega"`
+	Receptor      string    `json:"receptor"`
+	Observaciones string    `json:"observaciones"`
+	IdArchivo     string    `json:"id_archivo,omitempty"`
+	NombreArchivo string    `json:"nombre_archivo,omitempty"`
+	TipoArchivo   string    `json:"tipo_archivo,omitempty"`
+	IdCreador     string    `json:"id_creador"`
+}
+
+// Crea el dto a partir del modelo
+func NewEntregaPedido(entrega *model.EntregaPedido) *EntregaPedido {
+	if entrega == nil {
+		return nil
+	}
+
+	return &EntregaPedido{
+		IdEnvio:       entrega.IdEnvio,
+		Ciudad:        entrega.Ciudad,
+		FechaEntrega:  entrega.FechaEntrega,
+		Receptor:      entrega.Receptor,
+		Observaciones: entrega.Observaciones,
+		IdArchivo:     entrega.IdArchivo,
+		NombreArchivo: entrega.NombreArchivo,
+		TipoArchivo:   entrega.TipoArchivo,
+		IdCreador:     entrega.IdCreador,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (entrega *EntregaPedido) GetModel() *model.EntregaPedido {
+	if entrega == nil {
+		return nil
+	}
+
+	return &model.EntregaPedido{
+		IdEnvio:       entrega.IdEnvio,
+		Ciudad:        entrega.Ciudad,
+		FechaEntrega:  entrega.FechaEntrega,
+		Receptor:      entrega.Receptor,
+		Observaciones: entrega.Observaciones,
+		IdArchivo:     entrega.IdArchivo,
+		NombreArchivo: entrega.NombreArchivo,
+		TipoArchivo:   entrega.TipoArchivo,
+		IdCreador:     entrega.IdCreador,
+	}
+}
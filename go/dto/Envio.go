@@ -14,7 +14,10 @@ type Envio struct {
 	Paradas                  []Parada          `json:"paradas"`
 	Pedidos                  []string          `json:"pedidos"`
 	IdCreador                string            `json:"id_creador"`
+	IdConductor              string            `json:"id_conductor"`
+	FechaDespacho            *time.Time        `json:"fecha_despacho,omitempty"`
 	Estado                   model.EstadoEnvio `json:"estado"`
+	Version                  int               `json:"version"`
 }
 
 func NewEnvio(envio model.Envio) *Envio {
@@ -26,7 +29,10 @@ func NewEnvio(envio model.Envio) *Envio {
 		Paradas:                  NewParadas(envio.Paradas),
 		Pedidos:                  envio.Pedidos,
 		IdCreador:                envio.IdCreador,
+		IdConductor:              envio.IdConductor,
+		FechaDespacho:            newFechaOpcional(envio.FechaDespacho),
 		Estado:                   envio.Estado,
+		Version:                  envio.Version,
 	}
 }
 
@@ -39,7 +45,10 @@ func (envio Envio) GetModel() *model.Envio {
 		Paradas:                  envio.getParadas(),
 		Pedidos:                  envio.Pedidos,
 		IdCreador:                envio.IdCreador,
+		IdConductor:              envio.IdConductor,
+		FechaDespacho:            getFechaOpcional(envio.FechaDespacho),
 		Estado:                   envio.Estado,
+		Version:                  envio.Version,
 	}
 }
 
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// Las fechas vacias del modelo no se informan en el json
+func newFechaOpcional(fecha time.Time) *time.Time {
+	if fecha.IsZero() {
+		return nil
+	}
+	return &fecha
+}
+
+// Si el json no trae la fecha, en el modelo queda vacia
+func getFechaOpcional(fecha *time.Time) time.Time {
+	if fecha == nil {
+		return time.Time{}
+	}
+	return *fecha
+}
@@ -0,0 +1,8 @@
+package dto
+
+// Conteo fisico del stock de un producto
+type Inventario struct {
+	CodigoProducto string `json:"codigo_producto"`
+	StockContado   int    `json:"stock_contado"`
+	Version        int    `json:"version"`
+}
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"time"
+)
+
+type Mantenimiento struct {
+	Id                       string                    `json:"id"`
+	PatenteCamion            string                    `json:"patente_camion"`
+	Descripcion              string                    `json:"descripcion"`
+	Estado                   model.EstadoMantenimiento `json:"estado"`
+	FechaProgramada          *time.Time                `json:"fecha_programada,omitempty"`
+	KmProgramados            int                       `json:"km_programados,omitempty"`
+	FechaInicio              *time.Time                `json:"fecha_inicio,omitempty"`
+	FechaRealizacion         *time.Time                `json:"fecha_realizacion,omitempty"`
+	KmAlRealizar             int                       `json:"km_al_realizar,omitempty"`
+	Costo                    float64                   `json:"costo"`
+	Observaciones            string                    `json:"observaciones"`
+	Vencido                  bool                      `json:"vencido"`
+	FechaCreacion            time.Time                 `json:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time                 `json:"fecha_ultima_actualizacion"`
+	IdCreador                string                    `json:"id_creador"`
+	Version                  int                       `json:"version"`
+}
+
+// Crea el dto a partir del modelo. Los km acumulados del camion sirven para indicar si el mantenimiento esta vencido
+func NewMantenimiento(mantenimiento *model.Mantenimiento, kmAcumulados int) *Mantenimiento {
+	return &Mantenimiento{
+		Id:                       utils.GetStringIDFromObjectID(mantenimiento.ObjectId),
+		PatenteCamion:            mantenimiento.PatenteCamion,
+		Descripcion:              mantenimiento.Descripcion,
+		Estado:                   mantenimiento.Estado,
+		FechaProgramada:          newFechaOpcional(mantenimiento.FechaProgramada),
+		KmProgramados:            mantenimiento.KmProgramados,
+		FechaInicio:              newFechaOpcional(mantenimiento.FechaInicio),
+		FechaRealizacion:         newFechaOpcional(mantenimiento.FechaRealizacion),
+		KmAlRealizar:             mantenimiento.KmAlRealizar,
+		Costo:                    mantenimiento.Costo,
+		Observaciones:            mantenimiento.Observaciones,
+		Vencido:                  mantenimiento.EstaVencido(time.Now(), kmAcumulados),
+		FechaCreacion:            mantenimiento.FechaCreacion,
+		FechaUltimaActualizacion: mantenimiento.FechaUltimaActualizacion,
+		IdCreador:                mantenimiento.IdCreador,
+		Version:                  mantenimiento.Version,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (mantenimiento Mantenimiento) GetModel() *model.Mantenimiento {
+	return &model.Mantenimiento{
+		ObjectId:                 utils.GetObjectIDFromStringID(mantenimiento.Id),
+		PatenteCamion:            mantenimiento.PatenteCamion,
+		Descripcion:              mantenimiento.Descripcion,
+		Estado:                   mantenimiento.Estado,
+		FechaProgramada:          getFechaOpcional(mantenimiento.FechaProgramada),
+		KmProgramados:            mantenimiento.KmProgramados,
+		FechaInicio:              getFechaOpcional(mantenimiento.FechaInicio),
+		FechaRealizacion:         getFechaOpcional(mantenimiento.FechaRealizacion),
+		KmAlRealizar:             mantenimiento.KmAlRealizar,
+		Costo:                    mantenimiento.Costo,
+		Observaciones:            mantenimiento.Observaciones,
+		FechaCreacion:            mantenimiento.FechaCreacion,
+		FechaUltimaActualizacion: mantenimiento.FechaUltimaActualizacion,
+		IdCreador:                mantenimiento.IdCreador,
+		Version:                  mantenimiento.Version,
+	}
+}
+
+// Mantenimientos de un camion, junto con los km que lleva recorridos en envios finalizados
+type MantenimientosCamion struct {
+	PatenteCamion  string           `json:"patente_camion"`
+	KmAcumulados   int              `json:"km_acumulados"`
+	Mantenimientos []*Mantenimiento `json:"mantenimientos"`
+}
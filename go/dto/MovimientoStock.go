@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"time"
+)
+
+type MovimientoStock struct {
+	Id                string                      `json:"id"`
+	CodigoProducto    string                      `json:"codigo_producto"`
+	Cantidad          int                         `json:"cantidad"`
+	CantidadReservada int                         `json:"cantidad_reservada"`
+	Motivo            model.MotivoMovimientoStock `json:"motivo"`
+	IdPedido          string                      `json:"id_pedido,omitempty"`
+	IdEnvio           string                      `json:"id_envio,omitempty"`
+	FechaCreacion     time.Time                   `json:"fecha_creacion"`
+	IdCreador         string                      `json:"id_creador"`
+}
+
+// Crea el dto a partir del modelo
+func NewMovimientoStock(movimiento *model.MovimientoStock) *MovimientoStock {
+	return &MovimientoStock{
+		Id:                utils.GetStringIDFromObjectID(movimiento.ObjectId),
+		CodigoProducto:    movimiento.CodigoProducto,
+		Cantidad:          movimiento.Cantidad,
+		CantidadReservada: movimiento.CantidadReservada,
+		Motivo:            movimiento.Motivo,
+		IdPedido:          movimiento.IdPedido,
+		IdEnvio:           movimiento.IdEnvio,
+		FechaCreacion:     movimiento.FechaCreacion,
+		IdCreador:         movimiento.IdCreador,
+	}
+}
+
+// Crea el modelo a partir del dto
+func (movimiento MovimientoStock) GetModel() *model.MovimientoStock {
+	return &model.MovimientoStock{
+		ObjectId:          utils.GetObjectIDFromStringID(movimiento.Id),
+		CodigoProducto:    movimiento.CodigoProducto,
+		Cantidad:          movimiento.Cantidad,
+		CantidadReservada: movimiento.CantidadReservada,
+		Motivo:            movimiento.Motivo,
+		IdPedido:          movimiento.IdPedido,
+		IdEnvio:           movimiento.IdEnvio,
+		FechaCreacion:     movimiento.FechaCreacion,
+		IdCreador:         movimiento.IdCreador,
+	}
+}
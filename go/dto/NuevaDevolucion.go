@@ -0,0 +1,7 @@
+package dto
+
+// Datos que manda el cliente para registrar la devolucion de un pedido: que productos y cuantas unidades volvieron
+type NuevaDevolucion struct {
+	Productos []NuevoProductoPedido `json:"productos"`
+	Motivo    string                `json:"motivo"`
+}
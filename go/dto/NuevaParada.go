@@ -1,14 +1,21 @@
 package dto
 
+// Ademas de la ciudad, la parada indica que pedidos del envio se entregaron en ella
+// y cuales no se pudieron entregar
 type NuevaParada struct {
-	IdEnvio      string `json:"id_envio"`
-	Ciudad       string `json:"ciudad"`
-	KmRecorridos int    `json:"km_recorridos"`
+	IdEnvio             string   `json:"id_envio"`
+	Ciudad              string   `json:"ciudad"`
+	KmRecorridos        int      `json:"km_recorridos"`
+	PedidosEntregados   []string `json:"pedidos_entregados"`
+	PedidosNoEntregados []string `json:"pedidos_no_entregados"`
+	VersionEnvio        int      `json:"version_envio"`
 }
 
 func (nuevaParada NuevaParada) GetParada() Parada {
 	return Parada{
-		Ciudad:       nuevaParada.Ciudad,
-		KmRecorridos: nuevaParada.KmRecorridos,
+		Ciudad:              nuevaParada.Ciudad,
+		KmRecorridos:        nuevaParada.KmRecorridos,
+		PedidosEntregados:   nuevaParada.PedidosEntregados,
+		PedidosNoEntregados: nuevaParada.PedidosNoEntregados,
 	}
-}
\ No newline at end of file
+}
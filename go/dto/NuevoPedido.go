@@ -0,0 +1,12 @@
+package dto
+
+// Datos que manda el cliente para crear un pedido. Precio, peso y nombre de cada producto los completa el servidor
+type NuevoPedido struct {
+	ProductosElegidos []NuevoProductoPedido `json:"productos_elegidos"`
+	CiudadDestino     string                `json:"ciudad_destino"`
+}
+
+type NuevoProductoPedido struct {
+	CodigoProducto string `json:"codigo_producto"`
+	Cantidad       int    `json:"cantidad"`
+}
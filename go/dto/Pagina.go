@@ -0,0 +1,26 @@
+package dto
+
+import "TPIntegrador/model"
+
+// Respuesta de un listado paginado. El cursor siguiente es nulo en la ultima pagina
+type Pagina struct {
+	Items           interface{} `json:"items"`
+	SiguienteCursor *string     `json:"next_cursor"`
+	Total           int64       `json:"total"`
+}
+
+// Crea el dto a partir del modelo y de los items de la pagina
+func NewPagina(items interface{}, pagina *model.Pagina) *Pagina {
+	respuesta := &Pagina{Items: items}
+
+	if pagina == nil {
+		return respuesta
+	}
+
+	respuesta.Total = pagina.Total
+	if pagina.SiguienteCursor != "" {
+		respuesta.SiguienteCursor = &pagina.SiguienteCursor
+	}
+
+	return respuesta
+}
@@ -5,22 +5,28 @@ import (
 )
 
 type Parada struct {
-	Ciudad       string `json:"ciudad"`
-	KmRecorridos int    `json:"km_recorridos"`
+	Ciudad              string   `json:"ciudad"`
+	KmRecorridos        int      `json:"km_recorridos"`
+	PedidosEntregados   []string `json:"pedidos_entregados"`
+	PedidosNoEntregados []string `json:"pedidos_no_entregados"`
 }
 
 // Metodo para obtener el modelo a partir del dto
 func (parada Parada) GetModel() model.Parada {
 	return model.Parada{
-		Ciudad:       parada.Ciudad,
-		KmRecorridos: parada.KmRecorridos,
+		Ciudad:              parada.Ciudad,
+		KmRecorridos:        parada.KmRecorridos,
+		PedidosEntregados:   parada.PedidosEntregados,
+		PedidosNoEntregados: parada.PedidosNoEntregados,
 	}
 }
 
 // Metodo para crear un dto a partir del modelo
 func NewParada(parada *model.Parada) *Parada {
 	return &Parada{
-		Ciudad:       parada.Ciudad,
-		KmRecorridos: parada.KmRecorridos,
+		Ciudad:              parada.Ciudad,
+		KmRecorridos:        parada.KmRecorridos,
+		PedidosEntregados:   parada.PedidosEntregados,
+		PedidosNoEntregados: parada.PedidosNoEntregados,
 	}
 }
@@ -14,6 +14,12 @@ type Pedido struct {
 	FechaCreacion            time.Time          `json:"fecha_creacion"`
 	FechaUltimaActualizacion time.Time          `json:"fecha_ultima_actualizacion"`
 	IdCreador                string             `json:"id_creador"`
+	Entrega                  *EntregaPedido     `json:"entrega,omitempty"`
+	Devoluciones             []Devolucion       `json:"devoluciones,omitempty"`
+	IdPedidoPadre            string             `json:"id_pedido_padre,omitempty"`
+	IdPedidoHijo             string             `json:"id_pedido_hijo,omitempty"`
+	Revision                 int                `json:"revision"`
+	Version                  int                `json:"version"`
 }
 
 // Metodo para obtener el modelo a partir del dto
@@ -26,6 +32,11 @@ func (pedido Pedido) GetModel() *model.Pedido {
 		FechaCreacion:            pedido.FechaCreacion,
 		FechaUltimaActualizacion: pedido.FechaUltimaActualizacion,
 		IdCreador:                pedido.IdCreador,
+		Entrega:                  pedido.Entrega.GetModel(),
+		Devoluciones:             pedido.getDevoluciones(),
+		IdPedidoPadre:            pedido.IdPedidoPadre,
+		IdPedidoHijo:             pedido.IdPedidoHijo,
+		Version:                  pedido.Version,
 	}
 }
 
@@ -39,6 +50,12 @@ func NewPedido(pedido *model.Pedido) *Pedido {
 		FechaCreacion:            pedido.FechaCreacion,
 		FechaUltimaActualizacion: pedido.FechaUltimaActualizacion,
 		IdCreador:                pedido.IdCreador,
+		Entrega:                  NewEntregaPedido(pedido.Entrega),
+		Devoluciones:             NewDevoluciones(pedido.Devoluciones),
+		IdPedidoPadre:            pedido.IdPedidoPadre,
+		IdPedidoHijo:             pedido.IdPedidoHijo,
+		Revision:                 pedido.ObtenerNumeroRevision(),
+		Version:                  pedido.Version,
 	}
 }
 
@@ -51,6 +68,15 @@ func (pedido Pedido) getProductosElegidos() []model.ProductoPedido {
 	return productosElegidos
 }
 
+// Metodo para convertir una lista de Devoluciones del dto a una lista de Devoluciones del modelo
+func (pedido Pedido) getDevoluciones() []model.Devolucion {
+	var devoluciones []model.Devolucion
+	for _, devolucion := range pedido.Devoluciones {
+		devoluciones = append(devoluciones, devolucion.GetModel())
+	}
+	return devoluciones
+}
+
 // Metodo para convertir una lista de ProductoPedido del modelo a una lista de ProductoPedido del dto
 func NewProductosPedido(productosElegidos []model.ProductoPedido) []ProductoPedido {
 	var productosElegidosDto []ProductoPedido
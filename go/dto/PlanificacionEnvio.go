@@ -0,0 +1,24 @@
+package dto
+
+// Datos que manda el cliente para que se le proponga un recorrido
+type SolicitudPlanificacionEnvio struct {
+	PatenteCamion string   `json:"patente_camion"`
+	CiudadOrigen  string   `json:"ciudad_origen"`
+	Pedidos       []string `json:"pedidos"`
+}
+
+// Recorrido propuesto para un envio, con las paradas en el orden en que conviene hacerlas
+type PlanificacionEnvio struct {
+	PatenteCamion string              `json:"patente_camion"`
+	CiudadOrigen  string              `json:"ciudad_origen"`
+	Paradas       []ParadaPlanificada `json:"paradas"`
+	KmTotales     int                 `json:"km_totales"`
+	CostoEstimado float64             `json:"costo_estimado"`
+}
+
+// Parada propuesta, con los km desde la parada anterior y los pedidos que se entregan en ella
+type ParadaPlanificada struct {
+	Ciudad       string   `json:"ciudad"`
+	KmRecorridos int      `json:"km_recorridos"`
+	Pedidos      []string `json:"pedidos"`
+}
@@ -11,12 +11,18 @@ type Producto struct {
 	TipoDeProducto           model.TipoProducto `json:"tipo_producto"`
 	Nombre                   string             `json:"nombre"`
 	PesoUnitario             float64            `json:"peso_unitario"`
+	AltoUnitario             float64            `json:"alto_unitario"`
+	AnchoUnitario            float64            `json:"ancho_unitario"`
+	LargoUnitario            float64            `json:"largo_unitario"`
+	VolumenUnitario          float64            `json:"volumen_unitario"`
 	PrecioUnitario           float64            `json:"precio_unitario"`
 	StockMinimo              int                `json:"stock_minimo"`
 	StockActual              int                `json:"stock_actual"`
+	StockReservado           int                `json:"stock_reservado"`
 	FechaCreacion            time.Time          `json:"fecha_creacion"`
 	FechaUltimaActualizacion time.Time          `json:"fecha_ultima_actualizacion"`
 	IdCreador                string             `json:"id_creador"`
+	Version                  int                `json:"version"`
 }
 
 // Crea el dto a partir del modelo
@@ -27,11 +33,17 @@ func NewProducto(producto *model.Producto) *Producto {
 		Nombre:                   producto.Nombre,
 		PrecioUnitario:           producto.PrecioUnitario,
 		PesoUnitario:             producto.PesoUnitario,
+		AltoUnitario:             producto.AltoUnitario,
+		AnchoUnitario:            producto.AnchoUnitario,
+		LargoUnitario:            producto.LargoUnitario,
+		VolumenUnitario:          producto.VolumenUnitario,
 		StockMinimo:              producto.StockMinimo,
 		StockActual:              producto.StockActual,
+		StockReservado:           producto.StockReservado,
 		FechaCreacion:            producto.FechaCreacion,
 		FechaUltimaActualizacion: producto.FechaUltimaActualizacion,
 		IdCreador:                producto.IdCreador,
+		Version:                  producto.Version,
 	}
 }
 
@@ -43,10 +55,16 @@ func (producto Producto) GetModel() *model.Producto {
 		Nombre:                   producto.Nombre,
 		PrecioUnitario:           producto.PrecioUnitario,
 		PesoUnitario:             producto.PesoUnitario,
+		AltoUnitario:             producto.AltoUnitario,
+		AnchoUnitario:            producto.AnchoUnitario,
+		LargoUnitario:            producto.LargoUnitario,
+		VolumenUnitario:          producto.VolumenUnitario,
 		StockMinimo:              producto.StockMinimo,
 		StockActual:              producto.StockActual,
+		StockReservado:           producto.StockReservado,
 		FechaCreacion:            producto.FechaCreacion,
 		FechaUltimaActualizacion: producto.FechaUltimaActualizacion,
 		IdCreador:                producto.IdCreador,
+		Version:                  producto.Version,
 	}
 }
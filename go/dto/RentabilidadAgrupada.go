@@ -0,0 +1,26 @@
+package dto
+
+import "TPIntegrador/model"
+
+type RentabilidadAgrupada struct {
+	Clave            string  `json:"clave"`
+	Nombre           string  `json:"nombre"`
+	CantidadPedidos  int     `json:"cantidad_pedidos"`
+	PesoTransportado float64 `json:"peso_transportado"`
+	Ingresos         float64 `json:"ingresos"`
+	Costos           float64 `json:"costos"`
+	MargenNeto       float64 `json:"margen_neto"`
+}
+
+// Crea el dto a partir del modelo
+func NewRentabilidadAgrupada(rentabilidad *model.RentabilidadAgrupada) *RentabilidadAgrupada {
+	return &RentabilidadAgrupada{
+		Clave:            rentabilidad.Clave,
+		Nombre:           rentabilidad.Nombre,
+		CantidadPedidos:  rentabilidad.CantidadPedidos,
+		PesoTransportado: rentabilidad.PesoTransportado,
+		Ingresos:         rentabilidad.Ingresos,
+		Costos:           rentabilidad.Costos,
+		MargenNeto:       rentabilidad.MargenNeto,
+	}
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "TPIntegrador/model"
+
+type RentabilidadCamion struct {
+	Patente             string  `json:"patente"`
+	CantidadEnvios      int     `json:"cantidad_envios"`
+	KmRecorridos        int     `json:"km_recorridos"`
+	PesoTransportado    float64 `json:"peso_transportado"`
+	FactorCargaPromedio float64 `json:"factor_carga_promedio"`
+	Ingresos            float64 `json:"ingresos"`
+	Costos              float64 `json:"costos"`
+	MargenNeto          float64 `json:"margen_neto"`
+}
+
+// Crea el dto a partir del modelo
+func NewRentabilidadCamion(rentabilidad *model.RentabilidadCamion) *RentabilidadCamion {
+	return &RentabilidadCamion{
+		Patente:             rentabilidad.Patente,
+		CantidadEnvios:      rentabilidad.CantidadEnvios,
+		KmRecorridos:        rentabilidad.KmRecorridos,
+		PesoTransportado:    rentabilidad.PesoTransportado,
+		FactorCargaPromedio: rentabilidad.FactorCargaPromedio,
+		Ingresos:            rentabilidad.Ingresos,
+		Costos:              rentabilidad.Costos,
+		MargenNeto:          rentabilidad.MargenNeto,
+	}
+}
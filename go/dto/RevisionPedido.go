@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+type RevisionPedido struct {
+	Numero            int              `json:"numero"`
+	ProductosElegidos []ProductoPedido `json:"productos_elegidos"`
+	CiudadDestino     string           `json:"ciudad_destino"`
+	FechaCreacion     time.Time        `json:"fecha_creacion"`
+	IdCreador         string           `json:"id_creador"`
+}
+
+// Crea el dto a partir del modelo
+func NewRevisionPedido(revision *model.RevisionPedido) *RevisionPedido {
+	return &RevisionPedido{
+		Numero:            revision.Numero,
+		ProductosElegidos: NewProductosPedido(revision.ProductosElegidos),
+		CiudadDestino:     revision.CiudadDestino,
+		FechaCreacion:     revision.FechaCreacion,
+		IdCreador:         revision.IdCreador,
+	}
+}
+
+// Metodo para convertir una lista de revisiones del modelo a una lista de revisiones del dto
+func NewRevisionesPedido(revisiones []model.RevisionPedido) []RevisionPedido {
+	revisionesDto := make([]RevisionPedido, 0)
+	for _, revision := range revisiones {
+		revisionesDto = append(revisionesDto, *NewRevisionPedido(&revision))
+	}
+	return revisionesDto
+}
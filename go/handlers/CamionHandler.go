@@ -5,6 +5,8 @@ import (
 	"TPIntegrador/services"
 	"TPIntegrador/utils"
 	"TPIntegrador/utils/logging"
+	"errors"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,10 +22,16 @@ func NewCamionHandler(camionService services.CamionServiceInterface) *CamionHand
 func (handler *CamionHandler) ObtenerCamiones(c *gin.Context) {
 	user := dto.NewUser(utils.GetUserInfoFromContext(c))
 
-	//Creo un filtro vacio
-	filtro := utils.FiltroCamion{}
+	paginacion, err := leerPaginacion(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "ObtenerCamiones", err, &user)
+		return
+	}
 
-	camiones, err := handler.camionService.ObtenerCamiones(filtro)
+	//Creo un filtro sin condiciones, solo con la paginacion
+	filtro := utils.FiltroCamion{Paginacion: paginacion}
+
+	camiones, pagina, err := handler.camionService.ObtenerCamiones(filtro)
 
 	//Si hay un error, lo devolvemos
 	if err != nil {
@@ -32,7 +40,7 @@ func (handler *CamionHandler) ObtenerCamiones(c *gin.Context) {
 	}
 
 	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "CamionHandler", "ObtenerCamiones", camiones, &user)
+	logging.LoggearResultadoYResponder(c, "CamionHandler", "ObtenerCamiones", obtenerRespuestaListado(camiones, pagina, paginacion), &user)
 }
 
 func (handler *CamionHandler) ObtenerCamionPorPatente(c *gin.Context) {
@@ -43,9 +51,7 @@ func (handler *CamionHandler) ObtenerCamionPorPatente(c *gin.Context) {
 	//Creamos el filtro
 	filtro := utils.FiltroCamion{Patente: patente}
 
-	listaCamiones, err := handler.camionService.ObtenerCamiones(filtro)
-
-	camion := listaCamiones[0]
+	listaCamiones, _, err := handler.camionService.ObtenerCamiones(filtro)
 
 	//Si hay un error, lo devolvemos
 	if err != nil {
@@ -53,6 +59,16 @@ func (handler *CamionHandler) ObtenerCamionPorPatente(c *gin.Context) {
 		return
 	}
 
+	if len(listaCamiones) == 0 {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "ObtenerCamionPorPatente", errors.New("no existe el camion"), &user)
+		return
+	}
+
+	camion := listaCamiones[0]
+
+	//La version va como ETag, para que el cliente la pueda mandar en el If-Match al modificarlo
+	c.Header("ETag", utils.GetETagFromVersion(camion.Version))
+
 	//Agregamos un log para indicar información relevante del resultado
 	logging.LoggearResultadoYResponder(c, "CamionHandler", "ObtenerCamionPorPatente", camion, &user)
 }
@@ -85,6 +101,18 @@ func (handler *CamionHandler) ActualizarCamion(c *gin.Context) {
 	err := c.ShouldBindJSON(&camion)
 	if err != nil {
 		logging.LoggearErrorYResponder(c, "CamionHandler", "ActualizarCamion", err, &user)
+		return
+	}
+
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "ActualizarCamion", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del camion en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		camion.Version = version
 	}
 
 	//Pasamos el camion para actualizar al service
@@ -103,11 +131,18 @@ func (handler *CamionHandler) EliminarCamion(c *gin.Context) {
 
 	patente := c.Param("patente")
 
+	//Si el cliente manda la version que leyo, solo se elimina si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "EliminarCamion", err, &user)
+		return
+	}
+
 	//Generamos el objeto camion
-	camionConPatente := dto.Camion{Patente: patente}
+	camionConPatente := dto.Camion{Patente: patente, Version: version}
 
 	//Si hay un error, lo devolvemos
-	err := handler.camionService.EliminarCamion(&camionConPatente, &user)
+	err = handler.camionService.EliminarCamion(&camionConPatente, &user)
 	if err != nil {
 		logging.LoggearErrorYResponder(c, "CamionHandler", "EliminarCamion", err, &user)
 		return
@@ -116,3 +151,55 @@ func (handler *CamionHandler) EliminarCamion(c *gin.Context) {
 	//Agregamos un log para indicar información relevante del resultado
 	logging.LoggearResultadoYResponder(c, "CamionHandler", "EliminarCamion", true, &user)
 }
+
+// Carga o renueva un documento del camion, como el seguro o la VTV
+func (handler *CamionHandler) GuardarDocumentoCamion(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	var documento dto.DocumentoCamion
+	err := c.ShouldBindJSON(&documento)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "GuardarDocumentoCamion", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version que leyo, solo se guarda si el camion sigue siendo el mismo
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "GuardarDocumentoCamion", err, &user)
+		return
+	}
+
+	camion := dto.Camion{Patente: c.Param("patente"), Version: version}
+
+	err = handler.camionService.GuardarDocumentoCamion(&camion, &documento, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "GuardarDocumentoCamion", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "CamionHandler", "GuardarDocumentoCamion", true, &user)
+}
+
+// Obtiene los documentos que vencen dentro de los proximos dias (30 si no se indican), y los que ya vencieron
+func (handler *CamionHandler) ObtenerDocumentosPorVencer(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	dias, err := strconv.Atoi(c.DefaultQuery("dias", "30"))
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "ObtenerDocumentosPorVencer", err, &user)
+		return
+	}
+
+	documentos, err := handler.camionService.ObtenerDocumentosPorVencer(dias)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CamionHandler", "ObtenerDocumentosPorVencer", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "CamionHandler", "ObtenerDocumentosPorVencer", documentos, &user)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/services"
+	"TPIntegrador/utils"
+	"TPIntegrador/utils/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CiudadHandler struct {
+	ciudadService services.CiudadServiceInterface
+}
+
+func NewCiudadHandler(ciudadService services.CiudadServiceInterface) *CiudadHandler {
+	return &CiudadHandler{ciudadService: ciudadService}
+}
+
+func (handler *CiudadHandler) ObtenerCiudades(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	ciudades, err := handler.ciudadService.ObtenerCiudades()
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CiudadHandler", "ObtenerCiudades", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "CiudadHandler", "ObtenerCiudades", ciudades, &user)
+}
+
+func (handler *CiudadHandler) ImportarDistancias(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//El archivo CSV o JSON llega como multipart
+	archivo, err := leerArchivoAdjunto(c, "archivo", services.TamañoMaximoArchivoDistancias)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CiudadHandler", "ImportarDistancias", err, &user)
+		return
+	}
+
+	importacion, err := handler.ciudadService.ImportarDistancias(archivo, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "CiudadHandler", "ImportarDistancias", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "CiudadHandler", "ImportarDistancias", importacion, &user)
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/services"
+	"TPIntegrador/utils"
+	"TPIntegrador/utils/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConductorHandler struct {
+	conductorService services.ConductorServiceInterface
+}
+
+func NewConductorHandler(conductorService services.ConductorServiceInterface) *ConductorHandler {
+	return &ConductorHandler{conductorService: conductorService}
+}
+
+func (handler *ConductorHandler) ObtenerConductores(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	conductores, err := handler.conductorService.ObtenerConductores()
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ObtenerConductores", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "ObtenerConductores", conductores, &user)
+}
+
+func (handler *ConductorHandler) ObtenerConductorPorCodigo(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	conductor, err := handler.conductorService.ObtenerConductorPorCodigo(&dto.Conductor{CodigoUsuario: c.Param("codigo")})
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ObtenerConductorPorCodigo", err, &user)
+		return
+	}
+
+	//La version va como ETag, para que el cliente la pueda mandar en el If-Match al modificarlo
+	c.Header("ETag", utils.GetETagFromVersion(conductor.Version))
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "ObtenerConductorPorCodigo", conductor, &user)
+}
+
+func (handler *ConductorHandler) CrearConductor(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	var conductor dto.Conductor
+	err := c.ShouldBindJSON(&conductor)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "CrearConductor", err, &user)
+		return
+	}
+
+	err = handler.conductorService.CrearConductor(&conductor, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "CrearConductor", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "CrearConductor", true, &user)
+}
+
+func (handler *ConductorHandler) ActualizarConductor(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	var conductor dto.Conductor
+	err := c.ShouldBindJSON(&conductor)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ActualizarConductor", err, &user)
+		return
+	}
+
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ActualizarConductor", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del conductor en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		conductor.Version = version
+	}
+
+	err = handler.conductorService.ActualizarConductor(&conductor, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ActualizarConductor", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "ActualizarConductor", true, &user)
+}
+
+func (handler *ConductorHandler) EliminarConductor(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Si el cliente manda la version que leyo, solo se elimina si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "EliminarConductor", err, &user)
+		return
+	}
+
+	conductor := dto.Conductor{CodigoUsuario: c.Param("codigo"), Version: version}
+
+	err = handler.conductorService.EliminarConductor(&conductor, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "EliminarConductor", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "EliminarConductor", true, &user)
+}
+
+// Obtiene las asignaciones de camiones, pudiendo filtrarlas por camion, conductor y fecha de vigencia
+func (handler *ConductorHandler) ObtenerAsignaciones(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Tomo la fecha en 0001-01-01 como la ausencia de filtro
+	vigenteEnStr := c.DefaultQuery("vigenteEn", "0001-01-01")
+	vigenteEn, err := time.Parse("2006-01-02", vigenteEnStr)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ObtenerAsignaciones", err, &user)
+		return
+	}
+
+	filtro := utils.FiltroAsignacionCamion{
+		PatenteCamion:   c.DefaultQuery("patente", ""),
+		CodigoConductor: c.DefaultQuery("conductor", ""),
+		VigenteEn:       vigenteEn,
+	}
+
+	asignaciones, err := handler.conductorService.ObtenerAsignaciones(filtro)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "ObtenerAsignaciones", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "ObtenerAsignaciones", asignaciones, &user)
+}
+
+func (handler *ConductorHandler) AsignarCamion(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Recibimos el camion, el conductor y el periodo de la asignacion
+	var asignacion dto.AsignacionCamion
+	err := c.ShouldBindJSON(&asignacion)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "AsignarCamion", err, &user)
+		return
+	}
+
+	err = handler.conductorService.AsignarCamion(&asignacion, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "AsignarCamion", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "AsignarCamion", asignacion, &user)
+}
+
+func (handler *ConductorHandler) FinalizarAsignacion(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//El body es opcional: si no trae la fecha hasta, la asignacion termina en este momento
+	var asignacion dto.AsignacionCamion
+	if c.Request.ContentLength > 0 {
+		err := c.ShouldBindJSON(&asignacion)
+		if err != nil {
+			logging.LoggearErrorYResponder(c, "ConductorHandler", "FinalizarAsignacion", err, &user)
+			return
+		}
+	}
+
+	//El id de la asignacion lo tomamos de la ruta
+	asignacion.Id = c.Param("id")
+
+	err := handler.conductorService.FinalizarAsignacion(&asignacion, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ConductorHandler", "FinalizarAsignacion", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ConductorHandler", "FinalizarAsignacion", true, &user)
+}
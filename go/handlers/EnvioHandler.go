@@ -4,6 +4,7 @@ import (
 	"TPIntegrador/dto"
 	"TPIntegrador/services"
 	"TPIntegrador/utils"
+	"TPIntegrador/utils/exportacion"
 	"TPIntegrador/utils/logging"
 	"TPIntegrador/model"
 	"time"
@@ -42,6 +43,12 @@ func (handler *EnvioHandler) ObtenerEnvios(c *gin.Context) {
 		logging.LoggearErrorYResponder(c, "EnvioHandler", "ObtenerEnvios", err, &user)
 	}
 
+	paginacion, err := leerPaginacion(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "ObtenerEnvios", err, &user)
+		return
+	}
+
 	//Creamos el filtro
 	filtro := utils.FiltroEnvio{
 		PatenteCamion:      patente,
@@ -49,10 +56,11 @@ func (handler *EnvioHandler) ObtenerEnvios(c *gin.Context) {
 		UltimaParada:       ultimaParada,
 		FechaCreacionDesde: fechaCreacionDesde,
 		FechaCreacionHasta: fechaCreacionHasta,
+		Paginacion:         paginacion,
 	}
 
 	//Llama al service
-	envios, err := handler.envioService.ObtenerEnvios(filtro)
+	envios, pagina, err := handler.envioService.ObtenerEnvios(filtro)
 
 	//Si hay un error, lo devolvemos
 	if err != nil {
@@ -61,7 +69,8 @@ func (handler *EnvioHandler) ObtenerEnvios(c *gin.Context) {
 	}
 
 	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ObtenerEnvios", envios, &user)
+	respuesta := obtenerRespuestaListado(envios, pagina, paginacion)
+	responderEnFormato(c, "EnvioHandler", "ObtenerEnvios", respuesta, func() exportacion.Documento { return documentoEnvios(envios) }, &user)
 }
 
 func (handler *EnvioHandler) ObtenerEnvioPorId(c *gin.Context) {
@@ -77,51 +86,11 @@ func (handler *EnvioHandler) ObtenerEnvioPorId(c *gin.Context) {
 		return
 	}
 
-	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ObtenerEnvioPorId", envio, &user)
-}
-
-func (handler *EnvioHandler) ObtenerBeneficioEntreFechas(c *gin.Context) {
-	user := dto.NewUser(utils.GetUserInfoFromContext(c))
-
-	//Convierte las fechas string a time.Time
-	fechaDesdeStr := c.DefaultQuery("fechaDesde", "0001-01-01")
-	fechaDesde, err := time.Parse("2006-01-02", fechaDesdeStr)
-	if err != nil {
-		logging.LoggearErrorYResponder(c, "EnvioHandler", "ObtenerBeneficioEntreFechas", err, &user)
-		return
-	}
-
-	//Convertimos la fecha a un time con hora 0
-	fechaDesde = time.Date(fechaDesde.Year(), fechaDesde.Month(), fechaDesde.Day(), 0, 0, 0, 0, fechaDesde.Location())
-
-	fechaHastaStr := c.DefaultQuery("fechaHasta", "0001-01-01")
-	fechaHasta, err := time.Parse("2006-01-02", fechaHastaStr)
-	if err != nil {
-		logging.LoggearErrorYResponder(c, "EnvioHandler", "ObtenerBeneficioEntreFechas", err, &user)
-		return
-	}
-
-	//Convertimos la fecha a un time con hora 0
-	fechaHasta = time.Date(fechaHasta.Year(), fechaHasta.Month(), fechaHasta.Day(), 0, 0, 0, 0, fechaHasta.Location())
-
-	//Creamos el filtro, que tiene en cuenta solamente las fechas
-	filtro := utils.FiltroEnvio{
-		FechaUltimaActualizacionDesde: fechaDesde,
-		FechaUltimaActualizacionHasta: fechaHasta,
-	}
-
-	//Llama al service
-	beneficioTemporal, err := handler.envioService.ObtenerBeneficioTemporal(filtro)
-
-	//Si hay un error, lo devolvemos
-	if err != nil {
-		logging.LoggearErrorYResponder(c, "EnvioHandler", "ObtenerBeneficioEntreFechas", err, &user)
-		return
-	}
+	//La version va como ETag, para que el cliente la pueda mandar en el If-Match al modificarlo
+	c.Header("ETag", utils.GetETagFromVersion(envio.Version))
 
 	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ObtenerBeneficioEntreFechas", beneficioTemporal, &user)
+	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ObtenerEnvioPorId", envio, &user)
 }
 
 func (handler *EnvioHandler) ObtenerCantidadEnviosPorEstado(c *gin.Context) {
@@ -137,7 +106,9 @@ func (handler *EnvioHandler) ObtenerCantidadEnviosPorEstado(c *gin.Context) {
 	}
 
 	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ObtenerCantidadEnviosPorEstado", cantidades, &user)
+	responderEnFormato(c, "EnvioHandler", "ObtenerCantidadEnviosPorEstado", cantidades, func() exportacion.Documento {
+		return documentoCantidadesPorEstado("envios_por_estado", "Envios por estado", cantidades)
+	}, &user)
 }
 
 func (handler *EnvioHandler) CrearEnvio(c *gin.Context) {
@@ -172,6 +143,17 @@ func (handler *EnvioHandler) AgregarParada(c *gin.Context) {
 		return
 	}
 
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "AgregarParada", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del envio en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		parada.VersionEnvio = version
+	}
+
 	operacion, err := handler.envioService.AgregarParada(&parada, &user)
 	if err != nil || !operacion {
 		logging.LoggearErrorYResponder(c, "EnvioHandler", "AgregarParada", err, &user)
@@ -194,6 +176,17 @@ func (handler *EnvioHandler) CambiarEstadoEnvio(c *gin.Context) {
 		return
 	}
 
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "CambiarEstadoEnvio", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del envio en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		envio.Version = version
+	}
+
 	operacion, err := handler.envioService.CambiarEstadoEnvio(&envio, &user)
 	if err != nil || !operacion {
 		logging.LoggearErrorYResponder(c, "EnvioHandler", "CambiarEstadoEnvio", err, &user)
@@ -203,3 +196,66 @@ func (handler *EnvioHandler) CambiarEstadoEnvio(c *gin.Context) {
 	//Agregamos un log para indicar información relevante del resultado
 	logging.LoggearResultadoYResponder(c, "EnvioHandler", "CambiarEstadoEnvio", true, &user)
 }
+
+func (handler *EnvioHandler) PlanificarEnvio(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Recibimos el camion, la ciudad de origen y los pedidos a entregar
+	var solicitud dto.SolicitudPlanificacionEnvio
+	err := c.ShouldBindJSON(&solicitud)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "PlanificarEnvio", err, &user)
+		return
+	}
+
+	planificacion, err := handler.envioService.PlanificarEnvio(&solicitud, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "PlanificarEnvio", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "EnvioHandler", "PlanificarEnvio", planificacion, &user)
+}
+
+func (handler *EnvioHandler) ConsolidarEnvios(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Recibimos la ciudad desde donde salen los camiones
+	var solicitud dto.SolicitudConsolidacionEnvios
+	err := c.ShouldBindJSON(&solicitud)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "ConsolidarEnvios", err, &user)
+		return
+	}
+
+	consolidacion, err := handler.envioService.ConsolidarEnvios(&solicitud, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "ConsolidarEnvios", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ConsolidarEnvios", consolidacion, &user)
+}
+
+func (handler *EnvioHandler) ConfirmarEnvios(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Recibimos los envios propuestos que se quieren crear
+	var envios []dto.EnvioPropuesto
+	err := c.ShouldBindJSON(&envios)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "ConfirmarEnvios", err, &user)
+		return
+	}
+
+	resultados, err := handler.envioService.ConfirmarEnvios(envios, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "EnvioHandler", "ConfirmarEnvios", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "EnvioHandler", "ConfirmarEnvios", resultados, &user)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/utils"
+	"TPIntegrador/utils/exportacion"
+	"TPIntegrador/utils/logging"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Responde el resultado en JSON o, si se pidio con el parametro formato o el header Accept, como archivo CSV, XLSX o PDF.
+// El documento solo se arma si hace falta exportarlo
+func responderEnFormato(c *gin.Context, handler string, metodo string, resultado interface{}, armarDocumento func() exportacion.Documento, user *dto.User) {
+	formato, err := exportacion.ObtenerFormato(c.Query("formato"), c.GetHeader("Accept"))
+	if err != nil {
+		logging.LoggearErrorYResponder(c, handler, metodo, err, user)
+		return
+	}
+
+	if formato == exportacion.JSON {
+		logging.LoggearResultadoYResponder(c, handler, metodo, resultado, user)
+		return
+	}
+
+	archivo, err := exportacion.Exportar(armarDocumento(), formato)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, handler, metodo, err, user)
+		return
+	}
+
+	logging.LoggearArchivoYResponder(c, handler, metodo, archivo, user)
+}
+
+func documentoEnvios(envios []*dto.Envio) exportacion.Documento {
+	tabla := exportacion.Tabla{
+		Titulo: "Envios",
+		Columnas: []exportacion.Columna{
+			{Titulo: "Id"},
+			{Titulo: "Camion"},
+			{Titulo: "Estado"},
+			{Titulo: "Conductor"},
+			{Titulo: "Ultima parada"},
+			{Titulo: "Paradas", Totalizar: true},
+			{Titulo: "Km recorridos", Totalizar: true},
+			{Titulo: "Pedidos", Totalizar: true},
+			{Titulo: "Fecha creacion"},
+			{Titulo: "Ultima actualizacion"},
+		},
+	}
+
+	for _, envio := range envios {
+		modelo := envio.GetModel()
+
+		ultimaParada := ""
+		if len(envio.Paradas) > 0 {
+			ultimaParada = envio.Paradas[len(envio.Paradas)-1].Ciudad
+		}
+
+		tabla.Filas = append(tabla.Filas, []interface{}{
+			envio.Id,
+			envio.PatenteCamion,
+			string(envio.Estado),
+			envio.IdConductor,
+			ultimaParada,
+			len(envio.Paradas),
+			modelo.ObtenerKmRecorridos(),
+			len(envio.Pedidos),
+			envio.FechaCreacion,
+			envio.FechaUltimaActualizacion,
+		})
+	}
+
+	return exportacion.Documento{Nombre: "envios", Titulo: "Envios", Tablas: []exportacion.Tabla{tabla}}
+}
+
+func documentoPedidos(pedidos []*dto.Pedido) exportacion.Documento {
+	tabla := exportacion.Tabla{
+		Titulo: "Pedidos",
+		Columnas: []exportacion.Columna{
+			{Titulo: "Id"},
+			{Titulo: "Estado"},
+			{Titulo: "Ciudad destino"},
+			{Titulo: "Productos", Totalizar: true},
+			{Titulo: "Peso total", Totalizar: true},
+			{Titulo: "Precio total", Totalizar: true},
+			{Titulo: "Fecha creacion"},
+			{Titulo: "Ultima actualizacion"},
+		},
+	}
+
+	for _, pedido := range pedidos {
+		tabla.Filas = append(tabla.Filas, []interface{}{
+			pedido.Id,
+			string(pedido.Estado),
+			pedido.CiudadDestino,
+			len(pedido.ProductosElegidos),
+			pedido.GetModel().ObtenerPesoTotal(),
+			pedido.ObtenerPecioTotal(),
+			pedido.FechaCreacion,
+			pedido.FechaUltimaActualizacion,
+		})
+	}
+
+	return exportacion.Documento{Nombre: "pedidos", Titulo: "Pedidos", Tablas: []exportacion.Tabla{tabla}}
+}
+
+func documentoProductos(productos []dto.Producto) exportacion.Documento {
+	tabla := exportacion.Tabla{
+		Titulo: "Productos",
+		Columnas: []exportacion.Columna{
+			{Titulo: "Codigo"},
+			{Titulo: "Nombre"},
+			{Titulo: "Tipo"},
+			{Titulo: "Precio unitario"},
+			{Titulo: "Peso unitario"},
+			{Titulo: "Volumen unitario"},
+			{Titulo: "Stock minimo"},
+			{Titulo: "Stock actual", Totalizar: true},
+			{Titulo: "Stock reservado", Totalizar: true},
+			{Titulo: "Valor del stock", Totalizar: true},
+		},
+	}
+
+	for _, producto := range productos {
+		tabla.Filas = append(tabla.Filas, []interface{}{
+			producto.CodigoProducto,
+			producto.Nombre,
+			string(producto.TipoDeProducto),
+			producto.PrecioUnitario,
+			producto.PesoUnitario,
+			producto.VolumenUnitario,
+			producto.StockMinimo,
+			producto.StockActual,
+			producto.StockReservado,
+			producto.PrecioUnitario * float64(producto.StockActual),
+		})
+	}
+
+	return exportacion.Documento{Nombre: "productos", Titulo: "Productos", Tablas: []exportacion.Tabla{tabla}}
+}
+
+func documentoCantidadesPorEstado(nombre string, titulo string, cantidades []utils.CantidadEstado) exportacion.Documento {
+	tabla := exportacion.Tabla{
+		Titulo: titulo,
+		Columnas: []exportacion.Columna{
+			{Titulo: "Estado"},
+			{Titulo: "Cantidad", Totalizar: true},
+		},
+	}
+
+	for _, cantidad := range cantidades {
+		tabla.Filas = append(tabla.Filas, []interface{}{cantidad.Estado, cantidad.Cantidad})
+	}
+
+	return exportacion.Documento{Nombre: nombre, Titulo: titulo, Tablas: []exportacion.Tabla{tabla}}
+}
+
+func documentoRentabilidadCamiones(rentabilidades []*dto.RentabilidadCamion) exportacion.Documento {
+	tabla := exportacion.Tabla{
+		Titulo: "Rentabilidad por camion",
+		Columnas: []exportacion.Columna{
+			{Titulo: "Camion"},
+			{Titulo: "Envios", Totalizar: true},
+			{Titulo: "Km recorridos", Totalizar: true},
+			{Titulo: "Peso transportado", Totalizar: true},
+			{Titulo: "Factor de carga"},
+			{Titulo: "Ingresos", Totalizar: true},
+			{Titulo: "Costos", Totalizar: true},
+			{Titulo: "Margen neto", Totalizar: true},
+		},
+	}
+
+	for _, rentabilidad := range rentabilidades {
+		tabla.Filas = append(tabla.Filas, []interface{}{
+			rentabilidad.Patente,
+			rentabilidad.CantidadEnvios,
+			rentabilidad.KmRecorridos,
+			rentabilidad.PesoTransportado,
+			rentabilidad.FactorCargaPromedio,
+			rentabilidad.Ingresos,
+			rentabilidad.Costos,
+			rentabilidad.MargenNeto,
+		})
+	}
+
+	return exportacion.Documento{Nombre: "rentabilidad_camiones", Titulo: tabla.Titulo, Tablas: []exportacion.Tabla{tabla}}
+}
+
+func documentoRentabilidadAgrupada(agruparPor string, rentabilidades []*dto.RentabilidadAgrupada) exportacion.Documento {
+	tabla := exportacion.Tabla{
+		Titulo: "Rentabilidad por " + agruparPor,
+		Columnas: []exportacion.Columna{
+			{Titulo: "Clave"},
+			{Titulo: "Nombre"},
+			{Titulo: "Pedidos"},
+			{Titulo: "Peso transportado", Totalizar: true},
+			{Titulo: "Ingresos", Totalizar: true},
+			{Titulo: "Costos", Totalizar: true},
+			{Titulo: "Margen neto", Totalizar: true},
+		},
+	}
+
+	for _, rentabilidad := range rentabilidades {
+		tabla.Filas = append(tabla.Filas, []interface{}{
+			rentabilidad.Clave,
+			rentabilidad.Nombre,
+			rentabilidad.CantidadPedidos,
+			rentabilidad.PesoTransportado,
+			rentabilidad.Ingresos,
+			rentabilidad.Costos,
+			rentabilidad.MargenNeto,
+		})
+	}
+
+	return exportacion.Documento{Nombre: "rentabilidad_" + agruparPor, Titulo: tabla.Titulo, Tablas: []exportacion.Tabla{tabla}}
+}
+
+// Una tabla por cada agrupacion del beneficio, cada una con su total
+func documentoBeneficioTemporal(beneficio dto.BeneficioTemporal) exportacion.Documento {
+	columnas := func(periodo string) []exportacion.Columna {
+		return []exportacion.Columna{{Titulo: periodo}, {Titulo: "Beneficio", Totalizar: true}}
+	}
+
+	anuales := exportacion.Tabla{Titulo: "Beneficio anual", Columnas: columnas("Año")}
+	for _, beneficioAnual := range beneficio.BeneficiosAnuales {
+		anuales.Filas = append(anuales.Filas, []interface{}{strconv.Itoa(beneficioAnual.Año), beneficioAnual.Monto})
+	}
+
+	mensuales := exportacion.Tabla{Titulo: "Beneficio mensual", Columnas: columnas("Mes")}
+	for _, beneficioMensual := range beneficio.BeneficiosMensuales {
+		mensuales.Filas = append(mensuales.Filas, []interface{}{strconv.Itoa(beneficioMensual.Mes), beneficioMensual.Monto})
+	}
+
+	semanales := exportacion.Tabla{Titulo: "Beneficio semanal", Columnas: columnas("Semana")}
+	for _, beneficioSemanal := range beneficio.BeneficiosSemanales {
+		semanales.Filas = append(semanales.Filas, []interface{}{beneficioSemanal.Semana, beneficioSemanal.Monto})
+	}
+
+	diarios := exportacion.Tabla{Titulo: "Beneficio diario", Columnas: columnas("Dia")}
+	for _, beneficioDiario := range beneficio.BeneficiosDiarios {
+		diarios.Filas = append(diarios.Filas, []interface{}{beneficioDiario.Dia, beneficioDiario.Monto})
+	}
+
+	return exportacion.Documento{
+		Nombre: "beneficios",
+		Titulo: "Beneficio entre fechas",
+		Tablas: []exportacion.Tabla{anuales, mensuales, semanales, diarios},
+	}
+}
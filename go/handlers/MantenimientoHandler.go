@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/services"
+	"TPIntegrador/utils"
+	"TPIntegrador/utils/logging"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MantenimientoHandler struct {
+	mantenimientoService services.MantenimientoServiceInterface
+}
+
+func NewMantenimientoHandler(mantenimientoService services.MantenimientoServiceInterface) *MantenimientoHandler {
+	return &MantenimientoHandler{mantenimientoService: mantenimientoService}
+}
+
+func (handler *MantenimientoHandler) ObtenerMantenimientosCamion(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	mantenimientos, err := handler.mantenimientoService.ObtenerMantenimientosCamion(&dto.Camion{Patente: c.Param("patente")})
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "ObtenerMantenimientosCamion", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "MantenimientoHandler", "ObtenerMantenimientosCamion", mantenimientos, &user)
+}
+
+// Obtiene los camiones con mantenimientos que vencen dentro de los proximos dias (30 si no se indican)
+func (handler *MantenimientoHandler) ObtenerMantenimientosProximos(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	dias, err := strconv.Atoi(c.DefaultQuery("dias", "30"))
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "ObtenerMantenimientosProximos", err, &user)
+		return
+	}
+
+	proximos, err := handler.mantenimientoService.ObtenerMantenimientosProximos(dias)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "ObtenerMantenimientosProximos", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "MantenimientoHandler", "ObtenerMantenimientosProximos", proximos, &user)
+}
+
+func (handler *MantenimientoHandler) ProgramarMantenimiento(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	var mantenimiento dto.Mantenimiento
+	err := c.ShouldBindJSON(&mantenimiento)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "ProgramarMantenimiento", err, &user)
+		return
+	}
+
+	//La patente del camion la tomamos de la ruta
+	mantenimiento.PatenteCamion = c.Param("patente")
+
+	err = handler.mantenimientoService.ProgramarMantenimiento(&mantenimiento, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "ProgramarMantenimiento", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "MantenimientoHandler", "ProgramarMantenimiento", true, &user)
+}
+
+func (handler *MantenimientoHandler) CambiarEstadoMantenimiento(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Recibimos el nuevo estado, y si se realizo, el costo y las observaciones
+	var mantenimiento dto.Mantenimiento
+	err := c.ShouldBindJSON(&mantenimiento)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "CambiarEstadoMantenimiento", err, &user)
+		return
+	}
+
+	//El camion y el mantenimiento los tomamos de la ruta
+	mantenimiento.PatenteCamion = c.Param("patente")
+	mantenimiento.Id = c.Param("id")
+
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "CambiarEstadoMantenimiento", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del mantenimiento en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		mantenimiento.Version = version
+	}
+
+	err = handler.mantenimientoService.CambiarEstadoMantenimiento(&mantenimiento, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "MantenimientoHandler", "CambiarEstadoMantenimiento", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "MantenimientoHandler", "CambiarEstadoMantenimiento", true, &user)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maximo de resultados por pagina
+const limiteMaximoPagina = 500
+
+// Lee la paginacion del query: limit, cursor, sort (el campo por el que se ordena) y order (asc o desc)
+func leerPaginacion(c *gin.Context) (utils.Paginacion, error) {
+	paginacion := utils.Paginacion{
+		Cursor:     c.Query("cursor"),
+		OrdenarPor: c.Query("sort"),
+	}
+
+	if limiteStr := c.Query("limit"); limiteStr != "" {
+		limite, err := strconv.Atoi(limiteStr)
+		if err != nil || limite < 1 || limite > limiteMaximoPagina {
+			return paginacion, errors.New("el limit debe ser un numero entre 1 y " + strconv.Itoa(limiteMaximoPagina))
+		}
+		paginacion.Limite = limite
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		paginacion.Descendente = true
+	default:
+		return paginacion, errors.New("el order debe ser asc o desc")
+	}
+
+	return paginacion, nil
+}
+
+// Si se pidio una pagina, la respuesta es la pagina con el cursor siguiente y el total. Si no, es la lista como siempre
+func obtenerRespuestaListado(lista interface{}, pagina *dto.Pagina, paginacion utils.Paginacion) interface{} {
+	if paginacion.EsPaginada() {
+		return pagina
+	}
+	return lista
+}
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"TPIntegrador/dto"
+	"TPIntegrador/model"
 	"TPIntegrador/services"
 	"TPIntegrador/utils"
+	"TPIntegrador/utils/exportacion"
 	"TPIntegrador/utils/logging"
-	"TPIntegrador/model"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -43,16 +48,23 @@ func (handler *PedidoHandler) ObtenerPedidos(c *gin.Context) {
 		return
 	}
 
+	paginacion, err := leerPaginacion(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerPedidos", err, &user)
+		return
+	}
+
 	//Creamos el filtro con los datos obtenidos
 	filtro := utils.FiltroPedido{
 		IdEnvio:               idEnvio,
 		Estado:                model.EstadoPedido(estado),
 		FechaCreacionComienzo: fechaCreacionComienzo,
 		FechaCreacionFin:      fechaCreacionFin,
+		Paginacion:            paginacion,
 	}
 
 	//Obtenemos los pedidos
-	pedidos, err := handler.pedidoService.ObtenerPedidos(filtro)
+	pedidos, pagina, err := handler.pedidoService.ObtenerPedidos(filtro)
 
 	//Si hay un error, lo devolvemos
 	if err != nil {
@@ -61,7 +73,28 @@ func (handler *PedidoHandler) ObtenerPedidos(c *gin.Context) {
 	}
 
 	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "PedidoHandler", "ObtenerPedidos", pedidos, &user)
+	respuesta := obtenerRespuestaListado(pedidos, pagina, paginacion)
+	responderEnFormato(c, "PedidoHandler", "ObtenerPedidos", respuesta, func() exportacion.Documento { return documentoPedidos(pedidos) }, &user)
+}
+
+func (handler *PedidoHandler) ObtenerPedidoPorId(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	pedido, err := handler.pedidoService.ObtenerPedidoPorId(&dto.Pedido{Id: id})
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerPedidoPorId", err, &user)
+		return
+	}
+
+	//La version del pedido va como ETag, para que el cliente la pueda mandar en el If-Match al modificarlo
+	c.Header("ETag", utils.GetETagFromVersion(pedido.Version))
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "ObtenerPedidoPorId", pedido, &user)
 }
 
 func (handler *PedidoHandler) ObtenerCantidadPedidosPorEstado(c *gin.Context) {
@@ -76,13 +109,15 @@ func (handler *PedidoHandler) ObtenerCantidadPedidosPorEstado(c *gin.Context) {
 		return
 	}
 
-	logging.LoggearResultadoYResponder(c, "PedidoHandler", "ObtenerCantidadPedidosPorEstado", cantidades, &user)
+	responderEnFormato(c, "PedidoHandler", "ObtenerCantidadPedidosPorEstado", cantidades, func() exportacion.Documento {
+		return documentoCantidadesPorEstado("pedidos_por_estado", "Pedidos por estado", cantidades)
+	}, &user)
 }
 
 func (handler *PedidoHandler) CrearPedido(c *gin.Context) {
 	user := dto.NewUser(utils.GetUserInfoFromContext(c))
 
-	var pedido dto.Pedido
+	var pedido dto.NuevoPedido
 
 	//Parseamos el body del request y lo guardamos en el objeto pedido
 	err := c.ShouldBindJSON(&pedido)
@@ -102,16 +137,93 @@ func (handler *PedidoHandler) CrearPedido(c *gin.Context) {
 	logging.LoggearResultadoYResponder(c, "PedidoHandler", "CrearPedido", true, &user)
 }
 
+func (handler *PedidoHandler) EditarPedido(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	//Si el cliente manda la version que leyo, solo se actualiza si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "EditarPedido", err, &user)
+		return
+	}
+
+	//Parseamos el body con los nuevos productos y destino del pedido
+	var edicion dto.NuevoPedido
+	err = c.ShouldBindJSON(&edicion)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "EditarPedido", err, &user)
+		return
+	}
+
+	err = handler.pedidoService.EditarPedido(&dto.Pedido{Id: id, Version: version}, &edicion, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "EditarPedido", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "EditarPedido", true, &user)
+}
+
+func (handler *PedidoHandler) ObtenerRevisionesPedido(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	revisiones, err := handler.pedidoService.ObtenerRevisionesPedido(&dto.Pedido{Id: id})
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerRevisionesPedido", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "ObtenerRevisionesPedido", revisiones, &user)
+}
+
+func (handler *PedidoHandler) ObtenerRevisionPedido(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	numero, err := strconv.Atoi(c.Param("numero"))
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerRevisionPedido", err, &user)
+		return
+	}
+
+	revision, err := handler.pedidoService.ObtenerRevisionPedido(&dto.Pedido{Id: id}, numero)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerRevisionPedido", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "ObtenerRevisionPedido", revision, &user)
+}
+
 func (handler *PedidoHandler) AceptarPedido(c *gin.Context) {
 	user := dto.NewUser(utils.GetUserInfoFromContext(c))
 
 	id := c.Param("id")
 
+	//Si el cliente manda la version que leyo, solo se actualiza si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "AceptarPedido", err, &user)
+		return
+	}
+
 	//Generamos el objeto pedido
-	pedido := dto.Pedido{Id: id}
+	pedido := dto.Pedido{Id: id, Version: version}
 
 	//Aceptamos el pedido
-	err := handler.pedidoService.AceptarPedido(&pedido, &user)
+	err = handler.pedidoService.AceptarPedido(&pedido, &user)
 	if err != nil {
 		logging.LoggearErrorYResponder(c, "PedidoHandler", "AceptarPedido", err, &user)
 		return
@@ -126,11 +238,18 @@ func (handler *PedidoHandler) CancelarPedido(c *gin.Context) {
 
 	id := c.Param("id")
 
+	//Si el cliente manda la version que leyo, solo se actualiza si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "CancelarPedido", err, &user)
+		return
+	}
+
 	//Generamos el objeto pedido
-	pedido := dto.Pedido{Id: id}
+	pedido := dto.Pedido{Id: id, Version: version}
 
 	//Cancelamos el pedido
-	err := handler.pedidoService.CancelarPedido(&pedido, &user)
+	err = handler.pedidoService.CancelarPedido(&pedido, &user)
 	if err != nil {
 		logging.LoggearErrorYResponder(c, "PedidoHandler", "CancelarPedido", err, &user)
 		return
@@ -138,4 +257,156 @@ func (handler *PedidoHandler) CancelarPedido(c *gin.Context) {
 
 	//Agregamos un log para indicar información relevante del resultado
 	logging.LoggearResultadoYResponder(c, "PedidoHandler", "CancelarPedido", true, &user)
-}
\ No newline at end of file
+}
+
+func (handler *PedidoHandler) RegistrarDevolucion(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	//Si el cliente manda la version que leyo, solo se actualiza si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarDevolucion", err, &user)
+		return
+	}
+
+	//Parseamos el body con los productos devueltos
+	var devolucion dto.NuevaDevolucion
+	err = c.ShouldBindJSON(&devolucion)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarDevolucion", err, &user)
+		return
+	}
+
+	err = handler.pedidoService.RegistrarDevolucion(&dto.Pedido{Id: id, Version: version}, &devolucion, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarDevolucion", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "RegistrarDevolucion", true, &user)
+}
+
+func (handler *PedidoHandler) RegistrarEntrega(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	//Si el cliente manda la version que leyo, solo se actualiza si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarEntrega", err, &user)
+		return
+	}
+
+	//Los datos de la entrega llegan como multipart, junto con la firma o foto opcional
+	entrega := dto.EntregaPedido{
+		Receptor:      c.PostForm("receptor"),
+		Observaciones: c.PostForm("observaciones"),
+	}
+
+	//La fecha de entrega es opcional, si no viene se usa la de la parada
+	if fechaEntregaStr := c.PostForm("fecha_entrega"); fechaEntregaStr != "" {
+		fechaEntrega, err := time.Parse(time.RFC3339, fechaEntregaStr)
+		if err != nil {
+			logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarEntrega", err, &user)
+			return
+		}
+		entrega.FechaEntrega = fechaEntrega
+	}
+
+	archivo, err := leerArchivoAdjunto(c, "archivo", services.TamañoMaximoArchivoEntrega)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarEntrega", err, &user)
+		return
+	}
+
+	err = handler.pedidoService.RegistrarEntrega(&dto.Pedido{Id: id, Version: version}, &entrega, archivo, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "RegistrarEntrega", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "RegistrarEntrega", true, &user)
+}
+
+func (handler *PedidoHandler) ObtenerEntrega(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	entrega, err := handler.pedidoService.ObtenerEntrega(&dto.Pedido{Id: id})
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerEntrega", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "PedidoHandler", "ObtenerEntrega", entrega, &user)
+}
+
+func (handler *PedidoHandler) ObtenerArchivoEntrega(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	id := c.Param("id")
+
+	archivo, err := handler.pedidoService.ObtenerArchivoEntrega(&dto.Pedido{Id: id})
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "PedidoHandler", "ObtenerArchivoEntrega", err, &user)
+		return
+	}
+
+	//Devolvemos el archivo tal como se subio
+	logging.LoggearArchivoYResponder(c, "PedidoHandler", "ObtenerArchivoEntrega", archivo, &user)
+}
+
+// Lee el archivo del campo indicado del multipart. Si no se subio ningun archivo, devuelve nil
+func leerArchivoAdjunto(c *gin.Context, campo string, tamañoMaximo int64) (*dto.Archivo, error) {
+	cabecera, err := c.FormFile(campo)
+
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	errorTamaño := errors.New("el archivo no puede superar los " + strconv.FormatInt(tamañoMaximo>>20, 10) + " MB")
+
+	//Rechazamos el archivo antes de leerlo si ya sabemos que es demasiado grande
+	if cabecera.Size > tamañoMaximo {
+		return nil, errorTamaño
+	}
+
+	archivo, err := cabecera.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer archivo.Close()
+
+	//Leemos uno de mas para detectar los archivos que superan el limite aunque el tamaño declarado no lo indique
+	contenido, err := io.ReadAll(io.LimitReader(archivo, tamañoMaximo+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(contenido)) > tamañoMaximo {
+		return nil, errorTamaño
+	}
+
+	//El tipo se deduce del contenido, sin confiar en el que declara el cliente
+	return &dto.Archivo{
+		Nombre:    cabecera.Filename,
+		Tipo:      http.DetectContentType(contenido),
+		Contenido: contenido,
+	}, nil
+}
@@ -5,8 +5,10 @@ import (
 	"TPIntegrador/model"
 	"TPIntegrador/services"
 	"TPIntegrador/utils"
+	"TPIntegrador/utils/exportacion"
 	"TPIntegrador/utils/logging"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,13 +37,20 @@ func (handler *ProductoHandler) ObtenerProductos(c *gin.Context) {
 	//Obtiene el tipo de producto por el que se desea filtrar
 	tipoProducto := c.DefaultQuery("tipoProducto", "")
 
+	paginacion, err := leerPaginacion(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "ObtenerProductos", err, &user)
+		return
+	}
+
 	//Armamos el filtro
 	filtroProducto := utils.FiltroProducto{
 		FiltrarPorStockMinimo: filtrarPorStockMinimo,
 		TipoProducto:          model.TipoProducto(tipoProducto),
+		Paginacion:            paginacion,
 	}
 
-	productos, err := handler.productoService.ObtenerProductos(filtroProducto)
+	productos, pagina, err := handler.productoService.ObtenerProductos(filtroProducto)
 
 	//Si hay un error, lo devolvemos
 	if err != nil {
@@ -50,7 +59,8 @@ func (handler *ProductoHandler) ObtenerProductos(c *gin.Context) {
 	}
 
 	//Agregamos un log para indicar información relevante del resultado
-	logging.LoggearResultadoYResponder(c, "ProductoHandler", "ObtenerProductos", productos, &user)
+	respuesta := obtenerRespuestaListado(productos, pagina, paginacion)
+	responderEnFormato(c, "ProductoHandler", "ObtenerProductos", respuesta, func() exportacion.Documento { return documentoProductos(productos) }, &user)
 }
 
 func (handler *ProductoHandler) ObtenerProductoPorCodigo(c *gin.Context) {
@@ -70,6 +80,9 @@ func (handler *ProductoHandler) ObtenerProductoPorCodigo(c *gin.Context) {
 		return
 	}
 
+	//La version va como ETag, para que el cliente la pueda mandar en el If-Match al modificarlo
+	c.Header("ETag", utils.GetETagFromVersion(producto.Version))
+
 	//Agregamos un log para indicar información relevante del resultado
 	logging.LoggearResultadoYResponder(c, "ProductoHandler", "ObtenerProductoPorCodigo", producto, &user)
 }
@@ -110,6 +123,17 @@ func (handler *ProductoHandler) ActualizarProducto(c *gin.Context) {
 		return
 	}
 
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "ActualizarProducto", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del producto en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		producto.Version = version
+	}
+
 	//Actualizamos el producto en la base de datos
 	err = handler.productoService.ActualizarProducto(&producto, &user)
 	if err != nil {
@@ -121,6 +145,106 @@ func (handler *ProductoHandler) ActualizarProducto(c *gin.Context) {
 	logging.LoggearResultadoYResponder(c, "ProductoHandler", "ActualizarProducto", true, &user)
 }
 
+// Handler para registrar un conteo fisico del stock de un producto
+func (handler *ProductoHandler) RegistrarInventario(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	var inventario dto.Inventario
+
+	//Parseamos el body del request, que trae el stock contado
+	err := c.ShouldBindJSON(&inventario)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "RegistrarInventario", err, &user)
+		return
+	}
+
+	//El codigo del producto lo tomamos de la ruta
+	inventario.CodigoProducto = c.Param("codigo")
+
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "RegistrarInventario", err, &user)
+		return
+	}
+
+	//Si el cliente manda la version del producto en el If-Match, tiene prioridad sobre la del body
+	if version != 0 {
+		inventario.Version = version
+	}
+
+	err = handler.productoService.RegistrarInventario(&inventario, &user)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "RegistrarInventario", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ProductoHandler", "RegistrarInventario", true, &user)
+}
+
+// Obtiene los movimientos de stock de un producto, pudiendo filtrarlos por fecha
+func (handler *ProductoHandler) ObtenerMovimientosStock(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	codigo := c.Param("codigo")
+
+	//Convierte las fechas string a time.Time
+	fechaDesdeStr := c.DefaultQuery("fechaDesde", "0001-01-01")
+	fechaDesde, err := time.Parse("2006-01-02", fechaDesdeStr)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "ObtenerMovimientosStock", err, &user)
+		return
+	}
+
+	fechaHastaStr := c.DefaultQuery("fechaHasta", "0001-01-01")
+	fechaHasta, err := time.Parse("2006-01-02", fechaHastaStr)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "ObtenerMovimientosStock", err, &user)
+		return
+	}
+
+	//Incluimos todos los movimientos del dia de la fecha hasta
+	if !fechaHasta.IsZero() {
+		fechaHasta = fechaHasta.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	filtro := utils.FiltroMovimientoStock{
+		CodigoProducto: codigo,
+		FechaDesde:     fechaDesde,
+		FechaHasta:     fechaHasta,
+	}
+
+	movimientos, err := handler.productoService.ObtenerMovimientosStock(filtro)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "ObtenerMovimientosStock", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ProductoHandler", "ObtenerMovimientosStock", movimientos, &user)
+}
+
+// Compara el stock del producto con el que resulta de sus movimientos
+func (handler *ProductoHandler) ConciliarStock(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Creamos el objeto producto con el codigo de la ruta
+	productoConCodigo := dto.Producto{CodigoProducto: c.Param("codigo")}
+
+	conciliacion, err := handler.productoService.ConciliarStock(&productoConCodigo)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "ConciliarStock", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	logging.LoggearResultadoYResponder(c, "ProductoHandler", "ConciliarStock", conciliacion, &user)
+}
+
 // Handler para eliminar un producto
 func (handler *ProductoHandler) EliminarProducto(c *gin.Context) {
 	user := dto.NewUser(utils.GetUserInfoFromContext(c))
@@ -128,11 +252,18 @@ func (handler *ProductoHandler) EliminarProducto(c *gin.Context) {
 	//Recibimos el codigo del producto a eliminar
 	codigo := c.Param("codigo")
 
+	//Si el cliente manda la version que leyo, solo se elimina si sigue siendo la misma
+	version, err := utils.GetVersionFromIfMatch(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ProductoHandler", "EliminarProducto", err, &user)
+		return
+	}
+
 	//Creamos el objeto producto
-	producto := dto.Producto{CodigoProducto: codigo}
+	producto := dto.Producto{CodigoProducto: codigo, Version: version}
 
 	//Eliminamos el producto de la base de datos
-	err := handler.productoService.EliminarProducto(&producto, &user)
+	err = handler.productoService.EliminarProducto(&producto, &user)
 	if err != nil {
 		logging.LoggearErrorYResponder(c, "ProductoHandler", "EliminarProducto", err, &user)
 		return
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/model"
+	"TPIntegrador/services"
+	"TPIntegrador/utils"
+	"TPIntegrador/utils/exportacion"
+	"TPIntegrador/utils/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReporteHandler struct {
+	reporteService services.ReporteServiceInterface
+}
+
+func NewReporteHandler(reporteService services.ReporteServiceInterface) *ReporteHandler {
+	return &ReporteHandler{reporteService: reporteService}
+}
+
+// Obtiene la rentabilidad de cada camion entre las fechas desde y hasta (ambas incluidas)
+func (handler *ReporteHandler) ObtenerRentabilidadCamiones(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	filtro, err := leerPeriodoReporte(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerRentabilidadCamiones", err, &user)
+		return
+	}
+
+	rentabilidades, err := handler.reporteService.ObtenerRentabilidadCamiones(filtro, &user)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerRentabilidadCamiones", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	responderEnFormato(c, "ReporteHandler", "ObtenerRentabilidadCamiones", rentabilidades, func() exportacion.Documento {
+		return documentoRentabilidadCamiones(rentabilidades)
+	}, &user)
+}
+
+// Obtiene la rentabilidad entre las fechas desde y hasta (ambas incluidas), agrupada segun agrupar_por
+func (handler *ReporteHandler) ObtenerRentabilidadAgrupada(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	filtro, err := leerPeriodoReporte(c)
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerRentabilidadAgrupada", err, &user)
+		return
+	}
+
+	filtro.AgruparPor = model.DimensionRentabilidad(c.DefaultQuery("agrupar_por", string(model.PorTipoProducto)))
+
+	rentabilidades, err := handler.reporteService.ObtenerRentabilidadAgrupada(filtro, &user)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerRentabilidadAgrupada", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	responderEnFormato(c, "ReporteHandler", "ObtenerRentabilidadAgrupada", rentabilidades, func() exportacion.Documento {
+		return documentoRentabilidadAgrupada(string(filtro.AgruparPor), rentabilidades)
+	}, &user)
+}
+
+// Obtiene el beneficio por año, mes, semana y dia entre fechaDesde y fechaHasta
+func (handler *ReporteHandler) ObtenerBeneficioEntreFechas(c *gin.Context) {
+	user := dto.NewUser(utils.GetUserInfoFromContext(c))
+
+	//Convierte las fechas string a time.Time, con hora 0
+	fechaDesde, err := time.Parse("2006-01-02", c.DefaultQuery("fechaDesde", "0001-01-01"))
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerBeneficioEntreFechas", err, &user)
+		return
+	}
+
+	fechaHasta, err := time.Parse("2006-01-02", c.DefaultQuery("fechaHasta", "0001-01-01"))
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerBeneficioEntreFechas", err, &user)
+		return
+	}
+
+	filtro := utils.FiltroReporte{
+		FechaDesde: fechaDesde,
+		FechaHasta: fechaHasta,
+	}
+
+	beneficioTemporal, err := handler.reporteService.ObtenerBeneficioTemporal(filtro)
+
+	//Si hay un error, lo devolvemos
+	if err != nil {
+		logging.LoggearErrorYResponder(c, "ReporteHandler", "ObtenerBeneficioEntreFechas", err, &user)
+		return
+	}
+
+	//Agregamos un log para indicar información relevante del resultado
+	responderEnFormato(c, "ReporteHandler", "ObtenerBeneficioEntreFechas", beneficioTemporal, func() exportacion.Documento {
+		return documentoBeneficioTemporal(beneficioTemporal)
+	}, &user)
+}
+
+// Lee las fechas desde y hasta del query. Si no se indican, el periodo no tiene limite de ese lado
+func leerPeriodoReporte(c *gin.Context) (utils.FiltroReporte, error) {
+	filtro := utils.FiltroReporte{}
+
+	fechaDesde, err := time.Parse("2006-01-02", c.DefaultQuery("desde", "0001-01-01"))
+	if err != nil {
+		return filtro, err
+	}
+
+	fechaHasta, err := time.Parse("2006-01-02", c.DefaultQuery("hasta", "0001-01-01"))
+	if err != nil {
+		return filtro, err
+	}
+
+	//Incluimos todo el dia de la fecha hasta
+	if !fechaHasta.IsZero() {
+		fechaHasta = fechaHasta.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	filtro.FechaDesde = fechaDesde
+	filtro.FechaHasta = fechaHasta
+
+	return filtro, nil
+}
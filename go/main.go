@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	//Incluimos la base de zonas horarias, para no depender de la del sistema
+	_ "time/tzdata"
 
 	"TPIntegrador/clients"
 	"TPIntegrador/database"
@@ -9,15 +11,20 @@ import (
 	"TPIntegrador/middlewares"
 	"TPIntegrador/repositories"
 	"TPIntegrador/services"
+	"TPIntegrador/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	camionHandler   *handlers.CamionHandler
-	pedidoHandler   *handlers.PedidoHandler
-	productoHandler *handlers.ProductoHandler
-	envioHandler    *handlers.EnvioHandler
+	camionHandler        *handlers.CamionHandler
+	pedidoHandler        *handlers.PedidoHandler
+	productoHandler      *handlers.ProductoHandler
+	envioHandler         *handlers.EnvioHandler
+	ciudadHandler        *handlers.CiudadHandler
+	conductorHandler     *handlers.ConductorHandler
+	mantenimientoHandler *handlers.MantenimientoHandler
+	reporteHandler       *handlers.ReporteHandler
 
 	router *gin.Engine
 )
@@ -45,22 +52,41 @@ func mappingRoutes() {
 	//Rutas de pedidos
 	router.GET("/pedidos", pedidoHandler.ObtenerPedidos)
 	router.GET("/pedidos/cantidadPorEstado", pedidoHandler.ObtenerCantidadPedidosPorEstado)
+	router.GET("/pedidos/:id", pedidoHandler.ObtenerPedidoPorId)
+	router.GET("/pedidos/:id/entrega", pedidoHandler.ObtenerEntrega)
+	router.GET("/pedidos/:id/entrega/archivo", pedidoHandler.ObtenerArchivoEntrega)
+	router.GET("/pedidos/:id/revisiones", pedidoHandler.ObtenerRevisionesPedido)
+	router.GET("/pedidos/:id/revisiones/:numero", pedidoHandler.ObtenerRevisionPedido)
 	router.POST("/pedidos", pedidoHandler.CrearPedido)
+	router.POST("/pedidos/:id/entrega", pedidoHandler.RegistrarEntrega)
+	router.POST("/pedidos/:id/devolucion", pedidoHandler.RegistrarDevolucion)
+	router.PUT("/pedidos/:id", pedidoHandler.EditarPedido)
 	router.PUT("/pedidos/:id/aceptar", pedidoHandler.AceptarPedido)
 	router.PUT("/pedidos/:id/cancelar", pedidoHandler.CancelarPedido)
 
 	//Rutas de envios
 	router.GET("/envios", envioHandler.ObtenerEnvios)
 	router.GET("/envios/:id", envioHandler.ObtenerEnvioPorId)
-	router.GET("/envios/beneficioEntreFechas", envioHandler.ObtenerBeneficioEntreFechas)
+	router.GET("/envios/beneficioEntreFechas", reporteHandler.ObtenerBeneficioEntreFechas)
 	router.GET("/envios/cantidadPorEstado", envioHandler.ObtenerCantidadEnviosPorEstado)
+	router.GET("/envios/reportes/rentabilidad", reporteHandler.ObtenerRentabilidadAgrupada)
 	router.POST("/envios", envioHandler.CrearEnvio)
 	router.POST("/envios/nuevaParada", envioHandler.AgregarParada)
+	router.POST("/envios/planificar", envioHandler.PlanificarEnvio)
+	router.POST("/envios/consolidar", envioHandler.ConsolidarEnvios)
+	router.POST("/envios/confirmar", envioHandler.ConfirmarEnvios)
 	router.PUT("/envios/cambiarEstado", envioHandler.CambiarEstadoEnvio)
 
 	//Rutas de camiones
 	router.GET("/camiones", camionHandler.ObtenerCamiones)
 	router.GET("/camiones/:patente", camionHandler.ObtenerCamionPorPatente)
+	router.GET("/camiones/mantenimientos/proximos", mantenimientoHandler.ObtenerMantenimientosProximos)
+	router.GET("/camiones/documentos/vencimientos", camionHandler.ObtenerDocumentosPorVencer)
+	router.GET("/camiones/reportes/rentabilidad", reporteHandler.ObtenerRentabilidadCamiones)
+	router.GET("/camiones/:patente/mantenimientos", mantenimientoHandler.ObtenerMantenimientosCamion)
+	router.POST("/camiones/:patente/mantenimientos", mantenimientoHandler.ProgramarMantenimiento)
+	router.PUT("/camiones/:patente/mantenimientos/:id/estado", mantenimientoHandler.CambiarEstadoMantenimiento)
+	router.PUT("/camiones/:patente/documentos", camionHandler.GuardarDocumentoCamion)
 	router.POST("/camiones", camionHandler.CrearCamion)
 	router.PUT("/camiones", camionHandler.ActualizarCamion)
 	router.DELETE("/camiones/:patente", camionHandler.EliminarCamion)
@@ -68,29 +94,78 @@ func mappingRoutes() {
 	//Rutas de productos
 	router.GET("/productos", productoHandler.ObtenerProductos)
 	router.GET("/productos/:codigo", productoHandler.ObtenerProductoPorCodigo)
+	router.GET("/productos/:codigo/movimientos", productoHandler.ObtenerMovimientosStock)
+	router.GET("/productos/:codigo/conciliacion", productoHandler.ConciliarStock)
 	router.POST("/productos", productoHandler.CrearProducto)
 	router.PUT("/productos", productoHandler.ActualizarProducto)
+	router.PUT("/productos/:codigo/inventario", productoHandler.RegistrarInventario)
 	router.DELETE("/productos/:codigo", productoHandler.EliminarProducto)
+
+	//Rutas de ciudades
+	router.GET("/ciudades", ciudadHandler.ObtenerCiudades)
+	router.POST("/ciudades/distancias", ciudadHandler.ImportarDistancias)
+
+	//Rutas de conductores
+	router.GET("/conductores", conductorHandler.ObtenerConductores)
+	router.GET("/conductores/:codigo", conductorHandler.ObtenerConductorPorCodigo)
+	router.POST("/conductores", conductorHandler.CrearConductor)
+	router.PUT("/conductores", conductorHandler.ActualizarConductor)
+	router.DELETE("/conductores/:codigo", conductorHandler.EliminarConductor)
+
+	//Rutas de asignaciones de camiones a conductores
+	router.GET("/asignaciones", conductorHandler.ObtenerAsignaciones)
+	router.POST("/asignaciones", conductorHandler.AsignarCamion)
+	router.PUT("/asignaciones/:id/finalizar", conductorHandler.FinalizarAsignacion)
 }
 
 func dependencies() {
 	database := database.NewMongoDB()
 
+	//Crea los indices que falten y avisa si los de la base de datos no coinciden con los declarados o no se pudieron crear
+	diferenciasIndices, err := database.InicializarIndices()
+	if err != nil {
+		log.Fatalf("No se pudieron inicializar los indices: %s", err.Error())
+	}
+	for _, diferencia := range diferenciasIndices {
+		log.Printf("Diferencia en los indices: %s", diferencia)
+	}
+
+	//La zona horaria y el ejercicio fiscal de los reportes se configuran por variables de entorno
+	calendarioFiscal, err := utils.LeerCalendarioFiscal()
+	if err != nil {
+		log.Fatalf("Configuracion del calendario fiscal invalida: %s", err.Error())
+	}
+
 	//Iniciar repositorios
 	camionRepository := repositories.NewCamionRepository(database)
 	pedidoRepository := repositories.NewPedidoRepository(database)
 	productoRepository := repositories.NewProductoRepository(database)
 	envioRepository := repositories.NewEnvioRepository(database)
+	movimientoStockRepository := repositories.NewMovimientoStockRepository(database)
+	archivoRepository := repositories.NewArchivoRepository(database)
+	ciudadRepository := repositories.NewCiudadRepository(database)
+	conductorRepository := repositories.NewConductorRepository(database)
+	mantenimientoRepository := repositories.NewMantenimientoRepository(database)
+	reporteRepository := repositories.NewReporteRepository(database)
+	unidadDeTrabajo := repositories.NewUnidadDeTrabajo(database)
 
 	//Iniciar servicios
 	camionService := services.NewCamionService(camionRepository, envioRepository)
-	pedidoService := services.NewPedidoService(pedidoRepository, envioRepository, productoRepository)
-	productoService := services.NewProductoService(productoRepository, pedidoRepository)
-	envioService := services.NewEnvioService(envioRepository, camionRepository, pedidoRepository, productoRepository)
+	pedidoService := services.NewPedidoService(pedidoRepository, envioRepository, productoRepository, movimientoStockRepository, archivoRepository, conductorRepository, unidadDeTrabajo)
+	productoService := services.NewProductoService(productoRepository, pedidoRepository, movimientoStockRepository, unidadDeTrabajo, pedidoService)
+	envioService := services.NewEnvioService(envioRepository, camionRepository, pedidoRepository, productoRepository, movimientoStockRepository, ciudadRepository, conductorRepository, mantenimientoRepository, unidadDeTrabajo)
+	ciudadService := services.NewCiudadService(ciudadRepository, unidadDeTrabajo)
+	conductorService := services.NewConductorService(conductorRepository, camionRepository, unidadDeTrabajo)
+	mantenimientoService := services.NewMantenimientoService(mantenimientoRepository, camionRepository, envioRepository)
+	reporteService := services.NewReporteService(reporteRepository, calendarioFiscal)
 
 	//Iniciar handlers
 	camionHandler = handlers.NewCamionHandler(camionService)
 	pedidoHandler = handlers.NewPedidoHandler(pedidoService)
 	productoHandler = handlers.NewProductoHandler(productoService)
 	envioHandler = handlers.NewEnvioHandler(envioService)
+	ciudadHandler = handlers.NewCiudadHandler(ciudadService)
+	conductorHandler = handlers.NewConductorHandler(conductorService)
+	mantenimientoHandler = handlers.NewMantenimientoHandler(mantenimientoService)
+	reporteHandler = handlers.NewReporteHandler(reporteService)
 }
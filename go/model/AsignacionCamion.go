@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Indica que conductor maneja un camion durante un periodo.
+// Si la fecha hasta esta vacia, la asignacion no tiene fin
+type AsignacionCamion struct {
+	ObjectId        primitive.ObjectID `bson:"_id,omitempty"`
+	PatenteCamion   string             `bson:"patente_camion"`
+	CodigoConductor string             `bson:"codigo_conductor"`
+	FechaDesde      time.Time          `bson:"fecha_desde"`
+	FechaHasta      time.Time          `bson:"fecha_hasta"`
+	FechaCreacion   time.Time          `bson:"fecha_creacion"`
+	IdCreador       string             `bson:"id_creador"`
+}
+
+// Indica si la asignacion esta vigente en la fecha. La fecha desde se incluye y la fecha hasta no
+func (asignacion AsignacionCamion) EstaVigente(fecha time.Time) bool {
+	if fecha.Before(asignacion.FechaDesde) {
+		return false
+	}
+	return asignacion.FechaHasta.IsZero() || fecha.Before(asignacion.FechaHasta)
+}
+
+// Indica si los periodos de las dos asignaciones tienen algun momento en comun
+func (asignacion AsignacionCamion) SeSuperponeCon(otra AsignacionCamion) bool {
+	empiezaAntesDelFinDeOtra := otra.FechaHasta.IsZero() || asignacion.FechaDesde.Before(otra.FechaHasta)
+	otraEmpiezaAntesDelFin := asignacion.FechaHasta.IsZero() || otra.FechaDesde.Before(asignacion.FechaHasta)
+
+	return empiezaAntesDelFinDeOtra && otraEmpiezaAntesDelFin
+}
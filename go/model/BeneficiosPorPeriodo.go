@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Beneficio neto de los envios despachados, agrupado por ejercicio, mes, semana y dia en la zona horaria del calendario.
+// Cada mapa esta indexado por la clave del periodo (ver las funciones ObtenerClave...)
+type BeneficiosPorPeriodo struct {
+	Anuales   map[string]float64
+	Mensuales map[string]float64
+	Semanales map[string]float64
+	Diarios   map[string]float64
+}
+
+// Clave del ejercicio, que es el año en que empieza, por ejemplo 2024
+func ObtenerClaveEjercicio(ejercicio int) string {
+	return fmt.Sprintf("%04d", ejercicio)
+}
+
+// Clave del mes, por ejemplo 2024-03
+func ObtenerClaveMes(fecha time.Time) string {
+	return fmt.Sprintf("%04d-%02d", fecha.Year(), int(fecha.Month()))
+}
+
+// Clave de la semana ISO (de lunes a domingo), por ejemplo 2024-W09
+func ObtenerClaveSemana(fecha time.Time) string {
+	año, semana := fecha.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", año, semana)
+}
+
+// Clave del dia, por ejemplo 2024-03-01
+func ObtenerClaveDia(fecha time.Time) string {
+	return fecha.Format("2006-01-02")
+}
+
+// Devuelve el lunes a las 0 horas de la semana de la fecha
+func ObtenerInicioSemana(fecha time.Time) time.Time {
+	diasDesdeLunes := (int(fecha.Weekday()) + 6) % 7
+	return time.Date(fecha.Year(), fecha.Month(), fecha.Day()-diasDesdeLunes, 0, 0, 0, 0, fecha.Location())
+}
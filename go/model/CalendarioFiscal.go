@@ -0,0 +1,46 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Zona horaria en la que se interpretan las fechas de los reportes, y mes en el que empieza el ejercicio fiscal
+type CalendarioFiscal struct {
+	ZonaHoraria        *time.Location
+	MesInicioEjercicio time.Month
+}
+
+func NewCalendarioFiscal(zonaHoraria string, mesInicioEjercicio int) (CalendarioFiscal, error) {
+	ubicacion, err := time.LoadLocation(zonaHoraria)
+
+	if err != nil || ubicacion.String() == "Local" {
+		return CalendarioFiscal{}, errors.New("la zona horaria debe ser un nombre IANA, por ejemplo America/Argentina/Buenos_Aires")
+	}
+
+	if mesInicioEjercicio < 1 || mesInicioEjercicio > 12 {
+		return CalendarioFiscal{}, errors.New("el mes de inicio del ejercicio debe estar entre 1 y 12")
+	}
+
+	return CalendarioFiscal{ZonaHoraria: ubicacion, MesInicioEjercicio: time.Month(mesInicioEjercicio)}, nil
+}
+
+// Toma la fecha y hora tal como se leyeron (sin zona) como una fecha y hora de la zona del calendario
+func (calendario CalendarioFiscal) AplicarZonaHoraria(fecha time.Time) time.Time {
+	return time.Date(fecha.Year(), fecha.Month(), fecha.Day(), fecha.Hour(), fecha.Minute(), fecha.Second(), fecha.Nanosecond(), calendario.ZonaHoraria)
+}
+
+// Devuelve el ejercicio de la fecha, que se identifica por el año en que empieza
+func (calendario CalendarioFiscal) ObtenerEjercicio(fecha time.Time) int {
+	fechaLocal := fecha.In(calendario.ZonaHoraria)
+
+	if fechaLocal.Month() < calendario.MesInicioEjercicio {
+		return fechaLocal.Year() - 1
+	}
+	return fechaLocal.Year()
+}
+
+// Devuelve el primer instante del ejercicio
+func (calendario CalendarioFiscal) ObtenerInicioEjercicio(ejercicio int) time.Time {
+	return time.Date(ejercicio, calendario.MesInicioEjercicio, 1, 0, 0, 0, 0, calendario.ZonaHoraria)
+}
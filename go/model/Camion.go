@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,9 +11,73 @@ type Camion struct {
 	ObjectId                 primitive.ObjectID `bson:"_id,omitempty"`
 	Patente                  string             `bson:"patente"`
 	PesoMaximo               int                `bson:"peso_maximo"`
+	VolumenMaximo            float64            `bson:"volumen_maximo"`
 	CostoPorKilometro        float64            `bson:"costo_por_kilometro"`
 	FechaCreacion            time.Time          `bson:"fecha_creacion"`
 	FechaUltimaActualizacion time.Time          `bson:"fecha_ultima_actualizacion"`
 	IdCreador                string             `bson:"id_creador"`
 	EstaActivo               bool               `bson:"esta_activo"`
+	Documentos               []DocumentoCamion  `bson:"documentos,omitempty"`
+	Version                  int                `bson:"version"`
+}
+
+// Indica si el camion puede llevar la carga. Los camiones sin volumen cargado solo se limitan por peso
+func (camion Camion) AdmiteCarga(peso float64, volumen float64) bool {
+	return peso <= float64(camion.PesoMaximo) && (camion.VolumenMaximo <= 0 || volumen <= camion.VolumenMaximo)
+}
+
+// Devuelve los documentos del camion que estan vencidos en la fecha
+func (camion Camion) ObtenerDocumentosVencidos(fecha time.Time) []DocumentoCamion {
+	vencidos := make([]DocumentoCamion, 0)
+	for _, documento := range camion.Documentos {
+		if documento.EstaVencido(fecha) {
+			vencidos = append(vencidos, documento)
+		}
+	}
+	return vencidos
+}
+
+// Devuelve los tipos de documento obligatorios que el camion no tiene cargados
+func (camion Camion) ObtenerDocumentosFaltantes() []TipoDocumentoCamion {
+	faltantes := make([]TipoDocumentoCamion, 0)
+	for _, tipo := range TiposDocumentoCamionObligatorios {
+		cargado := false
+		for _, documento := range camion.Documentos {
+			if documento.Tipo == tipo {
+				cargado = true
+				break
+			}
+		}
+		if !cargado {
+			faltantes = append(faltantes, tipo)
+		}
+	}
+	return faltantes
+}
+
+// Devuelve un error que indica los documentos vencidos, si el camion tiene alguno en la fecha
+func (camion Camion) ValidarDocumentacion(fecha time.Time) error {
+	vencidos := camion.ObtenerDocumentosVencidos(fecha)
+
+	if len(vencidos) == 0 {
+		return nil
+	}
+
+	detalles := make([]string, 0, len(vencidos))
+	for _, documento := range vencidos {
+		detalles = append(detalles, string(documento.Tipo)+" (vencio el "+documento.FechaVencimiento.Format("2006-01-02")+")")
+	}
+
+	return &ErrorDocumentacionCamion{Patente: camion.Patente, Detalle: strings.Join(detalles, ", ")}
+}
+
+// Reemplaza el documento del mismo tipo, o lo agrega si el camion no tenia uno
+func (camion *Camion) GuardarDocumento(documento DocumentoCamion) {
+	for i, documentoActual := range camion.Documentos {
+		if documentoActual.Tipo == documento.Tipo {
+			camion.Documentos[i] = documento
+			return
+		}
+	}
+	camion.Documentos = append(camion.Documentos, documento)
 }
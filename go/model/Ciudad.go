@@ -0,0 +1,22 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Ciudad struct {
+	ObjectId                 primitive.ObjectID `bson:"_id,omitempty"`
+	Nombre                   string             `bson:"nombre"`
+	Clave                    string             `bson:"clave"`
+	FechaCreacion            time.Time          `bson:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time          `bson:"fecha_ultima_actualizacion"`
+	IdCreador                string             `bson:"id_creador"`
+}
+
+// Devuelve la clave con la que se comparan los nombres de ciudades, sin importar mayusculas ni espacios
+func ObtenerClaveCiudad(nombre string) string {
+	return strings.ToLower(strings.TrimSpace(nombre))
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Conductor de la empresa. Se identifica con el codigo de su usuario en el servicio de autenticacion
+type Conductor struct {
+	ObjectId                 primitive.ObjectID `bson:"_id,omitempty"`
+	CodigoUsuario            string             `bson:"codigo_usuario"`
+	Nombre                   string             `bson:"nombre"`
+	Licencia                 string             `bson:"licencia"`
+	FechaCreacion            time.Time          `bson:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time          `bson:"fecha_ultima_actualizacion"`
+	IdCreador                string             `bson:"id_creador"`
+	EstaActivo               bool               `bson:"esta_activo"`
+	Version                  int                `bson:"version"`
+}
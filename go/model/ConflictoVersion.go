@@ -0,0 +1,6 @@
+package model
+
+import "errors"
+
+// Error que devuelven las actualizaciones cuando el documento fue modificado desde que se leyo
+var ErrConflictoDeVersion = errors.New("el documento fue modificado por otra operación, vuelva a obtenerlo e intente de nuevo")
@@ -0,0 +1,191 @@
+package model
+
+import "sort"
+
+// Lo que hace falta saber de un pedido para asignarlo a un camion
+type CargaPedido struct {
+	IdPedido string
+	Ciudad   string
+	Peso     float64
+	Volumen  float64
+}
+
+// Envio propuesto por la consolidacion: un camion con los pedidos que lleva y su recorrido
+type EnvioConsolidado struct {
+	Camion  *Camion
+	Pedidos []CargaPedido
+	Paradas []Parada
+	Peso    float64
+	Volumen float64
+	Km      int
+	Costo   float64
+}
+
+type PedidoSinAsignar struct {
+	CargaPedido
+	Motivo string
+}
+
+// Reparte los pedidos entre los camiones, un envio por camion, sin superar el peso ni el volumen maximo de cada uno.
+// Es una heuristica golosa: toma el pedido pendiente mas lejano al origen, y para cada camion arma una carga
+// con ese pedido y los pedidos de las ciudades mas cercanas que todavia entran. Se queda con el camion
+// que transporta cada kilo mas barato, y repite hasta que no quedan pedidos o camiones.
+// Las distancias entre el origen y todas las ciudades de los pedidos tienen que estar cargadas
+func (matriz MatrizDistancias) ConsolidarCarga(origen string, pedidos []CargaPedido, camiones []*Camion) ([]EnvioConsolidado, []PedidoSinAsignar) {
+	pendientes := append([]CargaPedido{}, pedidos...)
+	camionesLibres := append([]*Camion{}, camiones...)
+
+	envios := make([]EnvioConsolidado, 0)
+	sinAsignar := make([]PedidoSinAsignar, 0)
+
+	//Primero los pedidos mas lejanos, y a igual distancia los mas pesados
+	sort.SliceStable(pendientes, func(i, j int) bool {
+		kmI, _ := matriz.ObtenerDistancia(origen, pendientes[i].Ciudad)
+		kmJ, _ := matriz.ObtenerDistancia(origen, pendientes[j].Ciudad)
+		if kmI != kmJ {
+			return kmI > kmJ
+		}
+		return pendientes[i].Peso > pendientes[j].Peso
+	})
+
+	for len(pendientes) > 0 && len(camionesLibres) > 0 {
+		semilla := pendientes[0]
+
+		var mejorEnvio *EnvioConsolidado
+		mejorCamion := -1
+
+		for i, camion := range camionesLibres {
+			if !camion.AdmiteCarga(semilla.Peso, semilla.Volumen) {
+				continue
+			}
+
+			envio := matriz.armarEnvioConsolidado(origen, camion, pendientes)
+
+			if mejorEnvio == nil || esEnvioMasConveniente(envio, *mejorEnvio) {
+				mejorEnvio = &envio
+				mejorCamion = i
+			}
+		}
+
+		//Si ningun camion libre puede llevar el pedido, lo dejamos afuera y seguimos con el siguiente
+		if mejorEnvio == nil {
+			sinAsignar = append(sinAsignar, PedidoSinAsignar{CargaPedido: semilla, Motivo: "ningun camion disponible soporta el peso o el volumen del pedido"})
+			pendientes = pendientes[1:]
+			continue
+		}
+
+		envios = append(envios, *mejorEnvio)
+		camionesLibres = append(camionesLibres[:mejorCamion], camionesLibres[mejorCamion+1:]...)
+		pendientes = quitarCargas(pendientes, mejorEnvio.Pedidos)
+	}
+
+	for _, pendiente := range pendientes {
+		sinAsignar = append(sinAsignar, PedidoSinAsignar{CargaPedido: pendiente, Motivo: "no quedan camiones disponibles"})
+	}
+
+	return envios, sinAsignar
+}
+
+// Carga el camion empezando por el primer pedido pendiente, y sigue agregando el pedido que entre
+// cuya ciudad este mas cerca de alguna de las ciudades que ya forman parte del recorrido
+func (matriz MatrizDistancias) armarEnvioConsolidado(origen string, camion *Camion, pendientes []CargaPedido) EnvioConsolidado {
+	envio := EnvioConsolidado{Camion: camion}
+
+	candidatos := append([]CargaPedido{}, pendientes...)
+	ciudades := []string{}
+
+	for len(candidatos) > 0 {
+		elegido := -1
+		kmElegido := 0
+
+		for i, candidato := range candidatos {
+			if !camion.AdmiteCarga(envio.Peso+candidato.Peso, envio.Volumen+candidato.Volumen) {
+				continue
+			}
+
+			//El primer pedido siempre es el primero de los pendientes
+			if len(envio.Pedidos) == 0 {
+				elegido = 0
+				break
+			}
+
+			km := matriz.distanciaAlRecorrido(candidato.Ciudad, ciudades)
+
+			if elegido == -1 || km < kmElegido || (km == kmElegido && candidato.Peso > candidatos[elegido].Peso) {
+				elegido = i
+				kmElegido = km
+			}
+		}
+
+		if elegido == -1 {
+			break
+		}
+
+		pedido := candidatos[elegido]
+		envio.Pedidos = append(envio.Pedidos, pedido)
+		envio.Peso += pedido.Peso
+		envio.Volumen += pedido.Volumen
+
+		//Cada ciudad es una sola parada, aunque se entreguen varios pedidos en ella
+		if !contieneCiudad(ciudades, pedido.Ciudad) {
+			ciudades = append(ciudades, pedido.Ciudad)
+		}
+
+		candidatos = append(candidatos[:elegido], candidatos[elegido+1:]...)
+	}
+
+	//Las distancias ya se validaron, asi que el recorrido siempre se puede armar
+	envio.Paradas, _ = matriz.PlanificarRuta(origen, ciudades)
+	envio.Km = ObtenerKmTotales(envio.Paradas)
+	envio.Costo = float64(envio.Km) * camion.CostoPorKilometro
+
+	return envio
+}
+
+// Devuelve la distancia de la ciudad a la ciudad mas cercana del recorrido
+func (matriz MatrizDistancias) distanciaAlRecorrido(ciudad string, recorrido []string) int {
+	minimo := -1
+	for _, ciudadRecorrido := range recorrido {
+		km, _ := matriz.ObtenerDistancia(ciudad, ciudadRecorrido)
+		if minimo == -1 || km < minimo {
+			minimo = km
+		}
+	}
+	return minimo
+}
+
+func contieneCiudad(ciudades []string, ciudad string) bool {
+	for _, otraCiudad := range ciudades {
+		if ObtenerClaveCiudad(otraCiudad) == ObtenerClaveCiudad(ciudad) {
+			return true
+		}
+	}
+	return false
+}
+
+// Un envio conviene mas que otro si cada kilo le cuesta menos, y a igual costo por kilo si es mas barato en total
+func esEnvioMasConveniente(envio EnvioConsolidado, otro EnvioConsolidado) bool {
+	costoPorKilo := envio.Costo / envio.Peso
+	otroCostoPorKilo := otro.Costo / otro.Peso
+
+	if envio.Peso == 0 || otro.Peso == 0 || costoPorKilo == otroCostoPorKilo {
+		return envio.Costo < otro.Costo
+	}
+
+	return costoPorKilo < otroCostoPorKilo
+}
+
+func quitarCargas(cargas []CargaPedido, quitar []CargaPedido) []CargaPedido {
+	quitados := make(map[string]bool)
+	for _, carga := range quitar {
+		quitados[carga.IdPedido] = true
+	}
+
+	restantes := make([]CargaPedido, 0)
+	for _, carga := range cargas {
+		if !quitados[carga.IdPedido] {
+			restantes = append(restantes, carga)
+		}
+	}
+	return restantes
+}
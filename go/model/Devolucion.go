@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Productos de un pedido entregado que volvieron, con el precio y peso que tenian en el pedido
+type Devolucion struct {
+	Productos     []ProductoPedido `bson:"productos"`
+	Motivo        string           `bson:"motivo"`
+	FechaCreacion time.Time        `bson:"fecha_creacion"`
+	IdCreador     string           `bson:"id_creador"`
+}
+
+func (devolucion Devolucion) ObtenerPrecioTotal() float64 {
+	var precioTotal float64 = 0
+	for _, producto := range devolucion.Productos {
+		precioTotal += producto.PrecioUnitario * float64(producto.Cantidad)
+	}
+	return precioTotal
+}
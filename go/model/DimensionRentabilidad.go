@@ -0,0 +1,21 @@
+package model
+
+// Criterio por el que se agrupa el reporte de rentabilidad
+type DimensionRentabilidad string
+
+const (
+	PorTipoProducto  DimensionRentabilidad = "tipo_producto"
+	PorProducto      DimensionRentabilidad = "producto"
+	PorCiudadDestino DimensionRentabilidad = "ciudad_destino"
+	PorCamion        DimensionRentabilidad = "camion"
+	PorConductor     DimensionRentabilidad = "conductor"
+)
+
+func EsUnaDimensionRentabilidadValida(dimension DimensionRentabilidad) bool {
+	return dimension == PorTipoProducto || dimension == PorProducto || dimension == PorCiudadDestino || dimension == PorCamion || dimension == PorConductor
+}
+
+// Indica si la dimension agrupa por los productos de los pedidos, y no por los pedidos enteros
+func (dimension DimensionRentabilidad) EsPorProducto() bool {
+	return dimension == PorTipoProducto || dimension == PorProducto
+}
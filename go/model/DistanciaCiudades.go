@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kilometros entre dos ciudades, identificadas por su clave. La distancia vale para ambos sentidos
+type DistanciaCiudades struct {
+	ObjectId                 primitive.ObjectID `bson:"_id,omitempty"`
+	Origen                   string             `bson:"origen"`
+	Destino                  string             `bson:"destino"`
+	Km                       int                `bson:"km"`
+	FechaUltimaActualizacion time.Time          `bson:"fecha_ultima_actualizacion"`
+	IdCreador                string             `bson:"id_creador"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Documento que el camion necesita para circular, como el seguro o la VTV. Un camion tiene un solo documento de cada tipo
+type DocumentoCamion struct {
+	Tipo             TipoDocumentoCamion `bson:"tipo"`
+	Numero           string              `bson:"numero"`
+	FechaEmision     time.Time           `bson:"fecha_emision"`
+	FechaVencimiento time.Time           `bson:"fecha_vencimiento"`
+}
+
+// El documento deja de ser valido en su fecha de vencimiento
+func (documento DocumentoCamion) EstaVencido(fecha time.Time) bool {
+	return !documento.FechaVencimiento.After(fecha)
+}
+
+// Error que se devuelve cuando un camion no puede circular por tener documentos vencidos
+type ErrorDocumentacionCamion struct {
+	Patente string
+	Detalle string
+}
+
+func (err *ErrorDocumentacionCamion) Error() string {
+	return "el camion " + err.Patente + " tiene documentos vencidos: " + err.Detalle
+}
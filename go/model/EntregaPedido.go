@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Comprobante de la entrega de un pedido
+type EntregaPedido struct {
+	IdEnvio       string    `bson:"id_envio"`
+	Ciudad        string    `bson:"ciudad"`
+	FechaEntrega  time.Time `bson:"fecha_entrega"`
+	Receptor      string    `bson:"receptor"`
+	Observaciones string    `bson:"observaciones"`
+	IdArchivo     string    `bson:"id_archivo,omitempty"`
+	NombreArchivo string    `bson:"nombre_archivo,omitempty"`
+	TipoArchivo   string    `bson:"tipo_archivo,omitempty"`
+	IdCreador     string    `bson:"id_creador"`
+}
@@ -14,5 +14,60 @@ type Envio struct {
 	Paradas                  []Parada           `bson:"paradas"`
 	Pedidos                  []string           `bson:"pedidos"`
 	IdCreador                string             `bson:"id_creador"`
+	IdConductor              string             `bson:"id_conductor"`
+	FechaDespacho            time.Time          `bson:"fecha_despacho"`
 	Estado                   EstadoEnvio        `bson:"estado"`
+	Version                  int                `bson:"version"`
+}
+
+// Devuelve los pedidos del envio que todavia no se entregaron ni se marcaron como no entregables en ninguna parada
+func (envio Envio) ObtenerPedidosPendientesDeEntrega() []string {
+	resueltos := make(map[string]bool)
+
+	for _, parada := range envio.Paradas {
+		for _, idPedido := range parada.PedidosEntregados {
+			resueltos[idPedido] = true
+		}
+		for _, idPedido := range parada.PedidosNoEntregados {
+			resueltos[idPedido] = true
+		}
+	}
+
+	pendientes := make([]string, 0)
+
+	for _, idPedido := range envio.Pedidos {
+		if !resueltos[idPedido] {
+			pendientes = append(pendientes, idPedido)
+		}
+	}
+
+	return pendientes
+}
+
+// Devuelve los pedidos que se le imputan al envio en los reportes: todos menos los que alguna parada marco como no entregados.
+// Esos pedidos vuelven a Aceptado pero siguen en la lista del envio, y si otro envio los entrega, se le imputan a ese.
+// etapaLookupPedidosDeEnvio, en el repositorio de reportes, aplica la misma regla
+func (envio Envio) ObtenerPedidosImputables() []string {
+	noEntregados := make(map[string]bool)
+
+	for _, parada := range envio.Paradas {
+		for _, idPedido := range parada.PedidosNoEntregados {
+			noEntregados[idPedido] = true
+		}
+	}
+
+	imputables := make([]string, 0)
+
+	for _, idPedido := range envio.Pedidos {
+		if !noEntregados[idPedido] {
+			imputables = append(imputables, idPedido)
+		}
+	}
+
+	return imputables
+}
+
+// Devuelve los km que recorrio el camion en el envio, sumando los de cada parada
+func (envio Envio) ObtenerKmRecorridos() int {
+	return ObtenerKmTotales(envio.Paradas)
 }
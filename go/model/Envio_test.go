@@ -0,0 +1,52 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObtenerPedidosImputablesConPedidoReenviado(t *testing.T) {
+	//El primer envio no pudo entregar el pedido B, que despues entrego el segundo envio
+	primerEnvio := Envio{
+		Pedidos: []string{"A", "B"},
+		Paradas: []Parada{{Ciudad: "Cordoba", PedidosEntregados: []string{"A"}, PedidosNoEntregados: []string{"B"}}},
+	}
+	segundoEnvio := Envio{
+		Pedidos: []string{"B"},
+		Paradas: []Parada{{Ciudad: "Cordoba", PedidosEntregados: []string{"B"}}},
+	}
+
+	casos := []struct {
+		nombre   string
+		envio    Envio
+		esperado []string
+	}{
+		{nombre: "primer envio", envio: primerEnvio, esperado: []string{"A"}},
+		{nombre: "segundo envio", envio: segundoEnvio, esperado: []string{"B"}},
+		{nombre: "envio sin paradas", envio: Envio{Pedidos: []string{"C"}}, esperado: []string{"C"}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			imputables := caso.envio.ObtenerPedidosImputables()
+
+			if !reflect.DeepEqual(imputables, caso.esperado) {
+				t.Fatalf("se esperaba %v, se obtuvo %v", caso.esperado, imputables)
+			}
+		})
+	}
+
+	//El pedido reenviado se cuenta una sola vez entre los dos envios
+	veces := 0
+	for _, envio := range []Envio{primerEnvio, segundoEnvio} {
+		for _, idPedido := range envio.ObtenerPedidosImputables() {
+			if idPedido == "B" {
+				veces++
+			}
+		}
+	}
+
+	if veces != 1 {
+		t.Fatalf("el pedido B se imputo %d veces", veces)
+	}
+}
@@ -1,13 +1,70 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+)
+
 type EstadoEnvio string
 
 const (
-	ADespachar EstadoEnvio = "A Despachar"
-	EnRuta     EstadoEnvio = "En Ruta"
-	Despachado EstadoEnvio = "Despachado"
+	ADespachar     EstadoEnvio = "A Despachar"
+	EnRuta         EstadoEnvio = "En Ruta"
+	Despachado     EstadoEnvio = "Despachado"
+	EnvioCancelado EstadoEnvio = "Cancelado"
 )
 
+// Tabla de transiciones: para cada estado, los estados a los que puede pasar un envio
+var transicionesEnvio = map[EstadoEnvio][]EstadoEnvio{
+	ADespachar:     {EnRuta, EnvioCancelado},
+	EnRuta:         {Despachado, EnvioCancelado},
+	Despachado:     {},
+	EnvioCancelado: {},
+}
+
 func EsUnEstadoEnvioValido(estado EstadoEnvio) bool {
-	return estado == ADespachar || estado == EnRuta || estado == Despachado
+	_, existe := transicionesEnvio[estado]
+	return existe
+}
+
+// Devuelve los estados a los que puede pasar un envio que esta en el estado indicado
+func ObtenerEstadosSiguientesEnvio(estado EstadoEnvio) []EstadoEnvio {
+	return transicionesEnvio[estado]
+}
+
+// Valida que un envio pueda pasar del estado actual al deseado. Si no puede, devuelve un ErrorTransicionEnvio
+func ValidarTransicionEnvio(estadoActual EstadoEnvio, estadoDeseado EstadoEnvio) error {
+	estadosPermitidos := ObtenerEstadosSiguientesEnvio(estadoActual)
+
+	for _, estadoPermitido := range estadosPermitidos {
+		if estadoPermitido == estadoDeseado {
+			return nil
+		}
+	}
+
+	return &ErrorTransicionEnvio{
+		EstadoActual:      estadoActual,
+		EstadoDeseado:     estadoDeseado,
+		EstadosPermitidos: estadosPermitidos,
+	}
+}
+
+// Error que indica que un envio no puede pasar a un estado, junto con los estados a los que si puede pasar
+type ErrorTransicionEnvio struct {
+	EstadoActual      EstadoEnvio
+	EstadoDeseado     EstadoEnvio
+	EstadosPermitidos []EstadoEnvio
+}
+
+func (err *ErrorTransicionEnvio) Error() string {
+	if len(err.EstadosPermitidos) == 0 {
+		return fmt.Sprintf("el envio no puede pasar al estado %s si esta en estado %s: no admite mas cambios de estado", err.EstadoDeseado, err.EstadoActual)
+	}
+
+	estados := make([]string, 0, len(err.EstadosPermitidos))
+	for _, estado := range err.EstadosPermitidos {
+		estados = append(estados, string(estado))
+	}
+
+	return fmt.Sprintf("el envio no puede pasar al estado %s si esta en estado %s: los estados permitidos son %s", err.EstadoDeseado, err.EstadoActual, strings.Join(estados, ", "))
 }
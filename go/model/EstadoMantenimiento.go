@@ -0,0 +1,33 @@
+package model
+
+type EstadoMantenimiento string
+
+const (
+	MantenimientoProgramado EstadoMantenimiento = "Programado"
+	MantenimientoEnCurso    EstadoMantenimiento = "En Curso"
+	MantenimientoRealizado  EstadoMantenimiento = "Realizado"
+	MantenimientoCancelado  EstadoMantenimiento = "Cancelado"
+)
+
+// Tabla de transiciones: para cada estado, los estados a los que puede pasar un mantenimiento
+var transicionesMantenimiento = map[EstadoMantenimiento][]EstadoMantenimiento{
+	MantenimientoProgramado: {MantenimientoEnCurso, MantenimientoRealizado, MantenimientoCancelado},
+	MantenimientoEnCurso:    {MantenimientoRealizado},
+	MantenimientoRealizado:  {},
+	MantenimientoCancelado:  {},
+}
+
+func EsUnEstadoMantenimientoValido(estado EstadoMantenimiento) bool {
+	_, existe := transicionesMantenimiento[estado]
+	return existe
+}
+
+// Indica si un mantenimiento puede pasar del estado actual al deseado
+func PuedeCambiarEstadoMantenimiento(estadoActual EstadoMantenimiento, estadoDeseado EstadoMantenimiento) bool {
+	for _, estadoPermitido := range transicionesMantenimiento[estadoActual] {
+		if estadoPermitido == estadoDeseado {
+			return true
+		}
+	}
+	return false
+}
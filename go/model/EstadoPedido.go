@@ -8,8 +8,9 @@ const (
 	Cancelado  EstadoPedido = "Cancelado"
 	ParaEnviar EstadoPedido = "Para Enviar"
 	Enviado    EstadoPedido = "Enviado"
+	Devuelto   EstadoPedido = "Devuelto"
 )
 
 func EsUnEstadoPedidoValido(estado EstadoPedido) bool {
-	return estado == Pendiente || estado == Aceptado || estado == Cancelado || estado == ParaEnviar || estado == Enviado
+	return estado == Pendiente || estado == Aceptado || estado == Cancelado || estado == ParaEnviar || estado == Enviado || estado == Devuelto
 }
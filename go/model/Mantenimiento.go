@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Service de un camion. Se programa para una fecha, para cuando el camion llegue a cierta cantidad de km
+// acumulados, o para lo que ocurra primero. Una vez realizado queda como registro
+type Mantenimiento struct {
+	ObjectId                 primitive.ObjectID  `bson:"_id,omitempty"`
+	PatenteCamion            string              `bson:"patente_camion"`
+	Descripcion              string              `bson:"descripcion"`
+	Estado                   EstadoMantenimiento `bson:"estado"`
+	FechaProgramada          time.Time           `bson:"fecha_programada"`
+	KmProgramados            int                 `bson:"km_programados"`
+	FechaInicio              time.Time           `bson:"fecha_inicio"`
+	FechaRealizacion         time.Time           `bson:"fecha_realizacion"`
+	KmAlRealizar             int                 `bson:"km_al_realizar"`
+	Costo                    float64             `bson:"costo"`
+	Observaciones            string              `bson:"observaciones"`
+	FechaCreacion            time.Time           `bson:"fecha_creacion"`
+	FechaUltimaActualizacion time.Time           `bson:"fecha_ultima_actualizacion"`
+	IdCreador                string              `bson:"id_creador"`
+	Version                  int                 `bson:"version"`
+}
+
+// Indica si el mantenimiento programado vence hasta la fecha indicada, o si el camion ya llego a los km programados
+func (mantenimiento Mantenimiento) EstaVencido(fecha time.Time, kmAcumulados int) bool {
+	if mantenimiento.Estado != MantenimientoProgramado {
+		return false
+	}
+
+	vencePorFecha := !mantenimiento.FechaProgramada.IsZero() && !mantenimiento.FechaProgramada.After(fecha)
+	vencePorKm := mantenimiento.KmProgramados > 0 && kmAcumulados >= mantenimiento.KmProgramados
+
+	return vencePorFecha || vencePorKm
+}
+
+// Indica si el camion no puede usarse por este mantenimiento: porque se esta haciendo o porque ya vencio
+func (mantenimiento Mantenimiento) BloqueaCamion(fecha time.Time, kmAcumulados int) bool {
+	return mantenimiento.Estado == MantenimientoEnCurso || mantenimiento.EstaVencido(fecha, kmAcumulados)
+}
+
+// Error que se devuelve cuando un camion no puede salir por estar en mantenimiento o tener uno vencido
+type ErrorMantenimientoCamion struct {
+	Patente       string
+	Mantenimiento *Mantenimiento
+}
+
+func (err *ErrorMantenimientoCamion) Error() string {
+	if err.Mantenimiento.Estado == MantenimientoEnCurso {
+		return "el camion " + err.Patente + " esta en mantenimiento: " + err.Mantenimiento.Descripcion
+	}
+	return "el camion " + err.Patente + " tiene un mantenimiento vencido: " + err.Mantenimiento.Descripcion
+}
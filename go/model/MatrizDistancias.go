@@ -0,0 +1,152 @@
+package model
+
+import "fmt"
+
+// Distancias en km entre ciudades, indexadas por la clave de cada ciudad
+type MatrizDistancias map[string]map[string]int
+
+func NewMatrizDistancias(distancias []*DistanciaCiudades) MatrizDistancias {
+	matriz := MatrizDistancias{}
+
+	for _, distancia := range distancias {
+		matriz.agregarDistancia(distancia.Origen, distancia.Destino, distancia.Km)
+		matriz.agregarDistancia(distancia.Destino, distancia.Origen, distancia.Km)
+	}
+
+	return matriz
+}
+
+func (matriz MatrizDistancias) agregarDistancia(origen string, destino string, km int) {
+	if matriz[origen] == nil {
+		matriz[origen] = map[string]int{}
+	}
+	matriz[origen][destino] = km
+}
+
+func (matriz MatrizDistancias) ObtenerDistancia(origen string, destino string) (int, bool) {
+	origen = ObtenerClaveCiudad(origen)
+	destino = ObtenerClaveCiudad(destino)
+
+	if origen == destino {
+		return 0, true
+	}
+
+	km, ok := matriz[origen][destino]
+	return km, ok
+}
+
+// Ordena los destinos para recorrerlos partiendo del origen: arma la ruta con el vecino mas cercano
+// y despues la mejora con 2-opt. Cada parada indica los km desde la parada anterior
+func (matriz MatrizDistancias) PlanificarRuta(origen string, destinos []string) ([]Parada, error) {
+	ciudades := append([]string{origen}, destinos...)
+
+	//Todas las distancias tienen que estar cargadas para poder comparar recorridos
+	err := matriz.ValidarDistancias(ciudades)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ruta := matriz.rutaVecinoMasCercano(ciudades)
+	ruta = matriz.mejorarRuta2Opt(ruta)
+
+	paradas := make([]Parada, 0)
+
+	for i := 1; i < len(ruta); i++ {
+		km, _ := matriz.ObtenerDistancia(ruta[i-1], ruta[i])
+		paradas = append(paradas, Parada{Ciudad: ruta[i], KmRecorridos: km})
+	}
+
+	return paradas, nil
+}
+
+// Verifica que esten cargadas las distancias entre todos los pares de ciudades
+func (matriz MatrizDistancias) ValidarDistancias(ciudades []string) error {
+	for i := range ciudades {
+		for j := i + 1; j < len(ciudades); j++ {
+			if _, ok := matriz.ObtenerDistancia(ciudades[i], ciudades[j]); !ok {
+				return fmt.Errorf("no hay distancia cargada entre %s y %s", ciudades[i], ciudades[j])
+			}
+		}
+	}
+
+	return nil
+}
+
+// Arma la ruta yendo siempre a la ciudad mas cercana que falta visitar. La primera ciudad es el origen
+func (matriz MatrizDistancias) rutaVecinoMasCercano(ciudades []string) []string {
+	ruta := []string{ciudades[0]}
+
+	pendientes := append([]string{}, ciudades[1:]...)
+
+	for len(pendientes) > 0 {
+		actual := ruta[len(ruta)-1]
+
+		masCercana := 0
+		for i := range pendientes {
+			km, _ := matriz.ObtenerDistancia(actual, pendientes[i])
+			kmMasCercana, _ := matriz.ObtenerDistancia(actual, pendientes[masCercana])
+			if km < kmMasCercana {
+				masCercana = i
+			}
+		}
+
+		ruta = append(ruta, pendientes[masCercana])
+		pendientes = append(pendientes[:masCercana], pendientes[masCercana+1:]...)
+	}
+
+	return ruta
+}
+
+// Invierte tramos de la ruta mientras eso la acorte. El origen queda fijo y la ruta no vuelve al origen
+func (matriz MatrizDistancias) mejorarRuta2Opt(ruta []string) []string {
+	mejoro := true
+
+	for mejoro {
+		mejoro = false
+
+		for i := 1; i < len(ruta)-1; i++ {
+			for k := i + 1; k < len(ruta); k++ {
+				if matriz.ahorro2Opt(ruta, i, k) > 0 {
+					invertirTramo(ruta, i, k)
+					mejoro = true
+				}
+			}
+		}
+	}
+
+	return ruta
+}
+
+// Devuelve cuantos km se ahorran invirtiendo el tramo de la ruta entre las posiciones i y k
+func (matriz MatrizDistancias) ahorro2Opt(ruta []string, i int, k int) int {
+	antes, _ := matriz.ObtenerDistancia(ruta[i-1], ruta[i])
+	despues, _ := matriz.ObtenerDistancia(ruta[i-1], ruta[k])
+
+	//Si el tramo llega al final de la ruta, no hay arista de salida que cambie
+	if k+1 < len(ruta) {
+		salidaAntes, _ := matriz.ObtenerDistancia(ruta[k], ruta[k+1])
+		salidaDespues, _ := matriz.ObtenerDistancia(ruta[i], ruta[k+1])
+		antes += salidaAntes
+		despues += salidaDespues
+	}
+
+	return antes - despues
+}
+
+func invertirTramo(ruta []string, i int, k int) {
+	for i < k {
+		ruta[i], ruta[k] = ruta[k], ruta[i]
+		i++
+		k--
+	}
+}
+
+// Suma los km de todas las paradas
+func ObtenerKmTotales(paradas []Parada) int {
+	total := 0
+	for _, parada := range paradas {
+		total += parada.KmRecorridos
+	}
+	return total
+}
@@ -0,0 +1,18 @@
+package model
+
+type MotivoMovimientoStock string
+
+const (
+	StockInicial      MotivoMovimientoStock = "Stock Inicial"
+	AjusteManual      MotivoMovimientoStock = "Ajuste Manual"
+	Reserva           MotivoMovimientoStock = "Reserva"
+	LiberacionReserva MotivoMovimientoStock = "Liberacion de Reserva"
+	EnvioDeStock      MotivoMovimientoStock = "Envio"
+	CancelacionEnvio  MotivoMovimientoStock = "Cancelacion de Envio"
+	DevolucionDeStock MotivoMovimientoStock = "Devolucion"
+	Inventario        MotivoMovimientoStock = "Inventario"
+)
+
+func EsUnMotivoMovimientoStockValido(motivo MotivoMovimientoStock) bool {
+	return motivo == StockInicial || motivo == AjusteManual || motivo == Reserva || motivo == LiberacionReserva || motivo == EnvioDeStock || motivo == CancelacionEnvio || motivo == DevolucionDeStock || motivo == Inventario
+}
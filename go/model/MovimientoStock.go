@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cantidad es la variacion del stock actual y CantidadReservada la del stock reservado
+type MovimientoStock struct {
+	ObjectId          primitive.ObjectID    `bson:"_id,omitempty"`
+	CodigoProducto    string                `bson:"codigo_producto"`
+	Cantidad          int                   `bson:"cantidad"`
+	CantidadReservada int                   `bson:"cantidad_reservada"`
+	Motivo            MotivoMovimientoStock `bson:"motivo"`
+	IdPedido          string                `bson:"id_pedido,omitempty"`
+	IdEnvio           string                `bson:"id_envio,omitempty"`
+	FechaCreacion     time.Time             `bson:"fecha_creacion"`
+	IdCreador         string                `bson:"id_creador"`
+}
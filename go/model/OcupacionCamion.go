@@ -0,0 +1,69 @@
+package model
+
+import "fmt"
+
+// Cuanto ocupa una carga de la capacidad de un camion, en peso (kg) y en volumen (m3)
+type OcupacionCamion struct {
+	PesoTotal     float64
+	PesoMaximo    float64
+	VolumenTotal  float64
+	VolumenMaximo float64
+}
+
+func NewOcupacionCamion(camion *Camion, pesoTotal float64, volumenTotal float64) *OcupacionCamion {
+	return &OcupacionCamion{
+		PesoTotal:     pesoTotal,
+		PesoMaximo:    float64(camion.PesoMaximo),
+		VolumenTotal:  volumenTotal,
+		VolumenMaximo: camion.VolumenMaximo,
+	}
+}
+
+func (ocupacion OcupacionCamion) ObtenerPorcentajePeso() float64 {
+	return obtenerPorcentaje(ocupacion.PesoTotal, ocupacion.PesoMaximo)
+}
+
+// Si el camion no tiene volumen cargado, el porcentaje es 0
+func (ocupacion OcupacionCamion) ObtenerPorcentajeVolumen() float64 {
+	return obtenerPorcentaje(ocupacion.VolumenTotal, ocupacion.VolumenMaximo)
+}
+
+// Devuelve un ErrorCapacidadCamion si la carga supera el peso o el volumen maximo
+func (ocupacion OcupacionCamion) ValidarCapacidad() error {
+	superaPeso := ocupacion.PesoTotal > ocupacion.PesoMaximo
+
+	//Los camiones sin volumen cargado solo se limitan por peso
+	superaVolumen := ocupacion.VolumenMaximo > 0 && ocupacion.VolumenTotal > ocupacion.VolumenMaximo
+
+	if superaPeso || superaVolumen {
+		return &ErrorCapacidadCamion{Ocupacion: ocupacion}
+	}
+
+	return nil
+}
+
+func obtenerPorcentaje(valor float64, maximo float64) float64 {
+	if maximo <= 0 {
+		return 0
+	}
+	return valor * 100 / maximo
+}
+
+// Error que se devuelve cuando un envio no cabe en el camion, con la ocupacion de cada limite
+type ErrorCapacidadCamion struct {
+	Ocupacion OcupacionCamion
+}
+
+func (err *ErrorCapacidadCamion) Error() string {
+	ocupacion := err.Ocupacion
+
+	mensaje := fmt.Sprintf("el envio no cabe en el camion: ocupa el %.1f%% del peso maximo (%.2f de %.2f kg)",
+		ocupacion.ObtenerPorcentajePeso(), ocupacion.PesoTotal, ocupacion.PesoMaximo)
+
+	if ocupacion.VolumenMaximo > 0 {
+		mensaje += fmt.Sprintf(" y el %.1f%% del volumen maximo (%.2f de %.2f m3)",
+			ocupacion.ObtenerPorcentajeVolumen(), ocupacion.VolumenTotal, ocupacion.VolumenMaximo)
+	}
+
+	return mensaje
+}
@@ -0,0 +1,8 @@
+package model
+
+// Resultado de pedir una pagina de un listado: el cursor de la pagina siguiente (vacio si es la ultima)
+// y la cantidad total de documentos que cumplen el filtro
+type Pagina struct {
+	SiguienteCursor string
+	Total           int64
+}
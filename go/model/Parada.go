@@ -1,6 +1,8 @@
 package model
 
 type Parada struct {
-	Ciudad       string `bson:"ciudad"`
-	KmRecorridos int    `bson:"km_recorridos"`
+	Ciudad              string   `bson:"ciudad"`
+	KmRecorridos        int      `bson:"km_recorridos"`
+	PedidosEntregados   []string `bson:"pedidos_entregados,omitempty"`
+	PedidosNoEntregados []string `bson:"pedidos_no_entregados,omitempty"`
 }
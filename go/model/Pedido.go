@@ -14,4 +14,70 @@ type Pedido struct {
 	FechaCreacion            time.Time          `bson:"fecha_creacion"`
 	FechaUltimaActualizacion time.Time          `bson:"fecha_ultima_actualizacion"`
 	IdCreador                string             `bson:"id_creador"`
+	Entrega                  *EntregaPedido     `bson:"entrega,omitempty"`
+	Devoluciones             []Devolucion       `bson:"devoluciones,omitempty"`
+	IdPedidoPadre            string             `bson:"id_pedido_padre,omitempty"`
+	IdPedidoHijo             string             `bson:"id_pedido_hijo,omitempty"`
+	Revisiones               []RevisionPedido   `bson:"revisiones,omitempty"`
+	Version                  int                `bson:"version"`
+}
+
+// Devuelve el peso de todos los productos del pedido
+func (pedido Pedido) ObtenerPesoTotal() float64 {
+	var pesoTotal float64 = 0
+	for _, producto := range pedido.ProductosElegidos {
+		pesoTotal += producto.ObtenerPesoProductoPedido()
+	}
+	return pesoTotal
+}
+
+// Devuelve el volumen en m3 de todos los productos del pedido
+func (pedido Pedido) ObtenerVolumenTotal() float64 {
+	var volumenTotal float64 = 0
+	for _, producto := range pedido.ProductosElegidos {
+		volumenTotal += producto.ObtenerVolumenProductoPedido()
+	}
+	return volumenTotal
+}
+
+// Devuelve todas las revisiones del pedido. Si nunca se edito, la unica revision es la que se creo
+func (pedido Pedido) ObtenerRevisiones() []RevisionPedido {
+	if len(pedido.Revisiones) > 0 {
+		return pedido.Revisiones
+	}
+
+	return []RevisionPedido{{
+		Numero:            1,
+		ProductosElegidos: pedido.ProductosElegidos,
+		CiudadDestino:     pedido.CiudadDestino,
+		FechaCreacion:     pedido.FechaCreacion,
+		IdCreador:         pedido.IdCreador,
+	}}
+}
+
+// Devuelve el numero de la revision vigente del pedido
+func (pedido Pedido) ObtenerNumeroRevision() int {
+	return len(pedido.ObtenerRevisiones())
+}
+
+// Devuelve cuantas unidades del producto ya se devolvieron, sumando todas las devoluciones del pedido
+func (pedido Pedido) ObtenerCantidadDevuelta(codigoProducto string) int {
+	cantidad := 0
+	for _, devolucion := range pedido.Devoluciones {
+		for _, producto := range devolucion.Productos {
+			if producto.CodigoProducto == codigoProducto {
+				cantidad += producto.Cantidad
+			}
+		}
+	}
+	return cantidad
+}
+
+// Devuelve el valor de todo lo que se devolvio del pedido
+func (pedido Pedido) ObtenerPrecioTotalDevuelto() float64 {
+	var precioTotal float64 = 0
+	for _, devolucion := range pedido.Devoluciones {
+		precioTotal += devolucion.ObtenerPrecioTotal()
+	}
+	return precioTotal
 }
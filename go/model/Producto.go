@@ -11,10 +11,29 @@ type Producto struct {
 	TipoDeProducto           TipoProducto       `bson:"tipo_producto"`
 	Nombre                   string             `bson:"nombre"`
 	PesoUnitario             float64            `bson:"peso_unitario"`
+	AltoUnitario             float64            `bson:"alto_unitario"`
+	AnchoUnitario            float64            `bson:"ancho_unitario"`
+	LargoUnitario            float64            `bson:"largo_unitario"`
+	VolumenUnitario          float64            `bson:"volumen_unitario"`
 	PrecioUnitario           float64            `bson:"precio_unitario"`
 	StockMinimo              int                `bson:"stock_minimo"`
 	StockActual              int                `bson:"stock_actual"`
+	StockReservado           int                `bson:"stock_reservado"`
 	FechaCreacion            time.Time          `bson:"fecha_creacion"`
 	FechaUltimaActualizacion time.Time          `bson:"fecha_ultima_actualizacion"`
 	IdCreador                string             `bson:"id_creador"`
+	Version                  int                `bson:"version"`
+}
+
+// El stock disponible es el que todavia no fue reservado por pedidos aceptados
+func (producto Producto) ObtenerStockDisponible() int {
+	return producto.StockActual - producto.StockReservado
+}
+
+// Calcula el volumen en m3 a partir de las medidas en cm. Si falta alguna medida, devuelve 0
+func (producto Producto) CalcularVolumenUnitario() float64 {
+	if producto.AltoUnitario <= 0 || producto.AnchoUnitario <= 0 || producto.LargoUnitario <= 0 {
+		return 0
+	}
+	return producto.AltoUnitario * producto.AnchoUnitario * producto.LargoUnitario / 1000000
 }
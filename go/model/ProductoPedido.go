@@ -1,13 +1,18 @@
 package model
 
 type ProductoPedido struct {
-	CodigoProducto string  `bson:"codigo_producto"`
-	Nombre         string  `bson:"nombre_producto"`
-	Cantidad       int     `bson:"cantidad"`
-	PrecioUnitario float64 `bson:"precio_unitario"`
-	PesoUnitario   float64 `bson:"peso_unitario"`
+	CodigoProducto  string  `bson:"codigo_producto"`
+	Nombre          string  `bson:"nombre_producto"`
+	Cantidad        int     `bson:"cantidad"`
+	PrecioUnitario  float64 `bson:"precio_unitario"`
+	PesoUnitario    float64 `bson:"peso_unitario"`
+	VolumenUnitario float64 `bson:"volumen_unitario"`
 }
 
 func (productoPedido ProductoPedido) ObtenerPesoProductoPedido() float64 {
 	return productoPedido.PesoUnitario * float64(productoPedido.Cantidad)
 }
+
+func (productoPedido ProductoPedido) ObtenerVolumenProductoPedido() float64 {
+	return productoPedido.VolumenUnitario * float64(productoPedido.Cantidad)
+}
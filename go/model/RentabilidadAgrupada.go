@@ -0,0 +1,13 @@
+package model
+
+// Resultado del reporte de rentabilidad para un grupo (un tipo de producto, una ciudad, un camion, etc.),
+// tal como lo devuelve la agregacion. El costo de cada envio se reparte entre sus pedidos segun el peso
+type RentabilidadAgrupada struct {
+	Clave            string  `bson:"_id"`
+	Nombre           string  `bson:"nombre"`
+	CantidadPedidos  int     `bson:"cantidad_pedidos"`
+	PesoTransportado float64 `bson:"peso_transportado"`
+	Ingresos         float64 `bson:"ingresos"`
+	Costos           float64 `bson:"costos"`
+	MargenNeto       float64 `bson:"margen_neto"`
+}
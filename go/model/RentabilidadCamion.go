@@ -0,0 +1,13 @@
+package model
+
+// Resultado del reporte de rentabilidad de un camion en un periodo, tal como lo devuelve la agregacion
+type RentabilidadCamion struct {
+	Patente             string  `bson:"patente"`
+	CantidadEnvios      int     `bson:"cantidad_envios"`
+	KmRecorridos        int     `bson:"km_recorridos"`
+	PesoTransportado    float64 `bson:"peso_transportado"`
+	FactorCargaPromedio float64 `bson:"factor_carga_promedio"`
+	Ingresos            float64 `bson:"ingresos"`
+	Costos              float64 `bson:"costos"`
+	MargenNeto          float64 `bson:"margen_neto"`
+}
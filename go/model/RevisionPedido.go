@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Estado que tenia un pedido antes de ser editado. Se guarda una por cada edicion
+type RevisionPedido struct {
+	Numero            int              `bson:"numero"`
+	ProductosElegidos []ProductoPedido `bson:"productos_elegidos"`
+	CiudadDestino     string           `bson:"ciudad_destino"`
+	FechaCreacion     time.Time        `bson:"fecha_creacion"`
+	IdCreador         string           `bson:"id_creador"`
+}
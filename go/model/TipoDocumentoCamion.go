@@ -0,0 +1,16 @@
+package model
+
+type TipoDocumentoCamion string
+
+const (
+	Seguro               TipoDocumentoCamion = "Seguro"
+	VTV                  TipoDocumentoCamion = "VTV"
+	PermisoDeCirculacion TipoDocumentoCamion = "Permiso de Circulacion"
+)
+
+// Documentos que todo camion deberia tener cargados. Si falta alguno se avisa junto con los vencimientos
+var TiposDocumentoCamionObligatorios = []TipoDocumentoCamion{Seguro, VTV}
+
+func EsUnTipoDocumentoCamionValido(tipo TipoDocumentoCamion) bool {
+	return tipo == Seguro || tipo == VTV || tipo == PermisoDeCirculacion
+}
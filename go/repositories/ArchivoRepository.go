@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"TPIntegrador/utils"
+	"bytes"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ArchivoRepositoryInterface interface {
+	GuardarArchivo(nombre string, tipo string, contenido []byte) (string, error)
+	ObtenerArchivo(id string) ([]byte, error)
+	EliminarArchivo(id string) error
+}
+
+// Guarda los archivos en GridFS, dentro de la misma base de datos de la empresa
+type ArchivoRepository struct {
+	db database.DB
+}
+
+func NewArchivoRepository(db database.DB) *ArchivoRepository {
+	return &ArchivoRepository{
+		db: db,
+	}
+}
+
+func (repository *ArchivoRepository) obtenerBucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(repository.db.GetClient().Database("empresa"), options.GridFSBucket().SetName("archivos"))
+}
+
+func (repository *ArchivoRepository) GuardarArchivo(nombre string, tipo string, contenido []byte) (string, error) {
+	bucket, err := repository.obtenerBucket()
+
+	if err != nil {
+		return "", err
+	}
+
+	//Guardamos el tipo de contenido en la metadata para poder devolverlo luego
+	opciones := options.GridFSUpload().SetMetadata(bson.M{"tipo": tipo})
+
+	id, err := bucket.UploadFromStream(nombre, bytes.NewReader(contenido), opciones)
+
+	if err != nil {
+		return "", err
+	}
+
+	return utils.GetStringIDFromObjectID(id), nil
+}
+
+func (repository *ArchivoRepository) ObtenerArchivo(id string) ([]byte, error) {
+	bucket, err := repository.obtenerBucket()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var contenido bytes.Buffer
+
+	_, err = bucket.DownloadToStream(utils.GetObjectIDFromStringID(id), &contenido)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contenido.Bytes(), nil
+}
+
+func (repository *ArchivoRepository) EliminarArchivo(id string) error {
+	bucket, err := repository.obtenerBucket()
+
+	if err != nil {
+		return err
+	}
+
+	return bucket.Delete(utils.GetObjectIDFromStringID(id))
+}
@@ -10,21 +10,26 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CamionRepositoryInterface interface {
 	CrearCamion(*model.Camion) error
 	ObtenerCamiones(utils.FiltroCamion) ([]*model.Camion, error)
+	ObtenerPaginaCamiones(utils.FiltroCamion) ([]*model.Camion, *model.Pagina, error)
 	ActualizarCamion(*model.Camion) error
+	ActualizarDocumentosCamion(*model.Camion) error
 }
 
 type CamionRepository struct {
-	db database.DB
+	db  database.DB
+	ctx context.Context
 }
 
 func NewCamionRepository(db database.DB) *CamionRepository {
 	return &CamionRepository{
-		db: db,
+		db:  db,
+		ctx: context.Background(),
 	}
 }
 
@@ -35,27 +40,34 @@ func (repository CamionRepository) CrearCamion(camion *model.Camion) error {
 	//Seteamos las fechas para el objeto camion
 	camion.FechaCreacion = time.Now()
 	camion.FechaUltimaActualizacion = time.Now()
+	camion.Version = 1
 
 	collection := repository.db.GetClient().Database("empresa").Collection("camiones")
-	_, err := collection.InsertOne(context.Background(), camion)
+	_, err := collection.InsertOne(repository.ctx, camion)
+
+	//La patente tiene un indice unico entre los camiones activos
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("ya existe un camion con la patente " + camion.Patente)
+	}
+
 	return err
 }
 
 func (repository CamionRepository) obtenerCamiones(filtro bson.M) ([]*model.Camion, error) {
 	collection := repository.db.GetClient().Database("empresa").Collection("camiones")
 
-	cursor, err := collection.Find(context.TODO(), filtro)
+	cursor, err := collection.Find(repository.ctx, filtro)
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer cursor.Close(context.Background())
+	defer cursor.Close(repository.ctx)
 
 	//Inicializamos el slice de camiones por si no hay camiones
 	camiones := make([]*model.Camion, 0)
 
-	for cursor.Next(context.Background()) {
+	for cursor.Next(repository.ctx) {
 		var camion model.Camion
 		err := cursor.Decode(&camion)
 		if err != nil {
@@ -68,7 +80,43 @@ func (repository CamionRepository) obtenerCamiones(filtro bson.M) ([]*model.Cami
 	return camiones, err
 }
 
+// Campos por los que se pueden ordenar los listados de camiones
+var camposOrdenCamiones = map[string]bool{
+	"patente":             true,
+	"peso_maximo":         true,
+	"volumen_maximo":      true,
+	"costo_por_kilometro": true,
+	"fecha_creacion":      true,
+}
+
 func (repository CamionRepository) ObtenerCamiones(filtro utils.FiltroCamion) ([]*model.Camion, error) {
+	return repository.obtenerCamiones(filtroCamiones(filtro))
+}
+
+// Devuelve la pagina de camiones que pide la paginacion del filtro, en el orden indicado
+func (repository CamionRepository) ObtenerPaginaCamiones(filtro utils.FiltroCamion) ([]*model.Camion, *model.Pagina, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("camiones")
+
+	documentos, pagina, err := buscarPagina(repository.ctx, collection, filtroCamiones(filtro), filtro.Paginacion, camposOrdenCamiones)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	camiones := make([]*model.Camion, 0, len(documentos))
+	for _, documento := range documentos {
+		var camion model.Camion
+		err := bson.Unmarshal(documento, &camion)
+		if err != nil {
+			return nil, nil, err
+		}
+		camiones = append(camiones, &camion)
+	}
+
+	return camiones, pagina, nil
+}
+
+// Arma el filtro de la base de datos a partir del filtro de camiones
+func filtroCamiones(filtro utils.FiltroCamion) bson.M {
 	//Inicializamos el filtro vacio
 	filtroBD := bson.M{}
 
@@ -82,7 +130,7 @@ func (repository CamionRepository) ObtenerCamiones(filtro utils.FiltroCamion) ([
 		filtroBD["esta_activo"] = filtro.EstaActivo
 	}
 
-	return repository.obtenerCamiones(filtroBD)
+	return filtroBD
 }
 
 func (repository CamionRepository) ActualizarCamion(camion *model.Camion) error {
@@ -97,21 +145,63 @@ func (repository CamionRepository) ActualizarCamion(camion *model.Camion) error
 	actualizacion := bson.M{"$set": bson.M{
 		"patente":                    camion.Patente,
 		"peso_maximo":                camion.PesoMaximo,
+		"volumen_maximo":             camion.VolumenMaximo,
 		"costo_por_kilometro":        camion.CostoPorKilometro,
 		"fecha_ultima_actualizacion": camion.FechaUltimaActualizacion,
 		"esta_activo":                camion.EstaActivo,
-	}}
+	}, "$inc": bson.M{"version": 1}}
+
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, camion.Version), actualizacion)
 
-	operacion, err := collection.UpdateOne(context.TODO(), filtro, actualizacion)
+	//Al reactivar un camion, puede que otro activo ya use su patente
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("ya existe un camion activo con la patente " + camion.Patente)
+	}
 
 	if err != nil {
 		return err
 	}
 
 	//Si no se actualizo ningun camion, devolvemos un error
-	if operacion.MatchedCount == 0 {
-		return errors.New("no se encontró el camion a actualizar")
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el camion a actualizar")
+
+	if err != nil {
+		return err
 	}
 
+	camion.Version++
+
+	return nil
+}
+
+// Guarda los documentos del camion. Se separa de ActualizarCamion para que editar el camion no pise los documentos
+func (repository CamionRepository) ActualizarDocumentosCamion(camion *model.Camion) error {
+	camion.FechaUltimaActualizacion = time.Now()
+
+	collection := repository.db.GetClient().Database("empresa").Collection("camiones")
+
+	filtro := bson.M{"patente": camion.Patente}
+
+	actualizacion := bson.M{"$set": bson.M{
+		"documentos":                 camion.Documentos,
+		"fecha_ultima_actualizacion": camion.FechaUltimaActualizacion,
+	}, "$inc": bson.M{"version": 1}}
+
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, camion.Version), actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el camion a actualizar")
+
+	if err != nil {
+		return err
+	}
+
+	camion.Version++
+
 	return nil
 }
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"TPIntegrador/model"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CiudadRepositoryInterface interface {
+	GuardarCiudad(*model.Ciudad) error
+	ObtenerCiudades() ([]*model.Ciudad, error)
+	GuardarDistancia(*model.DistanciaCiudades) error
+	ObtenerDistancias(claves []string) ([]*model.DistanciaCiudades, error)
+}
+
+type CiudadRepository struct {
+	db  database.DB
+	ctx context.Context
+}
+
+func NewCiudadRepository(db database.DB) *CiudadRepository {
+	return &CiudadRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+// Crea la ciudad, o actualiza su nombre si ya existia una con la misma clave
+func (repository *CiudadRepository) GuardarCiudad(ciudad *model.Ciudad) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("ciudades")
+
+	ciudad.Clave = model.ObtenerClaveCiudad(ciudad.Nombre)
+	ciudad.FechaUltimaActualizacion = time.Now()
+
+	filtro := bson.M{"clave": ciudad.Clave}
+
+	actualizacion := bson.M{
+		"$set": bson.M{
+			"nombre":                     ciudad.Nombre,
+			"fecha_ultima_actualizacion": ciudad.FechaUltimaActualizacion,
+		},
+		//La fecha de creacion y el creador solo se guardan la primera vez
+		"$setOnInsert": bson.M{
+			"_id":            primitive.NewObjectID(),
+			"fecha_creacion": ciudad.FechaUltimaActualizacion,
+			"id_creador":     ciudad.IdCreador,
+		},
+	}
+
+	_, err := collection.UpdateOne(repository.ctx, filtro, actualizacion, options.Update().SetUpsert(true))
+	return err
+}
+
+func (repository *CiudadRepository) ObtenerCiudades() ([]*model.Ciudad, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("ciudades")
+
+	opciones := options.Find().SetSort(bson.D{{Key: "nombre", Value: 1}})
+
+	cursor, err := collection.Find(repository.ctx, bson.M{}, opciones)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice de ciudades por si no hay ciudades
+	ciudades := make([]*model.Ciudad, 0)
+
+	for cursor.Next(repository.ctx) {
+		var ciudad model.Ciudad
+		err := cursor.Decode(&ciudad)
+		if err != nil {
+			return nil, err
+		}
+		ciudades = append(ciudades, &ciudad)
+	}
+
+	return ciudades, cursor.Err()
+}
+
+// Crea o reemplaza la distancia entre dos ciudades. Como vale para ambos sentidos, se guarda una sola vez por par
+func (repository *CiudadRepository) GuardarDistancia(distancia *model.DistanciaCiudades) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("distancias")
+
+	distancia.Origen = model.ObtenerClaveCiudad(distancia.Origen)
+	distancia.Destino = model.ObtenerClaveCiudad(distancia.Destino)
+
+	//Ordenamos el par para que A-B y B-A sean el mismo documento
+	if distancia.Destino < distancia.Origen {
+		distancia.Origen, distancia.Destino = distancia.Destino, distancia.Origen
+	}
+
+	distancia.FechaUltimaActualizacion = time.Now()
+
+	filtro := bson.M{"origen": distancia.Origen, "destino": distancia.Destino}
+
+	actualizacion := bson.M{
+		"$set": bson.M{
+			"km":                         distancia.Km,
+			"fecha_ultima_actualizacion": distancia.FechaUltimaActualizacion,
+			"id_creador":                 distancia.IdCreador,
+		},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+	}
+
+	_, err := collection.UpdateOne(repository.ctx, filtro, actualizacion, options.Update().SetUpsert(true))
+	return err
+}
+
+// Devuelve las distancias entre cualquier par de las ciudades indicadas
+func (repository *CiudadRepository) ObtenerDistancias(claves []string) ([]*model.DistanciaCiudades, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("distancias")
+
+	filtro := bson.M{
+		"origen":  bson.M{"$in": claves},
+		"destino": bson.M{"$in": claves},
+	}
+
+	cursor, err := collection.Find(repository.ctx, filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	distancias := make([]*model.DistanciaCiudades, 0)
+
+	for cursor.Next(repository.ctx) {
+		var distancia model.DistanciaCiudades
+		err := cursor.Decode(&distancia)
+		if err != nil {
+			return nil, err
+		}
+		distancias = append(distancias, &distancia)
+	}
+
+	return distancias, cursor.Err()
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ConductorRepositoryInterface interface {
+	CrearConductor(*model.Conductor) error
+	ObtenerConductores(utils.FiltroConductor) ([]*model.Conductor, error)
+	ActualizarConductor(*model.Conductor) error
+	CrearAsignacion(*model.AsignacionCamion) error
+	ObtenerAsignaciones(utils.FiltroAsignacionCamion) ([]*model.AsignacionCamion, error)
+	ObtenerAsignacionPorId(*model.AsignacionCamion) (*model.AsignacionCamion, error)
+	FinalizarAsignacion(*model.AsignacionCamion) error
+}
+
+type ConductorRepository struct {
+	db  database.DB
+	ctx context.Context
+}
+
+func NewConductorRepository(db database.DB) *ConductorRepository {
+	return &ConductorRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (repository *ConductorRepository) CrearConductor(conductor *model.Conductor) error {
+	//Nos aseguramos de que el Id sea creado por mongo
+	conductor.ObjectId = primitive.NewObjectID()
+
+	//Seteamos las fechas del conductor
+	conductor.FechaCreacion = time.Now()
+	conductor.FechaUltimaActualizacion = time.Now()
+	conductor.Version = 1
+
+	collection := repository.db.GetClient().Database("empresa").Collection("conductores")
+	_, err := collection.InsertOne(repository.ctx, conductor)
+	return err
+}
+
+func (repository *ConductorRepository) ObtenerConductores(filtroConductor utils.FiltroConductor) ([]*model.Conductor, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("conductores")
+
+	//Inicializamos el filtro vacio
+	filtro := bson.M{}
+
+	if filtroConductor.CodigoUsuario != "" {
+		filtro["codigo_usuario"] = filtroConductor.CodigoUsuario
+	}
+
+	if filtroConductor.FiltrarPorEstaActivo {
+		filtro["esta_activo"] = filtroConductor.EstaActivo
+	}
+
+	opciones := options.Find().SetSort(bson.D{{Key: "nombre", Value: 1}})
+
+	cursor, err := collection.Find(repository.ctx, filtro, opciones)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice de conductores por si no hay conductores
+	conductores := make([]*model.Conductor, 0)
+
+	for cursor.Next(repository.ctx) {
+		var conductor model.Conductor
+
+		err := cursor.Decode(&conductor)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conductores = append(conductores, &conductor)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return conductores, nil
+}
+
+func (repository *ConductorRepository) ActualizarConductor(conductor *model.Conductor) error {
+	//Actualizamos la fecha de actualizacion del conductor
+	conductor.FechaUltimaActualizacion = time.Now()
+
+	collection := repository.db.GetClient().Database("empresa").Collection("conductores")
+
+	filtro := bson.M{"codigo_usuario": conductor.CodigoUsuario}
+
+	//El codigo de usuario, la fecha de creacion y el creador no se modifican nunca
+	actualizacion := bson.M{"$set": bson.M{
+		"nombre":                     conductor.Nombre,
+		"licencia":                   conductor.Licencia,
+		"esta_activo":                conductor.EstaActivo,
+		"fecha_ultima_actualizacion": conductor.FechaUltimaActualizacion,
+	}, "$inc": bson.M{"version": 1}}
+
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, conductor.Version), actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el conductor a actualizar")
+
+	if err != nil {
+		return err
+	}
+
+	conductor.Version++
+
+	return nil
+}
+
+func (repository *ConductorRepository) CrearAsignacion(asignacion *model.AsignacionCamion) error {
+	//Nos aseguramos de que el Id sea creado por mongo
+	asignacion.ObjectId = primitive.NewObjectID()
+	asignacion.FechaCreacion = time.Now()
+
+	collection := repository.db.GetClient().Database("empresa").Collection("asignaciones_camiones")
+	_, err := collection.InsertOne(repository.ctx, asignacion)
+	return err
+}
+
+func (repository *ConductorRepository) ObtenerAsignaciones(filtroAsignacion utils.FiltroAsignacionCamion) ([]*model.AsignacionCamion, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("asignaciones_camiones")
+
+	//Inicializamos el filtro vacio
+	filtro := bson.M{}
+
+	if filtroAsignacion.PatenteCamion != "" {
+		filtro["patente_camion"] = filtroAsignacion.PatenteCamion
+	}
+
+	if filtroAsignacion.CodigoConductor != "" {
+		filtro["codigo_conductor"] = filtroAsignacion.CodigoConductor
+	}
+
+	//Una asignacion esta vigente si empezo y todavia no termino, o no tiene fecha de fin
+	if !filtroAsignacion.VigenteEn.IsZero() {
+		filtro["fecha_desde"] = bson.M{"$lte": filtroAsignacion.VigenteEn}
+		filtro["$or"] = bson.A{
+			bson.M{"fecha_hasta": time.Time{}},
+			bson.M{"fecha_hasta": bson.M{"$gt": filtroAsignacion.VigenteEn}},
+		}
+	}
+
+	opciones := options.Find().SetSort(bson.D{{Key: "fecha_desde", Value: 1}})
+
+	cursor, err := collection.Find(repository.ctx, filtro, opciones)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice de asignaciones por si no hay asignaciones
+	asignaciones := make([]*model.AsignacionCamion, 0)
+
+	for cursor.Next(repository.ctx) {
+		var asignacion model.AsignacionCamion
+
+		err := cursor.Decode(&asignacion)
+
+		if err != nil {
+			return nil, err
+		}
+
+		asignaciones = append(asignaciones, &asignacion)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return asignaciones, nil
+}
+
+func (repository *ConductorRepository) ObtenerAsignacionPorId(asignacionConId *model.AsignacionCamion) (*model.AsignacionCamion, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("asignaciones_camiones")
+
+	var asignacion model.AsignacionCamion
+
+	err := collection.FindOne(repository.ctx, bson.M{"_id": asignacionConId.ObjectId}).Decode(&asignacion)
+
+	if err != nil {
+		return nil, errors.New("no se encontró la asignacion")
+	}
+
+	return &asignacion, nil
+}
+
+// Cierra el periodo de la asignacion en su fecha hasta
+func (repository *ConductorRepository) FinalizarAsignacion(asignacion *model.AsignacionCamion) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("asignaciones_camiones")
+
+	filtro := bson.M{"_id": asignacion.ObjectId}
+
+	actualizacion := bson.M{"$set": bson.M{"fecha_hasta": asignacion.FechaHasta}}
+
+	operacion, err := collection.UpdateOne(repository.ctx, filtro, actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	if operacion.MatchedCount == 0 {
+		return errors.New("no se encontró la asignacion a finalizar")
+	}
+
+	return nil
+}
@@ -2,10 +2,9 @@ package repositories
 
 import (
 	"TPIntegrador/database"
-	"TPIntegrador/utils"
 	"TPIntegrador/model"
+	"TPIntegrador/utils"
 	"context"
-	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,18 +14,21 @@ import (
 type EnvioRepositoryInterface interface {
 	CrearEnvio(*model.Envio) error
 	ObtenerEnvios(*utils.FiltroEnvio) ([]*model.Envio, error)
+	ObtenerPaginaEnvios(*utils.FiltroEnvio) ([]*model.Envio, *model.Pagina, error)
 	ObtenerEnvioPorId(*model.Envio) (*model.Envio, error)
 	ObtenerCantidadEnviosPorEstado(model.EstadoEnvio) (int, error)
 	ActualizarEnvio(*model.Envio) error
 }
 
 type EnvioRepository struct {
-	db database.DB
+	db  database.DB
+	ctx context.Context
 }
 
 func NewEnvioRepository(db database.DB) *EnvioRepository {
 	return &EnvioRepository{
-		db: db,
+		db:  db,
+		ctx: context.Background(),
 	}
 }
 
@@ -39,8 +41,9 @@ func (repository EnvioRepository) CrearEnvio(envio *model.Envio) error {
 	//Coloco las fechas
 	envio.FechaCreacion = time.Now()
 	envio.FechaUltimaActualizacion = time.Now()
+	envio.Version = 1
 
-	_, err := collection.InsertOne(context.Background(), envio)
+	_, err := collection.InsertOne(repository.ctx, envio)
 
 	return err
 }
@@ -48,18 +51,18 @@ func (repository EnvioRepository) CrearEnvio(envio *model.Envio) error {
 func (repository EnvioRepository) obtenerEnvios(filtro bson.M) ([]*model.Envio, error) {
 	collection := repository.db.GetClient().Database("empresa").Collection("envios")
 
-	cursor, err := collection.Find(context.TODO(), filtro)
+	cursor, err := collection.Find(repository.ctx, filtro)
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer cursor.Close(context.Background())
+	defer cursor.Close(repository.ctx)
 
 	//Inicializo el slice de envios por si no hay envios
 	envios := make([]*model.Envio, 0)
 
-	for cursor.Next(context.Background()) {
+	for cursor.Next(repository.ctx) {
 		var envio model.Envio
 		err := cursor.Decode(&envio)
 		if err != nil {
@@ -72,7 +75,42 @@ func (repository EnvioRepository) obtenerEnvios(filtro bson.M) ([]*model.Envio,
 	return envios, err
 }
 
+// Campos por los que se pueden ordenar los listados de envios
+var camposOrdenEnvios = map[string]bool{
+	"fecha_creacion":             true,
+	"fecha_ultima_actualizacion": true,
+	"patente_camion":             true,
+	"estado":                     true,
+}
+
 func (repository EnvioRepository) ObtenerEnvios(filtroEnvio *utils.FiltroEnvio) ([]*model.Envio, error) {
+	return repository.obtenerEnvios(filtroEnvios(filtroEnvio))
+}
+
+// Devuelve la pagina de envios que pide la paginacion del filtro, en el orden indicado
+func (repository EnvioRepository) ObtenerPaginaEnvios(filtroEnvio *utils.FiltroEnvio) ([]*model.Envio, *model.Pagina, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("envios")
+
+	documentos, pagina, err := buscarPagina(repository.ctx, collection, filtroEnvios(filtroEnvio), filtroEnvio.Paginacion, camposOrdenEnvios)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envios := make([]*model.Envio, 0, len(documentos))
+	for _, documento := range documentos {
+		var envio model.Envio
+		err := bson.Unmarshal(documento, &envio)
+		if err != nil {
+			return nil, nil, err
+		}
+		envios = append(envios, &envio)
+	}
+
+	return envios, pagina, nil
+}
+
+// Arma el filtro de la base de datos a partir del filtro de envios
+func filtroEnvios(filtroEnvio *utils.FiltroEnvio) bson.M {
 	//Desestructuramos el filtro
 	patente := filtroEnvio.PatenteCamion
 	estado := filtroEnvio.Estado
@@ -108,10 +146,12 @@ func (repository EnvioRepository) ObtenerEnvios(filtroEnvio *utils.FiltroEnvio)
 	}
 
 	//Si el estado es despachado, no tiene paradas y no tiene sentido filtrar por ultima parada
-	if ultimaParada != "" && estado != model.ADespachar{
-		customJavaScript := "this.paradas[this.paradas.length - 1].ciudad === '" + ultimaParada + "'"
-
-		filtro["$where"] = customJavaScript
+	if ultimaParada != "" && estado != model.ADespachar {
+		//Compara la ciudad de la ultima parada con el valor como literal, para que no se tome como un campo o un operador
+		filtro["$expr"] = bson.M{"$eq": bson.A{
+			bson.M{"$arrayElemAt": bson.A{"$paradas.ciudad", -1}},
+			bson.M{"$literal": ultimaParada},
+		}}
 	}
 
 	//Tomo la fecha de ultima actualizacion en 0001-01-01 como la ausencia de filtro
@@ -126,7 +166,7 @@ func (repository EnvioRepository) ObtenerEnvios(filtroEnvio *utils.FiltroEnvio)
 		filtro["fecha_ultima_actualizacion"] = filtroFecha
 	}
 
-	return repository.obtenerEnvios(filtro)
+	return filtro
 }
 
 func (repository EnvioRepository) ObtenerEnvioPorId(envio *model.Envio) (*model.Envio, error) {
@@ -151,7 +191,7 @@ func (repository EnvioRepository) ObtenerCantidadEnviosPorEstado(estado model.Es
 
 	filtro := bson.M{"estado": estado}
 
-	cantidad, err := collection.CountDocuments(context.Background(), filtro)
+	cantidad, err := collection.CountDocuments(repository.ctx, filtro)
 
 	if err != nil {
 		return 0, err
@@ -169,18 +209,28 @@ func (repository EnvioRepository) ActualizarEnvio(envio *model.Envio) error {
 
 	//Solo actualizamos ciertos campos
 	actualizacion := bson.M{"$set": bson.M{
-		"estado":                    envio.Estado,
+		"estado":                     envio.Estado,
 		"fecha_ultima_actualizacion": envio.FechaUltimaActualizacion,
-		"patente_camion":            envio.PatenteCamion,
-		"pedidos":                   envio.Pedidos,
-		"paradas":                   envio.Paradas,
-	}}
+		"patente_camion":             envio.PatenteCamion,
+		"pedidos":                    envio.Pedidos,
+		"paradas":                    envio.Paradas,
+		"fecha_despacho":             envio.FechaDespacho,
+	}, "$inc": bson.M{"version": 1}}
 
-	operacion, err := collection.UpdateOne(context.Background(), filtro, actualizacion)
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, envio.Version), actualizacion)
 
-	if operacion.MatchedCount == 0 {
-		return errors.New("no se encontró el envio a actualizar")
+	if err != nil {
+		return err
 	}
 
-	return err
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el envio a actualizar")
+
+	if err != nil {
+		return err
+	}
+
+	envio.Version++
+
+	return nil
 }
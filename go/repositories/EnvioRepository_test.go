@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFiltroEnviosUltimaParadaNoEscapaDelFiltro(t *testing.T) {
+	casos := []struct {
+		nombre       string
+		ultimaParada string
+	}{
+		{nombre: "ciudad comun", ultimaParada: "Cordoba"},
+		{nombre: "javascript", ultimaParada: "'; return true; //"},
+		{nombre: "comilla doble", ultimaParada: `"`},
+		{nombre: "operador $where", ultimaParada: "$where"},
+		{nombre: "referencia a un campo", ultimaParada: "$estado"},
+		{nombre: "operador como json", ultimaParada: `{"$gt":""}`},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			filtro := filtroEnvios(&utils.FiltroEnvio{UltimaParada: caso.ultimaParada})
+
+			if contieneClave(filtro, "$where") {
+				t.Fatalf("el filtro no puede usar $where: %v", filtro)
+			}
+
+			esperado := bson.M{"$eq": bson.A{
+				bson.M{"$arrayElemAt": bson.A{"$paradas.ciudad", -1}},
+				bson.M{"$literal": caso.ultimaParada},
+			}}
+
+			if !reflect.DeepEqual(filtro["$expr"], esperado) {
+				t.Fatalf("la ultima parada tiene que compararse como literal, se obtuvo %v", filtro["$expr"])
+			}
+
+			//Ademas de la expresion, el filtro no puede tener otras condiciones
+			if len(filtro) != 1 {
+				t.Fatalf("el filtro tiene condiciones de mas: %v", filtro)
+			}
+		})
+	}
+}
+
+func TestFiltroEnviosSinUltimaParadaNoUsaExpr(t *testing.T) {
+	casos := []struct {
+		nombre string
+		filtro utils.FiltroEnvio
+	}{
+		{nombre: "sin ultima parada", filtro: utils.FiltroEnvio{}},
+		{nombre: "envio a despachar", filtro: utils.FiltroEnvio{UltimaParada: "Cordoba", Estado: model.ADespachar}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			filtro := filtroEnvios(&caso.filtro)
+
+			if _, existe := filtro["$expr"]; existe {
+				t.Fatalf("el filtro no deberia comparar la ultima parada: %v", filtro)
+			}
+		})
+	}
+}
+
+// Busca la clave en el filtro y en todas las condiciones anidadas
+func contieneClave(valor interface{}, clave string) bool {
+	switch valor := valor.(type) {
+	case bson.M:
+		for k, v := range valor {
+			if k == clave || contieneClave(v, clave) {
+				return true
+			}
+		}
+	case bson.D:
+		for _, elemento := range valor {
+			if elemento.Key == clave || contieneClave(elemento.Value, clave) {
+				return true
+			}
+		}
+	case bson.A:
+		for _, v := range valor {
+			if contieneClave(v, clave) {
+				return true
+			}
+		}
+	}
+	return false
+}
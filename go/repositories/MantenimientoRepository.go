@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MantenimientoRepositoryInterface interface {
+	CrearMantenimiento(*model.Mantenimiento) error
+	ObtenerMantenimientos(utils.FiltroMantenimiento) ([]*model.Mantenimiento, error)
+	ObtenerMantenimientoPorId(*model.Mantenimiento) (*model.Mantenimiento, error)
+	ActualizarMantenimiento(*model.Mantenimiento) error
+}
+
+type MantenimientoRepository struct {
+	db  database.DB
+	ctx context.Context
+}
+
+func NewMantenimientoRepository(db database.DB) *MantenimientoRepository {
+	return &MantenimientoRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (repository *MantenimientoRepository) CrearMantenimiento(mantenimiento *model.Mantenimiento) error {
+	//Nos aseguramos de que el Id sea creado por mongo
+	mantenimiento.ObjectId = primitive.NewObjectID()
+
+	//Seteamos las fechas del mantenimiento
+	mantenimiento.FechaCreacion = time.Now()
+	mantenimiento.FechaUltimaActualizacion = time.Now()
+	mantenimiento.Version = 1
+
+	collection := repository.db.GetClient().Database("empresa").Collection("mantenimientos")
+	_, err := collection.InsertOne(repository.ctx, mantenimiento)
+	return err
+}
+
+func (repository *MantenimientoRepository) ObtenerMantenimientos(filtroMantenimiento utils.FiltroMantenimiento) ([]*model.Mantenimiento, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("mantenimientos")
+
+	//Inicializamos el filtro vacio
+	filtro := bson.M{}
+
+	if filtroMantenimiento.PatenteCamion != "" {
+		filtro["patente_camion"] = filtroMantenimiento.PatenteCamion
+	}
+
+	if len(filtroMantenimiento.Estados) > 0 {
+		filtro["estado"] = bson.M{"$in": filtroMantenimiento.Estados}
+	}
+
+	//Los mas recientes primero
+	opciones := options.Find().SetSort(bson.D{{Key: "fecha_creacion", Value: -1}})
+
+	cursor, err := collection.Find(repository.ctx, filtro, opciones)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice de mantenimientos por si no hay mantenimientos
+	mantenimientos := make([]*model.Mantenimiento, 0)
+
+	for cursor.Next(repository.ctx) {
+		var mantenimiento model.Mantenimiento
+
+		err := cursor.Decode(&mantenimiento)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mantenimientos = append(mantenimientos, &mantenimiento)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return mantenimientos, nil
+}
+
+func (repository *MantenimientoRepository) ObtenerMantenimientoPorId(mantenimientoConId *model.Mantenimiento) (*model.Mantenimiento, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("mantenimientos")
+
+	var mantenimiento model.Mantenimiento
+
+	err := collection.FindOne(repository.ctx, bson.M{"_id": mantenimientoConId.ObjectId}).Decode(&mantenimiento)
+
+	if err != nil {
+		return nil, errors.New("no se encontró el mantenimiento")
+	}
+
+	return &mantenimiento, nil
+}
+
+func (repository *MantenimientoRepository) ActualizarMantenimiento(mantenimiento *model.Mantenimiento) error {
+	//Actualizamos la fecha de actualizacion del mantenimiento
+	mantenimiento.FechaUltimaActualizacion = time.Now()
+
+	collection := repository.db.GetClient().Database("empresa").Collection("mantenimientos")
+
+	filtro := bson.M{"_id": mantenimiento.ObjectId}
+
+	//El camion, la fecha de creacion y el creador no se modifican nunca
+	actualizacion := bson.M{"$set": bson.M{
+		"descripcion":                mantenimiento.Descripcion,
+		"estado":                     mantenimiento.Estado,
+		"fecha_programada":           mantenimiento.FechaProgramada,
+		"km_programados":             mantenimiento.KmProgramados,
+		"fecha_inicio":               mantenimiento.FechaInicio,
+		"fecha_realizacion":          mantenimiento.FechaRealizacion,
+		"km_al_realizar":             mantenimiento.KmAlRealizar,
+		"costo":                      mantenimiento.Costo,
+		"observaciones":              mantenimiento.Observaciones,
+		"fecha_ultima_actualizacion": mantenimiento.FechaUltimaActualizacion,
+	}, "$inc": bson.M{"version": 1}}
+
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, mantenimiento.Version), actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el mantenimiento a actualizar")
+
+	if err != nil {
+		return err
+	}
+
+	mantenimiento.Version++
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MovimientoStockRepositoryInterface interface {
+	RegistrarMovimiento(*model.MovimientoStock) error
+	ObtenerMovimientos(*utils.FiltroMovimientoStock) ([]*model.MovimientoStock, error)
+	ObtenerTotalesMovimientos(codigoProducto string) (int, int, error)
+}
+
+type MovimientoStockRepository struct {
+	db  database.DB
+	ctx context.Context
+}
+
+func NewMovimientoStockRepository(db database.DB) *MovimientoStockRepository {
+	return &MovimientoStockRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (repository *MovimientoStockRepository) RegistrarMovimiento(movimiento *model.MovimientoStock) error {
+	//Nos aseguramos de que el Id sea creado por mongo
+	movimiento.ObjectId = primitive.NewObjectID()
+
+	//Los movimientos no se modifican, asi que solo tienen fecha de creacion
+	movimiento.FechaCreacion = time.Now()
+
+	collection := repository.db.GetClient().Database("empresa").Collection("movimientos_stock")
+	_, err := collection.InsertOne(repository.ctx, movimiento)
+	return err
+}
+
+func (repository *MovimientoStockRepository) ObtenerMovimientos(filtroMovimiento *utils.FiltroMovimientoStock) ([]*model.MovimientoStock, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("movimientos_stock")
+
+	filtro := bson.M{}
+
+	if filtroMovimiento.CodigoProducto != "" {
+		filtro["codigo_producto"] = filtroMovimiento.CodigoProducto
+	}
+
+	//Tomo la fecha en 0001-01-01 como la ausencia de filtro
+	if !filtroMovimiento.FechaDesde.IsZero() || !filtroMovimiento.FechaHasta.IsZero() {
+		filtroFecha := bson.M{}
+		if !filtroMovimiento.FechaDesde.IsZero() {
+			filtroFecha["$gte"] = filtroMovimiento.FechaDesde
+		}
+		if !filtroMovimiento.FechaHasta.IsZero() {
+			filtroFecha["$lte"] = filtroMovimiento.FechaHasta
+		}
+		filtro["fecha_creacion"] = filtroFecha
+	}
+
+	//Los devolvemos en el orden en que ocurrieron
+	opciones := options.Find().SetSort(bson.D{{Key: "fecha_creacion", Value: 1}})
+
+	cursor, err := collection.Find(repository.ctx, filtro, opciones)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice de movimientos por si no hay movimientos
+	movimientos := make([]*model.MovimientoStock, 0)
+
+	for cursor.Next(repository.ctx) {
+		var movimiento model.MovimientoStock
+		err := cursor.Decode(&movimiento)
+		if err != nil {
+			return nil, err
+		}
+		movimientos = append(movimientos, &movimiento)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return movimientos, nil
+}
+
+// Suma todos los movimientos del producto, devolviendo el total del stock actual y el del stock reservado
+func (repository *MovimientoStockRepository) ObtenerTotalesMovimientos(codigoProducto string) (int, int, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("movimientos_stock")
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"codigo_producto": codigoProducto}},
+		bson.M{"$group": bson.M{
+			"_id":                nil,
+			"cantidad":           bson.M{"$sum": "$cantidad"},
+			"cantidad_reservada": bson.M{"$sum": "$cantidad_reservada"},
+		}},
+	}
+
+	cursor, err := collection.Aggregate(repository.ctx, pipeline)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	var totales struct {
+		Cantidad          int `bson:"cantidad"`
+		CantidadReservada int `bson:"cantidad_reservada"`
+	}
+
+	//Si no hay movimientos, los totales quedan en cero
+	if cursor.Next(repository.ctx) {
+		err = cursor.Decode(&totales)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return totales.Cantidad, totales.CantidadReservada, cursor.Err()
+}
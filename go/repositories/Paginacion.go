@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Contenido del cursor: el orden con el que se pidio la pagina y los valores del ultimo documento devuelto
+type cursorPagina struct {
+	Campo string        `bson:"c"`
+	Orden int           `bson:"o"`
+	Valor bson.RawValue `bson:"v"`
+	Id    bson.RawValue `bson:"id"`
+}
+
+// Busca los documentos del filtro en el orden pedido, desde el cursor y hasta el limite de la pagina.
+// Se ordena siempre tambien por _id, para que el orden sea estable y el cursor no saltee ni repita documentos.
+// Los campos por los que se puede ordenar son los de camposOrden
+func buscarPagina(ctx context.Context, collection *mongo.Collection, filtro bson.M, paginacion utils.Paginacion, camposOrden map[string]bool) ([]bson.Raw, *model.Pagina, error) {
+	campo := "_id"
+	if paginacion.OrdenarPor != "" {
+		if !camposOrden[paginacion.OrdenarPor] {
+			return nil, nil, errors.New("no se puede ordenar por " + paginacion.OrdenarPor)
+		}
+		campo = paginacion.OrdenarPor
+	}
+
+	orden := 1
+	if paginacion.Descendente {
+		orden = -1
+	}
+
+	pagina := &model.Pagina{}
+
+	//El total no depende del cursor, solo del filtro
+	if paginacion.EsPaginada() {
+		total, err := collection.CountDocuments(ctx, filtro)
+		if err != nil {
+			return nil, nil, err
+		}
+		pagina.Total = total
+	}
+
+	filtroPagina := filtro
+	if paginacion.Cursor != "" {
+		filtroCursor, err := obtenerFiltroCursor(paginacion.Cursor, campo, orden)
+		if err != nil {
+			return nil, nil, err
+		}
+		filtroPagina = bson.M{"$and": bson.A{filtro, filtroCursor}}
+	}
+
+	ordenamiento := bson.D{{Key: campo, Value: orden}}
+	if campo != "_id" {
+		ordenamiento = append(ordenamiento, bson.E{Key: "_id", Value: orden})
+	}
+
+	opciones := options.Find().SetSort(ordenamiento)
+
+	//Pedimos uno de mas para saber si hay una pagina siguiente
+	if paginacion.Limite > 0 {
+		opciones.SetLimit(int64(paginacion.Limite) + 1)
+	}
+
+	cursor, err := collection.Find(ctx, filtroPagina, opciones)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	documentos := make([]bson.Raw, 0)
+	for cursor.Next(ctx) {
+		//El cursor reutiliza el buffer del documento actual, por eso lo copiamos
+		documentos = append(documentos, append(bson.Raw(nil), cursor.Current...))
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if paginacion.Limite > 0 && len(documentos) > paginacion.Limite {
+		documentos = documentos[:paginacion.Limite]
+
+		siguienteCursor, err := armarCursor(documentos[len(documentos)-1], campo, orden)
+		if err != nil {
+			return nil, nil, err
+		}
+		pagina.SiguienteCursor = siguienteCursor
+	}
+
+	return documentos, pagina, nil
+}
+
+// Arma el cursor que apunta al documento, para seguir la busqueda a partir de el
+func armarCursor(documento bson.Raw, campo string, orden int) (string, error) {
+	//Si el documento no tiene el campo, se guarda null, que es como lo ordena mongo
+	valor, err := documento.LookupErr(campo)
+	if err != nil {
+		valor = bson.RawValue{Type: bsontype.Null}
+	}
+
+	contenido, err := bson.Marshal(cursorPagina{
+		Campo: campo,
+		Orden: orden,
+		Valor: valor,
+		Id:    documento.Lookup("_id"),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(contenido), nil
+}
+
+// Filtro de los documentos que van despues del cursor en el orden pedido
+func obtenerFiltroCursor(cursorCodificado string, campo string, orden int) (bson.M, error) {
+	errCursor := errors.New("el cursor no es válido")
+
+	contenido, err := base64.RawURLEncoding.DecodeString(cursorCodificado)
+	if err != nil {
+		return nil, errCursor
+	}
+
+	var cursor cursorPagina
+	err = bson.Unmarshal(contenido, &cursor)
+	if err != nil {
+		return nil, errCursor
+	}
+
+	if cursor.Campo != campo || cursor.Orden != orden {
+		return nil, errors.New("el cursor corresponde a otro orden, hay que pedirlo con el mismo sort y order")
+	}
+
+	operador := "$gt"
+	if orden < 0 {
+		operador = "$lt"
+	}
+
+	if campo == "_id" {
+		return bson.M{"_id": bson.M{operador: cursor.Id}}, nil
+	}
+
+	//Los que tienen el mismo valor se desempatan por _id, igual que en el ordenamiento
+	mismoValor := bson.M{campo: cursor.Valor, "_id": bson.M{operador: cursor.Id}}
+
+	//Mongo ordena los null y los campos que faltan antes que cualquier valor, pero $gt y $lt solo comparan valores
+	//del mismo tipo. Por eso los null se agregan aparte: en orden ascendente van despues de un null todos los que
+	//tienen valor, y en orden descendente van despues de cualquier valor
+	if cursor.Valor.Type == bsontype.Null {
+		mismoValor = bson.M{campo: nil, "_id": bson.M{operador: cursor.Id}}
+		if orden > 0 {
+			return bson.M{"$or": bson.A{bson.M{campo: bson.M{"$ne": nil}}, mismoValor}}, nil
+		}
+		return mismoValor, nil
+	}
+
+	siguientes := bson.A{
+		bson.M{campo: bson.M{operador: cursor.Valor}},
+		mismoValor,
+	}
+
+	if orden < 0 {
+		siguientes = append(siguientes, bson.M{campo: nil})
+	}
+
+	return bson.M{"$or": siguientes}, nil
+}
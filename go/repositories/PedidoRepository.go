@@ -5,7 +5,6 @@ import (
 	"TPIntegrador/model"
 	"TPIntegrador/utils"
 	"context"
-	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,18 +14,22 @@ import (
 type PedidoRepositoryInterface interface {
 	CrearPedido(*model.Pedido) error
 	ObtenerPedidos(*utils.FiltroPedido) ([]*model.Pedido, error)
+	ObtenerPaginaPedidos(*utils.FiltroPedido) ([]*model.Pedido, *model.Pagina, error)
 	ObtenerPedidoPorId(*model.Pedido) (*model.Pedido, error)
 	ObtenerCantidadPedidosPorEstado(model.EstadoPedido) (int, error)
 	ActualizarPedido(*model.Pedido) error
+	EditarPedido(*model.Pedido) error
 }
 
 type PedidoRepository struct {
-	db database.DB
+	db  database.DB
+	ctx context.Context
 }
 
 func NewPedidoRepository(db database.DB) *PedidoRepository {
 	return &PedidoRepository{
-		db: db,
+		db:  db,
+		ctx: context.Background(),
 	}
 }
 
@@ -37,17 +40,18 @@ func (repository *PedidoRepository) CrearPedido(pedido *model.Pedido) error {
 	//Seteamos las fechas para el objeto pedido
 	pedido.FechaCreacion = time.Now()
 	pedido.FechaUltimaActualizacion = time.Now()
+	pedido.Version = 1
 
 	collection := repository.db.GetClient().Database("empresa").Collection("pedidos")
 
-	_, err := collection.InsertOne(context.Background(), pedido)
+	_, err := collection.InsertOne(repository.ctx, pedido)
 	return err
 }
 
 func (repository *PedidoRepository) obtenerPedidos(filtro bson.M) ([]*model.Pedido, error) {
 	collection := repository.db.GetClient().Database("empresa").Collection("pedidos")
 
-	cursor, err := collection.Find(context.Background(), filtro)
+	cursor, err := collection.Find(repository.ctx, filtro)
 
 	if err != nil {
 		return nil, err
@@ -56,9 +60,9 @@ func (repository *PedidoRepository) obtenerPedidos(filtro bson.M) ([]*model.Pedi
 	//Inicializamos el slice de pedidos por si no hay pedidos
 	pedidos := make([]*model.Pedido, 0)
 
-	defer cursor.Close(context.Background())
+	defer cursor.Close(repository.ctx)
 
-	for cursor.Next(context.Background()) {
+	for cursor.Next(repository.ctx) {
 		var pedido model.Pedido
 		err := cursor.Decode(&pedido)
 		if err != nil {
@@ -74,7 +78,42 @@ func (repository *PedidoRepository) obtenerPedidos(filtro bson.M) ([]*model.Pedi
 	return pedidos, nil
 }
 
+// Campos por los que se pueden ordenar los listados de pedidos
+var camposOrdenPedidos = map[string]bool{
+	"fecha_creacion":             true,
+	"fecha_ultima_actualizacion": true,
+	"ciudad_destino":             true,
+	"estado":                     true,
+}
+
 func (repository *PedidoRepository) ObtenerPedidos(filtro *utils.FiltroPedido) ([]*model.Pedido, error) {
+	return repository.obtenerPedidos(filtroPedidos(filtro))
+}
+
+// Devuelve la pagina de pedidos que pide la paginacion del filtro, en el orden indicado
+func (repository *PedidoRepository) ObtenerPaginaPedidos(filtro *utils.FiltroPedido) ([]*model.Pedido, *model.Pagina, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("pedidos")
+
+	documentos, pagina, err := buscarPagina(repository.ctx, collection, filtroPedidos(filtro), filtro.Paginacion, camposOrdenPedidos)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pedidos := make([]*model.Pedido, 0, len(documentos))
+	for _, documento := range documentos {
+		var pedido model.Pedido
+		err := bson.Unmarshal(documento, &pedido)
+		if err != nil {
+			return nil, nil, err
+		}
+		pedidos = append(pedidos, &pedido)
+	}
+
+	return pedidos, pagina, nil
+}
+
+// Arma el filtro de la base de datos a partir del filtro de pedidos
+func filtroPedidos(filtro *utils.FiltroPedido) bson.M {
 	//Desestructuramos el filtro
 	idPedidos := filtro.IdPedidos
 	codigoProducto := filtro.CodigoProducto
@@ -103,6 +142,11 @@ func (repository *PedidoRepository) ObtenerPedidos(filtro *utils.FiltroPedido) (
 		filter["estado"] = estado
 	}
 
+	//Los pedidos hijos son los que se separaron de otro por falta de stock
+	if filtro.SoloPedidosHijos {
+		filter["id_pedido_padre"] = bson.M{"$exists": true, "$ne": ""}
+	}
+
 	//Tomo el codigoProducto vacio como la ausencia de filtro
 	if codigoProducto != "" {
 		filter["productos_elegidos"] = bson.M{
@@ -122,7 +166,7 @@ func (repository *PedidoRepository) ObtenerPedidos(filtro *utils.FiltroPedido) (
 		filter["fecha_creacion"] = filtroFecha
 	}
 
-	return repository.obtenerPedidos(filter)
+	return filter
 }
 
 func (repository *PedidoRepository) ObtenerPedidoPorId(pedidoConId *model.Pedido) (*model.Pedido, error) {
@@ -147,7 +191,7 @@ func (repository *PedidoRepository) ObtenerCantidadPedidosPorEstado(estado model
 
 	filtro := bson.M{"estado": estado}
 
-	cantidad, err := collection.CountDocuments(context.Background(), filtro)
+	cantidad, err := collection.CountDocuments(repository.ctx, filtro)
 
 	if err != nil {
 		return 0, err
@@ -167,13 +211,61 @@ func (repository *PedidoRepository) ActualizarPedido(pedido *model.Pedido) error
 	actualizacion := bson.M{"$set": bson.M{
 		"estado":                     pedido.Estado,
 		"fecha_ultima_actualizacion": pedido.FechaUltimaActualizacion,
-	}}
+		"entrega":                    pedido.Entrega,
+		"devoluciones":               pedido.Devoluciones,
+		"productos_elegidos":         pedido.ProductosElegidos,
+		"id_pedido_hijo":             pedido.IdPedidoHijo,
+	}, "$inc": bson.M{"version": 1}}
 
-	operacion, err := collection.UpdateOne(context.Background(), filtro, actualizacion)
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, pedido.Version), actualizacion)
 
-	if operacion.MatchedCount == 0 {
-		return errors.New("no se encontró el pedido a actualizar")
+	if err != nil {
+		return err
 	}
 
-	return err
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el pedido a actualizar")
+
+	if err != nil {
+		return err
+	}
+
+	pedido.Version++
+
+	return nil
+}
+
+func (repository *PedidoRepository) EditarPedido(pedido *model.Pedido) error {
+	pedido.FechaUltimaActualizacion = time.Now()
+
+	collection := repository.db.GetClient().Database("empresa").Collection("pedidos")
+
+	filtro := bson.M{"_id": pedido.ObjectId}
+
+	//Solo se pueden editar los pedidos que siguen pendientes y que nadie modifico desde que se leyeron
+	filtroEdicion := filtroConVersion(filtro, pedido.Version)
+	filtroEdicion["estado"] = model.Pendiente
+
+	actualizacion := bson.M{"$set": bson.M{
+		"productos_elegidos":         pedido.ProductosElegidos,
+		"ciudad_destino":             pedido.CiudadDestino,
+		"revisiones":                 pedido.Revisiones,
+		"fecha_ultima_actualizacion": pedido.FechaUltimaActualizacion,
+	}, "$inc": bson.M{"version": 1}}
+
+	operacion, err := collection.UpdateOne(repository.ctx, filtroEdicion, actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el pedido a editar")
+
+	if err != nil {
+		return err
+	}
+
+	pedido.Version++
+
+	return nil
 }
@@ -2,31 +2,43 @@ package repositories
 
 import (
 	"TPIntegrador/database"
-	"TPIntegrador/utils"
 	"TPIntegrador/model"
+	"TPIntegrador/utils"
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Error que devuelve ReservarStock cuando el stock disponible no alcanza
+var ErrSinStockDisponible = errors.New("no hay stock disponible del producto")
+
 type ProductoRepositoryInterface interface {
 	CrearProducto(*model.Producto) error
 	ObtenerProductos(utils.FiltroProducto) ([]*model.Producto, error)
+	ObtenerPaginaProductos(utils.FiltroProducto) ([]*model.Producto, *model.Pagina, error)
 	ObtenerProductoPorCodigo(*model.Producto) (*model.Producto, error)
 	ActualizarProducto(*model.Producto) error
+	ReservarStock(*model.Producto, int) error
+	LiberarStockReservado(*model.Producto, int) error
+	DescontarStockReservado(*model.Producto, int) error
+	ReponerStockReservado(*model.Producto, int) error
+	ReponerStock(*model.Producto, int) error
 	EliminarProducto(*model.Producto) error
 }
 
 type ProductoRepository struct {
-	db database.DB
+	db  database.DB
+	ctx context.Context
 }
 
 func NewProductoRepository(db database.DB) *ProductoRepository {
 	return &ProductoRepository{
-		db: db,
+		db:  db,
+		ctx: context.Background(),
 	}
 }
 
@@ -37,9 +49,10 @@ func (repository *ProductoRepository) CrearProducto(producto *model.Producto) er
 	//Seteamos las fechas del producto
 	producto.FechaCreacion = time.Now()
 	producto.FechaUltimaActualizacion = time.Now()
+	producto.Version = 1
 
 	collection := repository.db.GetClient().Database("empresa").Collection("productos")
-	_, err := collection.InsertOne(context.Background(), producto)
+	_, err := collection.InsertOne(repository.ctx, producto)
 	return err
 }
 
@@ -59,13 +72,49 @@ func (repository *ProductoRepository) ObtenerProductoPorCodigo(productoConCodigo
 	return productos[0], err
 }
 
+// Campos por los que se pueden ordenar los listados de productos
+var camposOrdenProductos = map[string]bool{
+	"nombre":          true,
+	"tipo_producto":   true,
+	"precio_unitario": true,
+	"stock_actual":    true,
+	"fecha_creacion":  true,
+}
+
 func (repository *ProductoRepository) ObtenerProductos(filtroProducto utils.FiltroProducto) ([]*model.Producto, error) {
+	return repository.obtenerProductos(filtroProductos(filtroProducto))
+}
+
+// Devuelve la pagina de productos que pide la paginacion del filtro, en el orden indicado
+func (repository *ProductoRepository) ObtenerPaginaProductos(filtroProducto utils.FiltroProducto) ([]*model.Producto, *model.Pagina, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("productos")
+
+	documentos, pagina, err := buscarPagina(repository.ctx, collection, filtroProductos(filtroProducto), filtroProducto.Paginacion, camposOrdenProductos)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	productos := make([]*model.Producto, 0, len(documentos))
+	for _, documento := range documentos {
+		var producto model.Producto
+		err := bson.Unmarshal(documento, &producto)
+		if err != nil {
+			return nil, nil, err
+		}
+		productos = append(productos, &producto)
+	}
+
+	return productos, pagina, nil
+}
+
+// Arma el filtro de la base de datos a partir del filtro de productos
+func filtroProductos(filtroProducto utils.FiltroProducto) bson.M {
 	//Primero creamos el filtro vacio
 	filtroDB := bson.M{}
 
-	//Si quiere filtrar por stock minimo, lo agregamos al filtro (que usa JavaScript para la condicion)
+	//Si quiere filtrar por stock minimo, lo agregamos al filtro comparando los dos campos del producto
 	if filtroProducto.FiltrarPorStockMinimo {
-		filtroDB["$where"] = "this.stock_actual < this.stock_minimo"
+		filtroDB["$expr"] = bson.M{"$lt": bson.A{"$stock_actual", "$stock_minimo"}}
 	}
 
 	//Si quiere filtrar por tipo de producto, lo agregamos al filtro
@@ -73,7 +122,7 @@ func (repository *ProductoRepository) ObtenerProductos(filtroProducto utils.Filt
 		filtroDB["tipo_producto"] = filtroProducto.TipoProducto
 	}
 
-	return repository.obtenerProductos(filtroDB)
+	return filtroDB
 }
 
 func (repository *ProductoRepository) obtenerProductos(filtro bson.M) ([]*model.Producto, error) {
@@ -82,15 +131,15 @@ func (repository *ProductoRepository) obtenerProductos(filtro bson.M) ([]*model.
 	//Inicializamos el slice de productos por si no hay productos
 	productosList := make([]*model.Producto, 0)
 
-	cursor, err := collection.Find(context.Background(), filtro)
+	cursor, err := collection.Find(repository.ctx, filtro)
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer cursor.Close(context.Background())
+	defer cursor.Close(repository.ctx)
 
-	for cursor.Next(context.Background()) {
+	for cursor.Next(repository.ctx) {
 		var producto model.Producto
 
 		err := cursor.Decode(&producto)
@@ -120,22 +169,167 @@ func (repository *ProductoRepository) ActualizarProducto(producto *model.Product
 	//Creo una operacion personalizada, para que no actualice nunca la fecha de creacion o el id del creador
 	actualizacion := bson.M{
 		"$set": bson.M{
-			"tipo_producto":   producto.TipoDeProducto,
-			"nombre":          producto.Nombre,
-			"peso_unitario":   producto.PesoUnitario,
-			"precio_unitario": producto.PrecioUnitario,
-			"stock_minimo":    producto.StockMinimo,
-			"stock_actual":    producto.StockActual,
+			"tipo_producto":    producto.TipoDeProducto,
+			"nombre":           producto.Nombre,
+			"peso_unitario":    producto.PesoUnitario,
+			"alto_unitario":    producto.AltoUnitario,
+			"ancho_unitario":   producto.AnchoUnitario,
+			"largo_unitario":   producto.LargoUnitario,
+			"volumen_unitario": producto.VolumenUnitario,
+			"precio_unitario":  producto.PrecioUnitario,
+			"stock_minimo":     producto.StockMinimo,
+			"stock_actual":     producto.StockActual,
 		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	//Solo se actualiza si nadie lo modifico desde que se leyo
+	operacion, err := collection.UpdateOne(repository.ctx, filtroConVersion(filtro, producto.Version), actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	err = verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.MatchedCount, "no se encontró el producto a actualizar")
+
+	if err != nil {
+		return err
+	}
+
+	producto.Version++
+
+	return nil
+}
+
+// Reserva la cantidad solo si el stock disponible (actual menos reservado) alcanza.
+// La condicion y el incremento se hacen en una sola operacion, para que dos pedidos no reserven las mismas unidades.
+func (repository *ProductoRepository) ReservarStock(producto *model.Producto, cantidad int) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("productos")
+
+	filtro := bson.M{
+		"_id": producto.ObjectId,
+		"$expr": bson.M{"$gte": bson.A{
+			bson.M{"$subtract": bson.A{"$stock_actual", bson.M{"$ifNull": bson.A{"$stock_reservado", 0}}}},
+			cantidad,
+		}},
+	}
+
+	actualizacion := bson.M{
+		"$inc": bson.M{"stock_reservado": cantidad, "version": 1},
+		"$set": bson.M{"fecha_ultima_actualizacion": time.Now()},
+	}
+
+	operacion, err := collection.UpdateOne(repository.ctx, filtro, actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	if operacion.MatchedCount == 0 {
+		return fmt.Errorf("%w %s", ErrSinStockDisponible, producto.ObjectId.Hex())
+	}
+
+	return nil
+}
+
+// Devuelve al stock disponible una cantidad que estaba reservada
+func (repository *ProductoRepository) LiberarStockReservado(producto *model.Producto, cantidad int) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("productos")
+
+	filtro := bson.M{"_id": producto.ObjectId}
+
+	actualizacion := bson.M{
+		"$inc": bson.M{"stock_reservado": -cantidad, "version": 1},
+		"$set": bson.M{"fecha_ultima_actualizacion": time.Now()},
 	}
 
-	operacion, err := collection.UpdateOne(context.Background(), filtro, actualizacion)
+	operacion, err := collection.UpdateOne(repository.ctx, filtro, actualizacion)
+
+	if err != nil {
+		return err
+	}
 
 	if operacion.MatchedCount == 0 {
 		return errors.New("no se encontró el producto a actualizar")
 	}
 
-	return err
+	return nil
+}
+
+// Convierte una reserva en un descuento real: baja tanto el stock actual como el reservado
+func (repository *ProductoRepository) DescontarStockReservado(producto *model.Producto, cantidad int) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("productos")
+
+	//Nunca dejamos el stock actual en negativo
+	filtro := bson.M{
+		"_id":          producto.ObjectId,
+		"stock_actual": bson.M{"$gte": cantidad},
+	}
+
+	actualizacion := bson.M{
+		"$inc": bson.M{"stock_actual": -cantidad, "stock_reservado": -cantidad, "version": 1},
+		"$set": bson.M{"fecha_ultima_actualizacion": time.Now()},
+	}
+
+	operacion, err := collection.UpdateOne(repository.ctx, filtro, actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	if operacion.MatchedCount == 0 {
+		return errors.New("no hay stock suficiente del producto " + producto.ObjectId.Hex())
+	}
+
+	return nil
+}
+
+// Deshace un DescontarStockReservado: vuelve a sumar la cantidad al stock actual y la deja reservada
+func (repository *ProductoRepository) ReponerStockReservado(producto *model.Producto, cantidad int) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("productos")
+
+	filtro := bson.M{"_id": producto.ObjectId}
+
+	actualizacion := bson.M{
+		"$inc": bson.M{"stock_actual": cantidad, "stock_reservado": cantidad, "version": 1},
+		"$set": bson.M{"fecha_ultima_actualizacion": time.Now()},
+	}
+
+	operacion, err := collection.UpdateOne(repository.ctx, filtro, actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	if operacion.MatchedCount == 0 {
+		return errors.New("no se encontró el producto a actualizar")
+	}
+
+	return nil
+}
+
+// Suma la cantidad al stock actual, por ejemplo cuando vuelve mercaderia de un pedido
+func (repository *ProductoRepository) ReponerStock(producto *model.Producto, cantidad int) error {
+	collection := repository.db.GetClient().Database("empresa").Collection("productos")
+
+	filtro := bson.M{"_id": producto.ObjectId}
+
+	actualizacion := bson.M{
+		"$inc": bson.M{"stock_actual": cantidad, "version": 1},
+		"$set": bson.M{"fecha_ultima_actualizacion": time.Now()},
+	}
+
+	operacion, err := collection.UpdateOne(repository.ctx, filtro, actualizacion)
+
+	if err != nil {
+		return err
+	}
+
+	if operacion.MatchedCount == 0 {
+		return errors.New("no se encontró el producto a actualizar")
+	}
+
+	return nil
 }
 
 func (repository *ProductoRepository) EliminarProducto(producto *model.Producto) error {
@@ -143,7 +337,12 @@ func (repository *ProductoRepository) EliminarProducto(producto *model.Producto)
 
 	filtro := bson.M{"_id": producto.ObjectId}
 
-	_, err := collection.DeleteOne(context.Background(), filtro)
+	//Solo se elimina si nadie lo modifico desde que se leyo
+	operacion, err := collection.DeleteOne(repository.ctx, filtroConVersion(filtro, producto.Version))
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	return verificarOperacionConVersion(repository.ctx, collection, filtro, operacion.DeletedCount, "no se encontró el producto a eliminar")
 }
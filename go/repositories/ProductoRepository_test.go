@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFiltroProductosStockMinimo(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		filtro   utils.FiltroProducto
+		esperado bson.M
+	}{
+		{
+			nombre:   "sin filtros",
+			filtro:   utils.FiltroProducto{},
+			esperado: bson.M{},
+		},
+		{
+			nombre:   "stock minimo",
+			filtro:   utils.FiltroProducto{FiltrarPorStockMinimo: true},
+			esperado: bson.M{"$expr": bson.M{"$lt": bson.A{"$stock_actual", "$stock_minimo"}}},
+		},
+		{
+			nombre: "stock minimo y tipo de producto",
+			filtro: utils.FiltroProducto{FiltrarPorStockMinimo: true, TipoProducto: model.TipoProducto("$where")},
+			esperado: bson.M{
+				"$expr":         bson.M{"$lt": bson.A{"$stock_actual", "$stock_minimo"}},
+				"tipo_producto": model.TipoProducto("$where"),
+			},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			filtro := filtroProductos(caso.filtro)
+
+			if contieneClave(filtro, "$where") {
+				t.Fatalf("el filtro no puede usar $where: %v", filtro)
+			}
+
+			if !reflect.DeepEqual(filtro, caso.esperado) {
+				t.Fatalf("se esperaba %v, se obtuvo %v", caso.esperado, filtro)
+			}
+		})
+	}
+}
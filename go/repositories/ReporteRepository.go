@@ -0,0 +1,516 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"TPIntegrador/model"
+	"TPIntegrador/utils"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Reportes que se calculan con pipelines de agregacion, en lugar de leer los documentos uno por uno
+type ReporteRepositoryInterface interface {
+	ObtenerRentabilidadCamiones(utils.FiltroReporte) ([]*model.RentabilidadCamion, error)
+	ObtenerBeneficiosPorPeriodo(utils.FiltroReporte, model.CalendarioFiscal) (*model.BeneficiosPorPeriodo, error)
+	ObtenerRentabilidadAgrupada(utils.FiltroReporte) ([]*model.RentabilidadAgrupada, error)
+}
+
+type ReporteRepository struct {
+	db  database.DB
+	ctx context.Context
+}
+
+func NewReporteRepository(db database.DB) *ReporteRepository {
+	return &ReporteRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+// Calcula para cada camion la cantidad de envios despachados en el periodo, los km recorridos, el factor de carga promedio,
+// los ingresos por sus pedidos, el costo por km y el margen neto. Incluye los camiones activos aunque no hayan tenido envios
+func (repository *ReporteRepository) ObtenerRentabilidadCamiones(filtro utils.FiltroReporte) ([]*model.RentabilidadCamion, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("camiones")
+
+	//Envios despachados del camion en el periodo, cada uno con sus km, el peso de sus pedidos y lo que facturaron
+	filtroEnvios := filtroEnviosDespachados(filtro)
+	filtroEnvios["$expr"] = bson.M{"$eq": bson.A{"$patente_camion", "$$patente"}}
+
+	pipeline := bson.A{
+		bson.M{"$lookup": bson.M{
+			"from": "envios",
+			"let":  bson.M{"patente": "$patente", "costo_por_kilometro": "$costo_por_kilometro"},
+			"pipeline": bson.A{
+				bson.M{"$match": filtroEnvios},
+				etapaLookupPedidosDeEnvio(),
+				bson.M{"$project": bson.M{
+					"km":      bson.M{"$sum": "$paradas.km_recorridos"},
+					"peso":    bson.M{"$sum": "$pedidos_envio.peso"},
+					"ingreso": bson.M{"$sum": "$pedidos_envio.ingreso"},
+					//El costo se calcula igual que en el beneficio por periodo, para que los reportes coincidan
+					"costo": expresionCostoEnvioConCostoPorKm("$$costo_por_kilometro"),
+				}},
+			},
+			"as": "envios",
+		}},
+		//Los camiones dados de baja solo aparecen si tuvieron envios en el periodo
+		bson.M{"$match": bson.M{"$or": bson.A{
+			bson.M{"esta_activo": true},
+			bson.M{"envios.0": bson.M{"$exists": true}},
+		}}},
+		bson.M{"$project": bson.M{
+			"_id":               0,
+			"patente":           1,
+			"cantidad_envios":   bson.M{"$size": "$envios"},
+			"km_recorridos":     bson.M{"$sum": "$envios.km"},
+			"peso_transportado": bson.M{"$sum": "$envios.peso"},
+			"ingresos":          bson.M{"$sum": "$envios.ingreso"},
+			//Factor de carga de cada envio: peso de sus pedidos sobre el peso maximo del camion
+			"factor_carga_promedio": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$peso_maximo", 0}},
+				bson.M{"$ifNull": bson.A{
+					bson.M{"$avg": bson.M{"$map": bson.M{
+						"input": "$envios",
+						"as":    "envio",
+						"in":    bson.M{"$divide": bson.A{"$$envio.peso", "$peso_maximo"}},
+					}}},
+					0,
+				}},
+				0,
+			}},
+			"costos": bson.M{"$sum": "$envios.costo"},
+		}},
+		bson.M{"$addFields": bson.M{"margen_neto": bson.M{"$subtract": bson.A{"$ingresos", "$costos"}}}},
+		bson.M{"$sort": bson.D{{Key: "patente", Value: 1}}},
+	}
+
+	cursor, err := collection.Aggregate(repository.ctx, pipeline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice por si no hay camiones
+	rentabilidades := make([]*model.RentabilidadCamion, 0)
+
+	err = cursor.All(repository.ctx, &rentabilidades)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rentabilidades, nil
+}
+
+// Monto acumulado de un periodo, tal como lo devuelve el $group
+type beneficioPeriodo struct {
+	Clave string  `bson:"_id"`
+	Monto float64 `bson:"monto"`
+}
+
+type resultadoBeneficiosPorPeriodo struct {
+	Anuales   []beneficioPeriodo `bson:"anuales"`
+	Mensuales []beneficioPeriodo `bson:"mensuales"`
+	Semanales []beneficioPeriodo `bson:"semanales"`
+	Diarios   []beneficioPeriodo `bson:"diarios"`
+	SinCamion []bson.M           `bson:"sin_camion"`
+}
+
+// Calcula en una sola consulta el beneficio neto de los envios despachados en el periodo, agrupado por ejercicio, mes,
+// semana ISO y dia de la fecha de despacho en la zona horaria del calendario. El beneficio de cada envio es lo que
+// facturaron sus pedidos menos el costo por km del camion por los km recorridos
+func (repository *ReporteRepository) ObtenerBeneficiosPorPeriodo(filtro utils.FiltroReporte, calendario model.CalendarioFiscal) (*model.BeneficiosPorPeriodo, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("envios")
+
+	pipeline := bson.A{
+		bson.M{"$match": filtroEnviosDespachados(filtro)},
+		etapaLookupPedidosDeEnvio(),
+		etapaLookupCamionDeEnvio(),
+		bson.M{"$project": bson.M{
+			"fecha":        expresionFechaDespacho(),
+			"tiene_camion": bson.M{"$gt": bson.A{bson.M{"$size": "$camion"}, 0}},
+			"monto":        bson.M{"$subtract": bson.A{bson.M{"$sum": "$pedidos_envio.ingreso"}, expresionCostoEnvio()}},
+		}},
+		bson.M{"$facet": bson.M{
+			"anuales":    etapasBeneficioPorPeriodo("%Y", expresionInicioEjercicio(calendario), calendario),
+			"mensuales":  etapasBeneficioPorPeriodo("%Y-%m", "$fecha", calendario),
+			"semanales":  etapasBeneficioPorPeriodo("%G-W%V", "$fecha", calendario),
+			"diarios":    etapasBeneficioPorPeriodo("%Y-%m-%d", "$fecha", calendario),
+			"sin_camion": bson.A{bson.M{"$match": bson.M{"tiene_camion": false}}, bson.M{"$limit": 1}},
+		}},
+	}
+
+	cursor, err := collection.Aggregate(repository.ctx, pipeline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//El $facet siempre devuelve un unico documento
+	var resultado resultadoBeneficiosPorPeriodo
+	if cursor.Next(repository.ctx) {
+		err = cursor.Decode(&resultado)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	//Sin el camion no se puede calcular el costo del envio
+	if len(resultado.SinCamion) > 0 {
+		return nil, errors.New("no existe el camion")
+	}
+
+	return &model.BeneficiosPorPeriodo{
+		Anuales:   obtenerMapaBeneficios(resultado.Anuales),
+		Mensuales: obtenerMapaBeneficios(resultado.Mensuales),
+		Semanales: obtenerMapaBeneficios(resultado.Semanales),
+		Diarios:   obtenerMapaBeneficios(resultado.Diarios),
+	}, nil
+}
+
+// Calcula los ingresos, el costo de envio y el margen de los envios despachados en el periodo, agrupados por la
+// dimension del filtro. El costo de cada envio se reparte entre sus pedidos segun su peso, y el de cada pedido entre
+// sus productos de la misma forma. Si no hay peso para repartir, se reparte en partes iguales
+func (repository *ReporteRepository) ObtenerRentabilidadAgrupada(filtro utils.FiltroReporte) ([]*model.RentabilidadAgrupada, error) {
+	collection := repository.db.GetClient().Database("empresa").Collection("envios")
+
+	pipeline := bson.A{
+		bson.M{"$match": filtroEnviosDespachados(filtro)},
+		etapaLookupPedidosDeEnvio(),
+		etapaLookupCamionDeEnvio(),
+		bson.M{"$addFields": bson.M{
+			"costo_envio": expresionCostoEnvio(),
+			"peso_envio":  bson.M{"$sum": "$pedidos_envio.peso"},
+		}},
+		bson.M{"$addFields": bson.M{"cantidad_pedidos_envio": bson.M{"$size": "$pedidos_envio"}}},
+		bson.M{"$unwind": "$pedidos_envio"},
+		bson.M{"$addFields": bson.M{
+			"pedido":       "$pedidos_envio",
+			"costo_pedido": expresionCostoProporcional("$costo_envio", "$pedidos_envio.peso", "$peso_envio", "$cantidad_pedidos_envio"),
+		}},
+	}
+
+	//Cada registro es un pedido con su peso, su ingreso y su parte del costo. Por producto, se abre en sus productos
+	var clave, nombre interface{}
+	linea := bson.M{
+		"peso":    "$pedido.peso",
+		"ingreso": "$pedido.ingreso",
+		"costo":   "$costo_pedido",
+	}
+
+	if filtro.AgruparPor.EsPorProducto() {
+		pipeline = append(pipeline,
+			bson.M{"$addFields": bson.M{"cantidad_productos_pedido": bson.M{"$size": "$pedido.productos"}}},
+			bson.M{"$unwind": "$pedido.productos"},
+		)
+		linea = bson.M{
+			"peso":    "$pedido.productos.peso",
+			"ingreso": "$pedido.productos.ingreso",
+			"costo":   expresionCostoProporcional("$costo_pedido", "$pedido.productos.peso", "$pedido.peso", "$cantidad_productos_pedido"),
+		}
+	}
+
+	switch filtro.AgruparPor {
+	case model.PorTipoProducto:
+		//El tipo no se guarda en el pedido, se busca en el producto
+		pipeline = append(pipeline, bson.M{"$lookup": bson.M{
+			"from": "productos",
+			"let":  bson.M{"id": bson.M{"$convert": bson.M{"input": "$pedido.productos.codigo_producto", "to": "objectId", "onError": nil, "onNull": nil}}},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$id"}}}},
+				bson.M{"$project": bson.M{"tipo_producto": 1}},
+			},
+			"as": "producto",
+		}})
+		clave = bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$producto.tipo_producto", 0}}, ""}}
+		nombre = clave
+	case model.PorProducto:
+		clave = "$pedido.productos.codigo_producto"
+		nombre = "$pedido.productos.nombre_producto"
+	case model.PorCiudadDestino:
+		clave = "$pedido.ciudad_destino"
+		nombre = clave
+	case model.PorCamion:
+		clave = "$patente_camion"
+		nombre = clave
+	case model.PorConductor:
+		//El envio guarda el codigo del usuario, el nombre esta en el conductor
+		pipeline = append(pipeline, bson.M{"$lookup": bson.M{
+			"from":         "conductores",
+			"localField":   "id_conductor",
+			"foreignField": "codigo_usuario",
+			"as":           "conductor",
+		}})
+		clave = bson.M{"$ifNull": bson.A{"$id_conductor", ""}}
+		nombre = bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$conductor.nombre", 0}}, clave}}
+	default:
+		return nil, errors.New("la dimension del reporte no es valida")
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{
+			"_id":               clave,
+			"nombre":            bson.M{"$first": nombre},
+			"pedidos":           bson.M{"$addToSet": "$pedido._id"},
+			"peso_transportado": bson.M{"$sum": linea["peso"]},
+			"ingresos":          bson.M{"$sum": linea["ingreso"]},
+			"costos":            bson.M{"$sum": linea["costo"]},
+		}},
+		bson.M{"$project": bson.M{
+			"nombre":            1,
+			"cantidad_pedidos":  bson.M{"$size": "$pedidos"},
+			"peso_transportado": 1,
+			"ingresos":          1,
+			"costos":            1,
+			"margen_neto":       bson.M{"$subtract": bson.A{"$ingresos", "$costos"}},
+		}},
+		bson.M{"$sort": bson.D{{Key: "margen_neto", Value: -1}, {Key: "_id", Value: 1}}},
+	)
+
+	cursor, err := collection.Aggregate(repository.ctx, pipeline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(repository.ctx)
+
+	//Inicializamos el slice por si no hay envios
+	rentabilidades := make([]*model.RentabilidadAgrupada, 0)
+
+	err = cursor.All(repository.ctx, &rentabilidades)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rentabilidades, nil
+}
+
+// Trae el camion del envio en camion. El camion puede estar dado de baja, por eso no se filtra por activo
+func etapaLookupCamionDeEnvio() bson.M {
+	return bson.M{"$lookup": bson.M{
+		"from":         "camiones",
+		"localField":   "patente_camion",
+		"foreignField": "patente",
+		"as":           "camion",
+	}}
+}
+
+// Costo por km del camion por los km del envio, con el camion que trae etapaLookupCamionDeEnvio
+func expresionCostoEnvio() bson.M {
+	return expresionCostoEnvioConCostoPorKm(bson.M{"$arrayElemAt": bson.A{"$camion.costo_por_kilometro", 0}})
+}
+
+// Costo por km indicado por los km del envio. Los km de la ultima parada no se cobran, porque el camion ya no sigue viaje
+func expresionCostoEnvioConCostoPorKm(costoPorKm interface{}) bson.M {
+	kmCobrados := bson.M{"$subtract": bson.A{
+		bson.M{"$sum": "$paradas.km_recorridos"},
+		bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$paradas.km_recorridos", -1}}, 0}},
+	}}
+
+	return bson.M{"$multiply": bson.A{
+		bson.M{"$ifNull": bson.A{costoPorKm, 0}},
+		kmCobrados,
+	}}
+}
+
+// Parte del costo que le toca a una porcion segun su peso sobre el peso total. Sin peso, se reparte en partes iguales
+func expresionCostoProporcional(costo string, peso string, pesoTotal string, cantidad string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{pesoTotal, 0}},
+		bson.M{"$divide": bson.A{bson.M{"$multiply": bson.A{costo, peso}}, pesoTotal}},
+		bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{cantidad, 0}},
+			bson.M{"$divide": bson.A{costo, cantidad}},
+			0,
+		}},
+	}}
+}
+
+// Agrupa los envios por la fecha formateada en la zona del calendario, que queda como clave del periodo, y suma sus montos
+func etapasBeneficioPorPeriodo(formato string, fecha interface{}, calendario model.CalendarioFiscal) bson.A {
+	return bson.A{
+		bson.M{"$group": bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   formato,
+				"date":     fecha,
+				"timezone": calendario.ZonaHoraria.String(),
+			}},
+			"monto": bson.M{"$sum": "$monto"},
+		}},
+	}
+}
+
+// Corre la fecha al principio del año calendario para que su año sea el del ejercicio. Si el ejercicio empieza en enero no hace falta
+func expresionInicioEjercicio(calendario model.CalendarioFiscal) interface{} {
+	if calendario.MesInicioEjercicio == time.January {
+		return "$fecha"
+	}
+
+	return bson.M{"$dateAdd": bson.M{
+		"startDate": "$fecha",
+		"unit":      "month",
+		"amount":    -(int(calendario.MesInicioEjercicio) - 1),
+		"timezone":  calendario.ZonaHoraria.String(),
+	}}
+}
+
+func obtenerMapaBeneficios(beneficios []beneficioPeriodo) map[string]float64 {
+	mapa := make(map[string]float64, len(beneficios))
+	for _, beneficio := range beneficios {
+		mapa[beneficio.Clave] = beneficio.Monto
+	}
+	return mapa
+}
+
+// Filtro de los envios que generan beneficio: los despachados dentro del periodo.
+// Los envios despachados antes de que se guardara la fecha de despacho se toman por su ultima actualizacion
+func filtroEnviosDespachados(filtro utils.FiltroReporte) bson.M {
+	filtroEnvios := bson.M{"estado": model.Despachado}
+
+	//Tomo la fecha en 0001-01-01 como la ausencia de filtro
+	if !filtro.FechaDesde.IsZero() || !filtro.FechaHasta.IsZero() {
+		filtroFecha := bson.M{}
+		if !filtro.FechaDesde.IsZero() {
+			filtroFecha["$gte"] = filtro.FechaDesde
+		}
+		if !filtro.FechaHasta.IsZero() {
+			filtroFecha["$lte"] = filtro.FechaHasta
+		}
+
+		filtroFechaDespacho := bson.M{"$gt": time.Time{}}
+		for operador, fecha := range filtroFecha {
+			filtroFechaDespacho[operador] = fecha
+		}
+
+		filtroEnvios["$or"] = bson.A{
+			bson.M{"fecha_despacho": filtroFechaDespacho},
+			bson.M{"fecha_despacho": bson.M{"$in": bson.A{nil, time.Time{}}}, "fecha_ultima_actualizacion": filtroFecha},
+		}
+	}
+
+	return filtroEnvios
+}
+
+// Fecha en la que se despacho el envio, o la de su ultima actualizacion si se despacho antes de que se guardara
+func expresionFechaDespacho() bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{"$fecha_despacho", time.Time{}}},
+		"$fecha_despacho",
+		"$fecha_ultima_actualizacion",
+	}}
+}
+
+// Trae los pedidos del envio en pedidos_envio, cada uno con su ciudad de destino, su peso, el ingreso que genero
+// y el peso y el ingreso de cada uno de sus productos.
+// No trae los pedidos que una parada marco como no entregados, igual que Envio.ObtenerPedidosImputables: esos pedidos
+// siguen en la lista del envio, y si otro envio los entrega se contarian dos veces.
+// Los ids de los pedidos se guardan como string en el envio, por eso se convierten a ObjectId
+func etapaLookupPedidosDeEnvio() bson.M {
+	return bson.M{"$lookup": bson.M{
+		"from": "pedidos",
+		"let": bson.M{"ids": bson.M{"$map": bson.M{
+			"input": expresionPedidosImputables(),
+			"as":    "id",
+			"in":    bson.M{"$convert": bson.M{"input": "$$id", "to": "objectId", "onError": nil, "onNull": nil}},
+		}}},
+		"pipeline": bson.A{
+			bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$_id", "$$ids"}}}},
+			bson.M{"$project": bson.M{
+				"ciudad_destino": 1,
+				"peso":           expresionPesoPedido(),
+				"ingreso":        expresionIngresoPedido(),
+				"productos":      expresionProductosPedido(),
+			}},
+		},
+		"as": "pedidos_envio",
+	}}
+}
+
+// Peso de todos los productos del pedido, como Pedido.ObtenerPesoTotal
+func expresionPesoPedido() bson.M {
+	return expresionSumaProductos("$productos_elegidos", "$$producto.peso_unitario")
+}
+
+// Ids de los pedidos del envio menos los que alguna parada marco como no entregados
+func expresionPedidosImputables() bson.M {
+	noEntregados := bson.M{"$reduce": bson.M{
+		"input":        bson.M{"$ifNull": bson.A{"$paradas", bson.A{}}},
+		"initialValue": bson.A{},
+		"in":           bson.M{"$concatArrays": bson.A{"$$value", bson.M{"$ifNull": bson.A{"$$this.pedidos_no_entregados", bson.A{}}}}},
+	}}
+
+	return bson.M{"$setDifference": bson.A{bson.M{"$ifNull": bson.A{"$pedidos", bson.A{}}}, noEntregados}}
+}
+
+// Lo que facturo el pedido: solo los entregados generan ingresos, y lo devuelto no cuenta
+func expresionIngresoPedido() bson.M {
+	devuelto := bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$devoluciones", bson.A{}}},
+		"as":    "devolucion",
+		"in":    expresionSumaProductos("$$devolucion.productos", "$$producto.precio_unitario"),
+	}}}
+
+	return bson.M{"$cond": bson.A{
+		bson.M{"$in": bson.A{"$estado", bson.A{model.Enviado, model.Devuelto}}},
+		bson.M{"$subtract": bson.A{expresionSumaProductos("$productos_elegidos", "$$producto.precio_unitario"), devuelto}},
+		0,
+	}}
+}
+
+// Productos del pedido, cada uno con su peso y lo que facturo, igual que en expresionIngresoPedido
+func expresionProductosPedido() bson.M {
+	//Lo devuelto de este producto, sumando todas las devoluciones del pedido
+	devuelto := bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$devoluciones", bson.A{}}},
+		"as":    "devolucion",
+		"in": bson.M{"$sum": bson.M{"$map": bson.M{
+			"input": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$$devolucion.productos", bson.A{}}},
+				"as":    "devuelto",
+				"cond":  bson.M{"$eq": bson.A{"$$devuelto.codigo_producto", "$$producto.codigo_producto"}},
+			}},
+			"as": "devuelto",
+			"in": bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$$devuelto.precio_unitario", 0}}, "$$devuelto.cantidad"}},
+		}}},
+	}}}
+
+	return bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$productos_elegidos", bson.A{}}},
+		"as":    "producto",
+		"in": bson.M{
+			"codigo_producto": "$$producto.codigo_producto",
+			"nombre_producto": "$$producto.nombre_producto",
+			"peso":            bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$$producto.peso_unitario", 0}}, "$$producto.cantidad"}},
+			"ingreso": bson.M{"$cond": bson.A{
+				bson.M{"$in": bson.A{"$estado", bson.A{model.Enviado, model.Devuelto}}},
+				bson.M{"$subtract": bson.A{
+					bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$$producto.precio_unitario", 0}}, "$$producto.cantidad"}},
+					devuelto,
+				}},
+				0,
+			}},
+		},
+	}}
+}
+
+// Suma el valor unitario indicado por la cantidad de cada producto de la lista
+func expresionSumaProductos(productos string, valorUnitario string) bson.M {
+	return bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{productos, bson.A{}}},
+		"as":    "producto",
+		"in":    bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{valorUnitario, 0}}, "$$producto.cantidad"}},
+	}}}
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPedidosImputablesConPedidoReenviado(t *testing.T) {
+	//El primer envio no pudo entregar el pedido B, que despues entrego el segundo envio
+	primerEnvio := bson.M{
+		"pedidos": bson.A{"A", "B"},
+		"paradas": bson.A{bson.M{"ciudad": "Cordoba", "pedidos_entregados": bson.A{"A"}, "pedidos_no_entregados": bson.A{"B"}}},
+	}
+	segundoEnvio := bson.M{
+		"pedidos": bson.A{"B"},
+		"paradas": bson.A{bson.M{"ciudad": "Cordoba", "pedidos_entregados": bson.A{"B"}}},
+	}
+
+	casos := []struct {
+		nombre   string
+		envio    bson.M
+		esperado []string
+	}{
+		{nombre: "primer envio", envio: primerEnvio, esperado: []string{"A"}},
+		{nombre: "segundo envio", envio: segundoEnvio, esperado: []string{"B"}},
+		{nombre: "envio sin paradas", envio: bson.M{"pedidos": bson.A{"C"}}, esperado: []string{"C"}},
+		{nombre: "envio sin pedidos", envio: bson.M{}, esperado: []string{}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			imputables := evaluarExpresion(t, expresionPedidosImputables(), caso.envio, nil)
+
+			if !reflect.DeepEqual(aStrings(imputables), caso.esperado) {
+				t.Fatalf("se esperaba %v, se obtuvo %v", caso.esperado, imputables)
+			}
+		})
+	}
+}
+
+func TestLookupPedidosDeEnvioUsaLosPedidosImputables(t *testing.T) {
+	lookup := etapaLookupPedidosDeEnvio()["$lookup"].(bson.M)
+	ids := lookup["let"].(bson.M)["ids"].(bson.M)["$map"].(bson.M)
+
+	if !reflect.DeepEqual(ids["input"], expresionPedidosImputables()) {
+		t.Fatalf("el lookup tiene que buscar solo los pedidos imputables, busca %v", ids["input"])
+	}
+}
+
+// Evalua las pocas expresiones de agregacion que usa expresionPedidosImputables sobre el documento
+func evaluarExpresion(t *testing.T, expresion interface{}, documento bson.M, variables bson.M) interface{} {
+	switch expresion := expresion.(type) {
+	case string:
+		if strings.HasPrefix(expresion, "$$") {
+			partes := strings.SplitN(strings.TrimPrefix(expresion, "$$"), ".", 2)
+			valor := variables[partes[0]]
+			if len(partes) == 2 {
+				return valor.(bson.M)[partes[1]]
+			}
+			return valor
+		}
+		if strings.HasPrefix(expresion, "$") {
+			return documento[strings.TrimPrefix(expresion, "$")]
+		}
+		return expresion
+	case bson.A:
+		valores := bson.A{}
+		for _, elemento := range expresion {
+			valores = append(valores, evaluarExpresion(t, elemento, documento, variables))
+		}
+		return valores
+	case bson.M:
+		for operador, argumentos := range expresion {
+			switch operador {
+			case "$ifNull":
+				argumentos := argumentos.(bson.A)
+				if valor := evaluarExpresion(t, argumentos[0], documento, variables); valor != nil {
+					return valor
+				}
+				return evaluarExpresion(t, argumentos[1], documento, variables)
+			case "$concatArrays":
+				resultado := bson.A{}
+				for _, arreglo := range evaluarExpresion(t, argumentos, documento, variables).(bson.A) {
+					resultado = append(resultado, arreglo.(bson.A)...)
+				}
+				return resultado
+			case "$setDifference":
+				arreglos := evaluarExpresion(t, argumentos, documento, variables).(bson.A)
+				excluidos := make(map[interface{}]bool)
+				for _, valor := range arreglos[1].(bson.A) {
+					excluidos[valor] = true
+				}
+				resultado := bson.A{}
+				for _, valor := range arreglos[0].(bson.A) {
+					if !excluidos[valor] {
+						excluidos[valor] = true
+						resultado = append(resultado, valor)
+					}
+				}
+				return resultado
+			case "$reduce":
+				argumentos := argumentos.(bson.M)
+				acumulado := evaluarExpresion(t, argumentos["initialValue"], documento, variables)
+				for _, elemento := range evaluarExpresion(t, argumentos["input"], documento, variables).(bson.A) {
+					acumulado = evaluarExpresion(t, argumentos["in"], documento, bson.M{"value": acumulado, "this": elemento})
+				}
+				return acumulado
+			}
+			t.Fatalf("operador no soportado en el test: %s", operador)
+		}
+	}
+	return expresion
+}
+
+func aStrings(valor interface{}) []string {
+	resultado := make([]string, 0)
+	for _, elemento := range valor.(bson.A) {
+		resultado = append(resultado, elemento.(string))
+	}
+	sort.Strings(resultado)
+	return resultado
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"TPIntegrador/database"
+	"context"
+)
+
+// Agrupa los repositorios que participan de una misma transaccion
+type Repositorios struct {
+	Camion          CamionRepositoryInterface
+	Envio           EnvioRepositoryInterface
+	Pedido          PedidoRepositoryInterface
+	Producto        ProductoRepositoryInterface
+	MovimientoStock MovimientoStockRepositoryInterface
+	Ciudad          CiudadRepositoryInterface
+	Conductor       ConductorRepositoryInterface
+	Mantenimiento   MantenimientoRepositoryInterface
+}
+
+type UnidadDeTrabajoInterface interface {
+	Ejecutar(func(*Repositorios) error) error
+}
+
+type UnidadDeTrabajo struct {
+	db database.DB
+}
+
+func NewUnidadDeTrabajo(db database.DB) *UnidadDeTrabajo {
+	return &UnidadDeTrabajo{
+		db: db,
+	}
+}
+
+// Ejecuta la operacion con repositorios atados a una transaccion.
+// Si la operacion devuelve un error, ninguna de las escrituras hechas con esos repositorios queda guardada.
+func (unidad *UnidadDeTrabajo) Ejecutar(operacion func(*Repositorios) error) error {
+	return unidad.db.EjecutarTransaccion(func(ctx context.Context) error {
+		repositorios := &Repositorios{
+			Camion:          &CamionRepository{db: unidad.db, ctx: ctx},
+			Envio:           &EnvioRepository{db: unidad.db, ctx: ctx},
+			Pedido:          &PedidoRepository{db: unidad.db, ctx: ctx},
+			Producto:        &ProductoRepository{db: unidad.db, ctx: ctx},
+			MovimientoStock: &MovimientoStockRepository{db: unidad.db, ctx: ctx},
+			Ciudad:          &CiudadRepository{db: unidad.db, ctx: ctx},
+			Conductor:       &ConductorRepository{db: unidad.db, ctx: ctx},
+			Mantenimiento:   &MantenimientoRepository{db: unidad.db, ctx: ctx},
+		}
+
+		return operacion(repositorios)
+	})
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"TPIntegrador/model"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Devuelve una copia del filtro que ademas exige que el documento siga en la version que se leyo.
+// Los documentos creados antes de que existiera la version no tienen el campo y se toman como version 0
+func filtroConVersion(filtro bson.M, version int) bson.M {
+	filtroVersion := bson.M{}
+	for campo, valor := range filtro {
+		filtroVersion[campo] = valor
+	}
+
+	if version == 0 {
+		filtroVersion["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filtroVersion["version"] = version
+	}
+
+	return filtroVersion
+}
+
+// Si una operacion condicionada por version no afecto ningun documento, distingue entre que el documento
+// no exista y que haya cambiado de version
+func verificarOperacionConVersion(ctx context.Context, collection *mongo.Collection, filtro bson.M, documentosAfectados int64, mensajeNoEncontrado string) error {
+	if documentosAfectados > 0 {
+		return nil
+	}
+
+	cantidad, err := collection.CountDocuments(ctx, filtro)
+
+	if err != nil {
+		return err
+	}
+
+	if cantidad == 0 {
+		return errors.New(mensajeNoEncontrado)
+	}
+
+	return model.ErrConflictoDeVersion
+}
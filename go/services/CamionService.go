@@ -6,13 +6,17 @@ import (
 	"TPIntegrador/repositories"
 	"TPIntegrador/utils"
 	"errors"
+	"sort"
+	"time"
 )
 
 type CamionServiceInterface interface {
 	CrearCamion(*dto.Camion, *dto.User) error
-	ObtenerCamiones(utils.FiltroCamion) ([]*dto.Camion, error)
+	ObtenerCamiones(utils.FiltroCamion) ([]*dto.Camion, *dto.Pagina, error)
 	ActualizarCamion(*dto.Camion, *dto.User) error
 	EliminarCamion(*dto.Camion, *dto.User) error
+	GuardarDocumentoCamion(*dto.Camion, *dto.DocumentoCamion, *dto.User) error
+	ObtenerDocumentosPorVencer(dias int) ([]*dto.DocumentoPorVencer, error)
 }
 
 type CamionService struct {
@@ -32,6 +36,25 @@ func (service *CamionService) CrearCamion(camion *dto.Camion, usuario *dto.User)
 		return errors.New("el usuario no tiene permisos para crear un camion")
 	}
 
+	//Un camion sin volumen maximo solo se limita por peso
+	if camion.VolumenMaximo < 0 {
+		return errors.New("el volumen maximo del camion no puede ser negativo")
+	}
+
+	//Los documentos se pueden cargar al crear el camion, o despues uno por uno
+	tiposCargados := make(map[model.TipoDocumentoCamion]bool)
+	for _, documento := range camion.Documentos {
+		err := validarDocumentoCamion(&documento)
+		if err != nil {
+			return err
+		}
+
+		if tiposCargados[documento.Tipo] {
+			return errors.New("el camion tiene mas de un documento de tipo " + string(documento.Tipo))
+		}
+		tiposCargados[documento.Tipo] = true
+	}
+
 	//Le agregamos el codigo del usuario que lo creo
 	camion.IdCreador = usuario.Codigo
 
@@ -41,15 +64,17 @@ func (service *CamionService) CrearCamion(camion *dto.Camion, usuario *dto.User)
 	return service.camionRepository.CrearCamion(camion.GetModel())
 }
 
-func (service *CamionService) ObtenerCamiones(filtro utils.FiltroCamion) ([]*dto.Camion, error) {
+// Devuelve los camiones activos del filtro, en el orden y con la paginacion que indica. La pagina lleva los mismos
+// camiones, junto con el cursor de la siguiente y el total
+func (service *CamionService) ObtenerCamiones(filtro utils.FiltroCamion) ([]*dto.Camion, *dto.Pagina, error) {
 	//Aseguramos que el filtro tenga el campo esta_activo en true
 	filtro.EstaActivo = true
 	filtro.FiltrarPorEstaActivo = true
 
-	camionesDB, err := service.camionRepository.ObtenerCamiones(filtro)
+	camionesDB, pagina, err := service.camionRepository.ObtenerPaginaCamiones(filtro)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	//Inicializo la lista de camiones por si no hay ninguno
@@ -60,45 +85,47 @@ func (service *CamionService) ObtenerCamiones(filtro utils.FiltroCamion) ([]*dto
 		camiones = append(camiones, camion)
 	}
 
-	return camiones, nil
+	return camiones, dto.NewPagina(camiones, pagina), nil
 }
 
 func (service *CamionService) ActualizarCamion(camion *dto.Camion, usuario *dto.User) error {
-	valido, err := service.validarUsuario(camion, usuario)
-	if !valido {
+	camionDB, err := service.validarUsuario(camion, usuario)
+	if err != nil {
+		return err
+	}
+
+	if camion.VolumenMaximo < 0 {
+		return errors.New("el volumen maximo del camion no puede ser negativo")
+	}
+
+	//Si el cliente indico la version que leyo, el camion no puede haber cambiado desde entonces
+	err = validarVersion(camion.Version, camionDB.Version)
+	if err != nil {
 		return err
 	}
 
 	//Aseguramos que el camion sigue activo
 	camion.EstaActivo = true
 
+	//La actualizacion se condiciona a la version que se leyo
+	camion.Version = camionDB.Version
+
 	return service.camionRepository.ActualizarCamion(camion.GetModel())
 }
 
 // En lugar de eliminar el camion, actualiza el campo esta_activo a false
 func (service *CamionService) EliminarCamion(camionConPatente *dto.Camion, usuario *dto.User) error {
-	valido, err := service.validarUsuario(camionConPatente, usuario)
-	if !valido {
+	camion, err := service.validarUsuario(camionConPatente, usuario)
+	if err != nil {
 		return err
 	}
 
-	//Creamos el filtro para obtener el camion
-	filtro := utils.FiltroCamion{Patente: camionConPatente.Patente}
-
-	//Obtengo el camion de la base de datos
-	camiones, err := service.camionRepository.ObtenerCamiones(filtro)
-
+	//Si el cliente indico la version que leyo, el camion no puede haber cambiado desde entonces
+	err = validarVersion(camionConPatente.Version, camion.Version)
 	if err != nil {
 		return err
 	}
 
-	//Si no existe el camion, devuelvo un error
-	if len(camiones) == 0 {
-		return errors.New("no existe el camion")
-	}
-
-	camion := camiones[0]
-
 	//Valido que el camion no tenga envios actualmente
 	err, tieneEnvios := service.camionTieneEnviosActualmente(dto.NewCamion(*camion))
 
@@ -118,55 +145,143 @@ func (service *CamionService) EliminarCamion(camionConPatente *dto.Camion, usuar
 	return service.camionRepository.ActualizarCamion(camion)
 }
 
+// Carga o renueva un documento del camion. Si ya tenia uno del mismo tipo, lo reemplaza
+func (service *CamionService) GuardarDocumentoCamion(camion *dto.Camion, documento *dto.DocumentoCamion, usuario *dto.User) error {
+	camionDB, err := service.validarUsuario(camion, usuario)
+	if err != nil {
+		return err
+	}
+
+	if !camionDB.EstaActivo {
+		return errors.New("el camion ha sido dado de baja")
+	}
+
+	//Si el cliente indico la version que leyo, el camion no puede haber cambiado desde entonces
+	err = validarVersion(camion.Version, camionDB.Version)
+	if err != nil {
+		return err
+	}
+
+	err = validarDocumentoCamion(documento)
+	if err != nil {
+		return err
+	}
+
+	camionDB.GuardarDocumento(documento.GetModel())
+
+	return service.camionRepository.ActualizarDocumentosCamion(camionDB)
+}
+
+// Devuelve los documentos de los camiones activos que vencen dentro de los proximos dias, incluyendo los que ya vencieron.
+// Los que vencen antes aparecen primero
+func (service *CamionService) ObtenerDocumentosPorVencer(dias int) ([]*dto.DocumentoPorVencer, error) {
+	if dias < 0 {
+		return nil, errors.New("la cantidad de dias no puede ser negativa")
+	}
+
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{EstaActivo: true, FiltrarPorEstaActivo: true})
+	if err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	fechaLimite := ahora.AddDate(0, 0, dias)
+
+	//Inicializo la lista por si no hay documentos por vencer
+	documentos := make([]*dto.DocumentoPorVencer, 0)
+
+	for _, camion := range camiones {
+		//Los documentos obligatorios que faltan no bloquean el camion, pero se avisan para que se carguen
+		for _, tipo := range camion.ObtenerDocumentosFaltantes() {
+			documentos = append(documentos, dto.NewDocumentoFaltante(camion.Patente, tipo))
+		}
+
+		for _, documento := range camion.ObtenerDocumentosVencidos(fechaLimite) {
+			documentos = append(documentos, dto.NewDocumentoPorVencer(camion.Patente, documento, ahora))
+		}
+	}
+
+	//Primero los faltantes, y despues por fecha de vencimiento
+	sort.SliceStable(documentos, func(i, j int) bool {
+		if documentos[i].FechaVencimiento == nil || documentos[j].FechaVencimiento == nil {
+			return documentos[i].FechaVencimiento == nil && documentos[j].FechaVencimiento != nil
+		}
+		return documentos[i].FechaVencimiento.Before(*documentos[j].FechaVencimiento)
+	})
+
+	return documentos, nil
+}
+
+func validarDocumentoCamion(documento *dto.DocumentoCamion) error {
+	if !model.EsUnTipoDocumentoCamionValido(documento.Tipo) {
+		return errors.New("el tipo de documento no es válido")
+	}
+
+	if documento.FechaVencimiento.IsZero() {
+		return errors.New("el documento debe tener una fecha de vencimiento")
+	}
+
+	if documento.FechaEmision != nil && !documento.FechaVencimiento.After(*documento.FechaEmision) {
+		return errors.New("la fecha de vencimiento del documento debe ser posterior a la de emision")
+	}
+
+	return nil
+}
+
 func (service *CamionService) camionTieneEnviosActualmente(camion *dto.Camion) (error, bool) {
+	tieneEnvios, err := camionTieneEnviosEnCurso(service.envioRepository, camion.Patente)
+	return err, tieneEnvios
+}
+
+// Indica si el camion tiene envios A Despachar o En Ruta
+func camionTieneEnviosEnCurso(envioRepository repositories.EnvioRepositoryInterface, patente string) (bool, error) {
 	//Creamos el filtro para obtener los envios
-	filtro := utils.FiltroEnvio{PatenteCamion: camion.Patente, Estado: model.ADespachar}
+	filtro := utils.FiltroEnvio{PatenteCamion: patente, Estado: model.ADespachar}
 
 	//Obtengo los envios de la base de datos
-	enviosADespachar, err := service.envioRepository.ObtenerEnvios(&filtro)
+	enviosADespachar, err := envioRepository.ObtenerEnvios(&filtro)
 
 	if err != nil {
-		return errors.New("error al obtener los envios a despachar"), false
+		return false, errors.New("error al obtener los envios a despachar")
 	}
 
 	//Hacemos lo mismo para los envios que estan En Ruta
 	filtro.Estado = model.EnRuta
 
-	enviosEnRuta, err := service.envioRepository.ObtenerEnvios(&filtro)
+	enviosEnRuta, err := envioRepository.ObtenerEnvios(&filtro)
 
 	if err != nil {
-		return errors.New("error al obtener los envios en ruta"), false
+		return false, errors.New("error al obtener los envios en ruta")
 	}
 
 	//Si no hay envios, devuelvo false
 	if len(enviosADespachar) == 0 && len(enviosEnRuta) == 0 {
-		return nil, false
+		return false, nil
 	}
 
-	return nil, true
+	return true, nil
 }
 
-func (service *CamionService) validarUsuario(camion *dto.Camion, usuario *dto.User) (bool, error) {
-	//Primero buscamos el camion por patente
+// Valida que el usuario pueda administrar camiones y que el camion exista, y lo devuelve tal como esta en la base de datos.
+// Quien maneja cada camion se define con las asignaciones de conductores, no con el creador del camion
+func (service *CamionService) validarUsuario(camion *dto.Camion, usuario *dto.User) (*model.Camion, error) {
+	if !service.validarRol(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para modificar camiones")
+	}
+
+	//Buscamos el camion por patente
 	filtro := utils.FiltroCamion{Patente: camion.Patente}
 
 	camiones, err := service.camionRepository.ObtenerCamiones(filtro)
 	if err != nil {
-		return false, errors.New("problema al validar usuario: " + err.Error())
+		return nil, errors.New("problema al validar usuario: " + err.Error())
 	}
 
 	if len(camiones) == 0 {
-		return false, errors.New("no existe el camion")
+		return nil, errors.New("no existe el camion")
 	}
 
-	camionDB := camiones[0]
-
-	//Validamos que el envio pertenezca al camionero
-	if camionDB.IdCreador != usuario.Codigo {
-		return false, errors.New("el camion no pertenece al usuario")
-	}
-
-	return true, nil
+	return camiones[0], nil
 }
 
 func (service *CamionService) validarRol(usuario *dto.User) bool {
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/model"
+	"TPIntegrador/repositories"
+	"TPIntegrador/utils"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type CiudadServiceInterface interface {
+	ObtenerCiudades() ([]*dto.Ciudad, error)
+	ImportarDistancias(*dto.Archivo, *dto.User) (*dto.ImportacionDistancias, error)
+}
+
+// Tamaño maximo del archivo de distancias
+const TamañoMaximoArchivoDistancias = 5 << 20
+
+type CiudadService struct {
+	ciudadRepository repositories.CiudadRepositoryInterface
+	unidadDeTrabajo  repositories.UnidadDeTrabajoInterface
+}
+
+func NewCiudadService(ciudadRepository repositories.CiudadRepositoryInterface, unidadDeTrabajo repositories.UnidadDeTrabajoInterface) *CiudadService {
+	return &CiudadService{
+		ciudadRepository: ciudadRepository,
+		unidadDeTrabajo:  unidadDeTrabajo,
+	}
+}
+
+func (service *CiudadService) ObtenerCiudades() ([]*dto.Ciudad, error) {
+	ciudadesDB, err := service.ciudadRepository.ObtenerCiudades()
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Inicializo la lista de ciudades por si no hay ninguna
+	ciudades := make([]*dto.Ciudad, 0)
+
+	for _, ciudadDB := range ciudadesDB {
+		ciudades = append(ciudades, dto.NewCiudad(ciudadDB))
+	}
+
+	return ciudades, nil
+}
+
+// Carga las distancias de un archivo CSV (origen,destino,km) o JSON, y da de alta las ciudades que aparecen en el.
+// Si alguna fila es invalida no se guarda nada
+func (service *CiudadService) ImportarDistancias(archivo *dto.Archivo, usuario *dto.User) (*dto.ImportacionDistancias, error) {
+	if !service.validarRol(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para importar distancias")
+	}
+
+	if archivo == nil {
+		return nil, errors.New("debe adjuntar el archivo de distancias")
+	}
+
+	distancias, err := leerArchivoDistancias(archivo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(distancias) == 0 {
+		return nil, errors.New("el archivo no tiene distancias")
+	}
+
+	//Validamos todas las filas antes de guardar
+	ciudades := make(map[string]string)
+
+	for i, distancia := range distancias {
+		if strings.TrimSpace(distancia.Origen) == "" || strings.TrimSpace(distancia.Destino) == "" {
+			return nil, fmt.Errorf("la distancia %d no tiene origen o destino", i+1)
+		}
+
+		if model.ObtenerClaveCiudad(distancia.Origen) == model.ObtenerClaveCiudad(distancia.Destino) {
+			return nil, fmt.Errorf("la distancia %d tiene el mismo origen y destino", i+1)
+		}
+
+		if distancia.Km <= 0 {
+			return nil, fmt.Errorf("la distancia %d debe tener una cantidad de km mayor a cero", i+1)
+		}
+
+		ciudades[model.ObtenerClaveCiudad(distancia.Origen)] = strings.TrimSpace(distancia.Origen)
+		ciudades[model.ObtenerClaveCiudad(distancia.Destino)] = strings.TrimSpace(distancia.Destino)
+	}
+
+	err = service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		for _, nombre := range ciudades {
+			err := repositorios.Ciudad.GuardarCiudad(&model.Ciudad{Nombre: nombre, IdCreador: usuario.Codigo})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, distancia := range distancias {
+			distanciaDB := distancia.GetModel()
+			distanciaDB.IdCreador = usuario.Codigo
+
+			err := repositorios.Ciudad.GuardarDistancia(distanciaDB)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportacionDistancias{Ciudades: len(ciudades), Distancias: len(distancias)}, nil
+}
+
+// Lee las distancias del archivo segun su formato, que se deduce de la extension o del contenido
+func leerArchivoDistancias(archivo *dto.Archivo) ([]dto.DistanciaCiudades, error) {
+	nombre := strings.ToLower(archivo.Nombre)
+
+	//El JSON de distancias es una lista, asi que empieza con un corchete
+	esJSON := bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(archivo.Contenido, []byte("\xef\xbb\xbf"))), []byte("["))
+
+	if strings.Contains(archivo.Tipo, "json") || strings.HasSuffix(nombre, ".json") || esJSON {
+		var distancias []dto.DistanciaCiudades
+
+		err := json.Unmarshal(archivo.Contenido, &distancias)
+
+		if err != nil {
+			return nil, errors.New("el archivo JSON de distancias no es valido: " + err.Error())
+		}
+
+		return distancias, nil
+	}
+
+	if strings.Contains(archivo.Tipo, "csv") || strings.HasSuffix(nombre, ".csv") || strings.HasPrefix(archivo.Tipo, "text/plain") {
+		return leerDistanciasCSV(archivo.Contenido)
+	}
+
+	return nil, errors.New("el archivo de distancias debe ser CSV o JSON")
+}
+
+// Cada fila del CSV es origen,destino,km. La primera fila puede ser el encabezado
+func leerDistanciasCSV(contenido []byte) ([]dto.DistanciaCiudades, error) {
+	lector := csv.NewReader(bytes.NewReader(contenido))
+	lector.FieldsPerRecord = 3
+	lector.TrimLeadingSpace = true
+
+	filas, err := lector.ReadAll()
+
+	if err != nil {
+		return nil, errors.New("el archivo CSV de distancias no es valido: " + err.Error())
+	}
+
+	distancias := make([]dto.DistanciaCiudades, 0)
+
+	for i, fila := range filas {
+		km, err := strconv.Atoi(strings.TrimSpace(fila[2]))
+
+		if err != nil {
+			//Si la primera fila no tiene un numero, es el encabezado
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("la fila %d del CSV no tiene una cantidad de km valida", i+1)
+		}
+
+		distancias = append(distancias, dto.DistanciaCiudades{
+			Origen:  fila[0],
+			Destino: fila[1],
+			Km:      km,
+		})
+	}
+
+	return distancias, nil
+}
+
+func (service *CiudadService) validarRol(usuario *dto.User) bool {
+	return usuario.Rol == string(utils.Administrador)
+}
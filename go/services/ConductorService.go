@@ -0,0 +1,325 @@
+package services
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/model"
+	"TPIntegrador/repositories"
+	"TPIntegrador/utils"
+	"errors"
+	"strings"
+	"time"
+)
+
+type ConductorServiceInterface interface {
+	CrearConductor(*dto.Conductor, *dto.User) error
+	ObtenerConductores() ([]*dto.Conductor, error)
+	ObtenerConductorPorCodigo(*dto.Conductor) (*dto.Conductor, error)
+	ActualizarConductor(*dto.Conductor, *dto.User) error
+	EliminarConductor(*dto.Conductor, *dto.User) error
+	AsignarCamion(*dto.AsignacionCamion, *dto.User) error
+	ObtenerAsignaciones(utils.FiltroAsignacionCamion) ([]*dto.AsignacionCamion, error)
+	FinalizarAsignacion(*dto.AsignacionCamion, *dto.User) error
+}
+
+type ConductorService struct {
+	conductorRepository repositories.ConductorRepositoryInterface
+	camionRepository    repositories.CamionRepositoryInterface
+	unidadDeTrabajo     repositories.UnidadDeTrabajoInterface
+}
+
+func NewConductorService(conductorRepository repositories.ConductorRepositoryInterface, camionRepository repositories.CamionRepositoryInterface, unidadDeTrabajo repositories.UnidadDeTrabajoInterface) *ConductorService {
+	return &ConductorService{
+		conductorRepository: conductorRepository,
+		camionRepository:    camionRepository,
+		unidadDeTrabajo:     unidadDeTrabajo,
+	}
+}
+
+// Devuelve una copia del service que trabaja con los repositorios de una transaccion
+func (service *ConductorService) conRepositorios(repositorios *repositories.Repositorios) *ConductorService {
+	return &ConductorService{
+		conductorRepository: repositorios.Conductor,
+		camionRepository:    repositorios.Camion,
+		unidadDeTrabajo:     service.unidadDeTrabajo,
+	}
+}
+
+func (service *ConductorService) CrearConductor(conductor *dto.Conductor, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para crear un conductor")
+	}
+
+	//El conductor se vincula con el usuario con el que se autentica
+	if strings.TrimSpace(conductor.CodigoUsuario) == "" {
+		return errors.New("el conductor debe tener el codigo de su usuario")
+	}
+
+	if strings.TrimSpace(conductor.Nombre) == "" {
+		return errors.New("el conductor debe tener un nombre")
+	}
+
+	//No puede haber dos conductores para el mismo usuario
+	conductores, err := service.conductorRepository.ObtenerConductores(utils.FiltroConductor{CodigoUsuario: conductor.CodigoUsuario})
+
+	if err != nil {
+		return err
+	}
+
+	if len(conductores) > 0 {
+		return errors.New("ya existe un conductor para el usuario " + conductor.CodigoUsuario)
+	}
+
+	//Le agregamos el codigo del usuario que lo creo
+	conductor.IdCreador = usuario.Codigo
+
+	//Indicamos que el conductor esta activo
+	conductor.EstaActivo = true
+
+	return service.conductorRepository.CrearConductor(conductor.GetModel())
+}
+
+func (service *ConductorService) ObtenerConductores() ([]*dto.Conductor, error) {
+	//Solo devolvemos los conductores activos
+	filtro := utils.FiltroConductor{EstaActivo: true, FiltrarPorEstaActivo: true}
+
+	conductoresDB, err := service.conductorRepository.ObtenerConductores(filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Inicializo la lista de conductores por si no hay ninguno
+	conductores := make([]*dto.Conductor, 0)
+
+	for _, conductorDB := range conductoresDB {
+		conductores = append(conductores, dto.NewConductor(conductorDB))
+	}
+
+	return conductores, nil
+}
+
+func (service *ConductorService) ObtenerConductorPorCodigo(conductorConCodigo *dto.Conductor) (*dto.Conductor, error) {
+	conductor, err := service.obtenerConductorActivo(conductorConCodigo.CodigoUsuario)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewConductor(conductor), nil
+}
+
+func (service *ConductorService) ActualizarConductor(conductor *dto.Conductor, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para actualizar un conductor")
+	}
+
+	conductorDB, err := service.obtenerConductorActivo(conductor.CodigoUsuario)
+
+	if err != nil {
+		return err
+	}
+
+	//Si el cliente indico la version que leyo, el conductor no puede haber cambiado desde entonces
+	err = validarVersion(conductor.Version, conductorDB.Version)
+
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(conductor.Nombre) == "" {
+		return errors.New("el conductor debe tener un nombre")
+	}
+
+	//Aseguramos que el conductor sigue activo
+	conductor.EstaActivo = true
+
+	//La actualizacion se condiciona a la version que se leyo
+	conductor.Version = conductorDB.Version
+
+	return service.conductorRepository.ActualizarConductor(conductor.GetModel())
+}
+
+// En lugar de eliminar el conductor, actualiza el campo esta_activo a false
+func (service *ConductorService) EliminarConductor(conductorConCodigo *dto.Conductor, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para eliminar un conductor")
+	}
+
+	conductor, err := service.obtenerConductorActivo(conductorConCodigo.CodigoUsuario)
+
+	if err != nil {
+		return err
+	}
+
+	//Si el cliente indico la version que leyo, el conductor no puede haber cambiado desde entonces
+	err = validarVersion(conductorConCodigo.Version, conductor.Version)
+
+	if err != nil {
+		return err
+	}
+
+	//No se puede dar de baja un conductor que tiene un camion asignado en este momento
+	asignaciones, err := service.conductorRepository.ObtenerAsignaciones(utils.FiltroAsignacionCamion{CodigoConductor: conductor.CodigoUsuario, VigenteEn: time.Now()})
+
+	if err != nil {
+		return err
+	}
+
+	if len(asignaciones) > 0 {
+		return errors.New("el conductor tiene asignado el camion " + asignaciones[0].PatenteCamion)
+	}
+
+	conductor.EstaActivo = false
+
+	return service.conductorRepository.ActualizarConductor(conductor)
+}
+
+// Asigna el camion al conductor durante un periodo. Ni el camion ni el conductor pueden tener otra asignacion
+// que se superponga con ese periodo
+func (service *ConductorService) AsignarCamion(asignacion *dto.AsignacionCamion, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para asignar camiones")
+	}
+
+	//Si no se indica desde cuando, la asignacion empieza en este momento
+	if asignacion.FechaDesde.IsZero() {
+		asignacion.FechaDesde = time.Now()
+	}
+
+	if asignacion.FechaHasta != nil && !asignacion.FechaHasta.After(asignacion.FechaDesde) {
+		return errors.New("la fecha hasta de la asignacion debe ser posterior a la fecha desde")
+	}
+
+	//El camion tiene que existir y estar activo
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{Patente: asignacion.PatenteCamion, EstaActivo: true, FiltrarPorEstaActivo: true})
+
+	if err != nil {
+		return err
+	}
+
+	if len(camiones) == 0 {
+		return errors.New("no existe el camion, o bien ha sido dado de baja")
+	}
+
+	_, err = service.obtenerConductorActivo(asignacion.CodigoConductor)
+
+	if err != nil {
+		return err
+	}
+
+	asignacion.IdCreador = usuario.Codigo
+
+	//Validamos las superposiciones y creamos la asignacion juntos, para que dos asignaciones no se pisen
+	return service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		nuevaAsignacion := asignacion.GetModel()
+
+		err := transaccion.validarSuperposicion(nuevaAsignacion, utils.FiltroAsignacionCamion{PatenteCamion: nuevaAsignacion.PatenteCamion}, "el camion ya esta asignado a otro conductor en ese periodo")
+
+		if err != nil {
+			return err
+		}
+
+		err = transaccion.validarSuperposicion(nuevaAsignacion, utils.FiltroAsignacionCamion{CodigoConductor: nuevaAsignacion.CodigoConductor}, "el conductor ya tiene otro camion asignado en ese periodo")
+
+		if err != nil {
+			return err
+		}
+
+		err = transaccion.conductorRepository.CrearAsignacion(nuevaAsignacion)
+
+		if err != nil {
+			return err
+		}
+
+		asignacion.Id = utils.GetStringIDFromObjectID(nuevaAsignacion.ObjectId)
+
+		return nil
+	})
+}
+
+func (service *ConductorService) ObtenerAsignaciones(filtro utils.FiltroAsignacionCamion) ([]*dto.AsignacionCamion, error) {
+	asignacionesDB, err := service.conductorRepository.ObtenerAsignaciones(filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Inicializo la lista de asignaciones por si no hay ninguna
+	asignaciones := make([]*dto.AsignacionCamion, 0)
+
+	for _, asignacionDB := range asignacionesDB {
+		asignaciones = append(asignaciones, dto.NewAsignacionCamion(asignacionDB))
+	}
+
+	return asignaciones, nil
+}
+
+// Adelanta el fin de una asignacion. Si no se indica la fecha, termina en este momento
+func (service *ConductorService) FinalizarAsignacion(asignacion *dto.AsignacionCamion, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para finalizar asignaciones")
+	}
+
+	asignacionDB, err := service.conductorRepository.ObtenerAsignacionPorId(asignacion.GetModel())
+
+	if err != nil {
+		return err
+	}
+
+	fechaHasta := time.Now()
+	if asignacion.FechaHasta != nil {
+		fechaHasta = *asignacion.FechaHasta
+	}
+
+	if !fechaHasta.After(asignacionDB.FechaDesde) {
+		return errors.New("la fecha hasta de la asignacion debe ser posterior a la fecha desde")
+	}
+
+	//Solo se puede acortar el periodo, para no superponerlo con otras asignaciones
+	if !asignacionDB.FechaHasta.IsZero() && !fechaHasta.Before(asignacionDB.FechaHasta) {
+		return errors.New("la asignacion ya finaliza antes de esa fecha")
+	}
+
+	asignacionDB.FechaHasta = fechaHasta
+
+	return service.conductorRepository.FinalizarAsignacion(asignacionDB)
+}
+
+// Devuelve un error si alguna de las asignaciones del filtro se superpone con la nueva
+func (service *ConductorService) validarSuperposicion(nuevaAsignacion *model.AsignacionCamion, filtro utils.FiltroAsignacionCamion, mensaje string) error {
+	asignaciones, err := service.conductorRepository.ObtenerAsignaciones(filtro)
+
+	if err != nil {
+		return err
+	}
+
+	for _, asignacion := range asignaciones {
+		if asignacion.SeSuperponeCon(*nuevaAsignacion) {
+			return errors.New(mensaje)
+		}
+	}
+
+	return nil
+}
+
+func (service *ConductorService) obtenerConductorActivo(codigoUsuario string) (*model.Conductor, error) {
+	filtro := utils.FiltroConductor{CodigoUsuario: codigoUsuario, EstaActivo: true, FiltrarPorEstaActivo: true}
+
+	conductores, err := service.conductorRepository.ObtenerConductores(filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conductores) == 0 {
+		return nil, errors.New("no existe el conductor, o bien ha sido dado de baja")
+	}
+
+	return conductores[0], nil
+}
+
+func (service *ConductorService) validarRol(usuario *dto.User) bool {
+	return usuario.Rol == string(utils.Administrador)
+}
@@ -7,32 +7,60 @@ import (
 	"TPIntegrador/utils"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type EnvioServiceInterface interface {
 	CrearEnvio(*dto.Envio, *dto.User) error
-	ObtenerEnvios(utils.FiltroEnvio) ([]*dto.Envio, error)
+	ObtenerEnvios(utils.FiltroEnvio) ([]*dto.Envio, *dto.Pagina, error)
 	ObtenerEnvioPorId(*dto.Envio) (*dto.Envio, error)
-	ObtenerBeneficioTemporal(utils.FiltroEnvio) (dto.BeneficioTemporal, error)
 	ObtenerCantidadEnviosPorEstado() ([]utils.CantidadEstado, error)
 	AgregarParada(*dto.NuevaParada, *dto.User) (bool, error)
 	CambiarEstadoEnvio(*dto.Envio, *dto.User) (bool, error)
+	PlanificarEnvio(*dto.SolicitudPlanificacionEnvio, *dto.User) (*dto.PlanificacionEnvio, error)
+	ConsolidarEnvios(*dto.SolicitudConsolidacionEnvios, *dto.User) (*dto.ConsolidacionEnvios, error)
+	ConfirmarEnvios([]dto.EnvioPropuesto, *dto.User) ([]dto.ResultadoConfirmacionEnvio, error)
 }
 
 type EnvioService struct {
-	envioRepository    repositories.EnvioRepositoryInterface
-	camionRepository   repositories.CamionRepositoryInterface
-	pedidoRepository   repositories.PedidoRepositoryInterface
-	productoRepository repositories.ProductoRepositoryInterface
+	envioRepository           repositories.EnvioRepositoryInterface
+	camionRepository          repositories.CamionRepositoryInterface
+	pedidoRepository          repositories.PedidoRepositoryInterface
+	productoRepository        repositories.ProductoRepositoryInterface
+	movimientoStockRepository repositories.MovimientoStockRepositoryInterface
+	ciudadRepository          repositories.CiudadRepositoryInterface
+	conductorRepository       repositories.ConductorRepositoryInterface
+	mantenimientoRepository   repositories.MantenimientoRepositoryInterface
+	unidadDeTrabajo           repositories.UnidadDeTrabajoInterface
 }
 
-func NewEnvioService(envioRepository repositories.EnvioRepositoryInterface, camionRepository repositories.CamionRepositoryInterface, pedidoRepository repositories.PedidoRepositoryInterface, productoRepository repositories.ProductoRepositoryInterface) *EnvioService {
+func NewEnvioService(envioRepository repositories.EnvioRepositoryInterface, camionRepository repositories.CamionRepositoryInterface, pedidoRepository repositories.PedidoRepositoryInterface, productoRepository repositories.ProductoRepositoryInterface, movimientoStockRepository repositories.MovimientoStockRepositoryInterface, ciudadRepository repositories.CiudadRepositoryInterface, conductorRepository repositories.ConductorRepositoryInterface, mantenimientoRepository repositories.MantenimientoRepositoryInterface, unidadDeTrabajo repositories.UnidadDeTrabajoInterface) *EnvioService {
 	return &EnvioService{
-		envioRepository:    envioRepository,
-		camionRepository:   camionRepository,
-		pedidoRepository:   pedidoRepository,
-		productoRepository: productoRepository,
+		envioRepository:           envioRepository,
+		camionRepository:          camionRepository,
+		pedidoRepository:          pedidoRepository,
+		productoRepository:        productoRepository,
+		movimientoStockRepository: movimientoStockRepository,
+		ciudadRepository:          ciudadRepository,
+		conductorRepository:       conductorRepository,
+		mantenimientoRepository:   mantenimientoRepository,
+		unidadDeTrabajo:           unidadDeTrabajo,
+	}
+}
+
+// Devuelve una copia del service que trabaja con los repositorios de una transaccion
+func (service *EnvioService) conRepositorios(repositorios *repositories.Repositorios) *EnvioService {
+	return &EnvioService{
+		envioRepository:           repositorios.Envio,
+		camionRepository:          repositorios.Camion,
+		pedidoRepository:          repositorios.Pedido,
+		productoRepository:        repositorios.Producto,
+		movimientoStockRepository: repositorios.MovimientoStock,
+		ciudadRepository:          repositorios.Ciudad,
+		conductorRepository:       repositorios.Conductor,
+		mantenimientoRepository:   repositorios.Mantenimiento,
+		unidadDeTrabajo:           service.unidadDeTrabajo,
 	}
 }
 
@@ -42,6 +70,23 @@ func (service *EnvioService) CrearEnvio(envio *dto.Envio, usuario *dto.User) err
 		return errors.New("el usuario no tiene permisos para crear un envio")
 	}
 
+	//Solo el conductor que tiene asignado el camion puede crear envios con el
+	err := service.validarConductorAsignado(envio.PatenteCamion, usuario)
+
+	if err != nil {
+		return err
+	}
+
+	//Indicamos el usuario que creo el envio, que es tambien el conductor que lo lleva
+	envio.IdCreador = usuario.Codigo
+	envio.IdConductor = usuario.Codigo
+
+	return service.crearEnvio(envio)
+}
+
+// Valida que la carga entre en el camion y crea el envio, junto con los cambios en los pedidos y el stock.
+// El envio ya tiene que indicar quien lo creo y que conductor lo lleva
+func (service *EnvioService) crearEnvio(envio *dto.Envio) error {
 	envioCabeEnCamion, err := service.envioCabeEnCamion(envio)
 
 	if err != nil {
@@ -56,31 +101,337 @@ func (service *EnvioService) CrearEnvio(envio *dto.Envio, usuario *dto.User) err
 	//al crearlo coloco el envio en estado despachar
 	envio.Estado = model.ADespachar
 
-	//Indicamos el usuario que creo el envio
-	envio.IdCreador = usuario.Codigo
+	//Los pedidos, el stock y el envio se guardan todos juntos o ninguno
+	return service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		//Creamos primero el envio, para que los movimientos de stock puedan referenciarlo
+		envioDB := envio.GetModel()
 
-	//Cambio el estado de los pedidos del envio
-	err = service.enviarPedidosDeEnvio(envio)
+		err := transaccion.envioRepository.CrearEnvio(envioDB)
+
+		if err != nil {
+			return err
+		}
+
+		envio.Id = utils.GetStringIDFromObjectID(envioDB.ObjectId)
+
+		//Cambio el estado de los pedidos del envio
+		err = transaccion.enviarPedidosDeEnvio(envio)
+
+		if err != nil {
+			return err
+		}
+
+		//descontar stock de productos
+		return transaccion.descontarStockProductosDeEnvio(envio)
+	})
+}
+
+// Propone el orden de las paradas para entregar los pedidos con el camion, usando las distancias cargadas entre ciudades.
+// No crea el envio: el recorrido propuesto sirve para armarlo y para cargar los km de cada parada
+func (service *EnvioService) PlanificarEnvio(solicitud *dto.SolicitudPlanificacionEnvio, usuario *dto.User) (*dto.PlanificacionEnvio, error) {
+	if !service.validarRol(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para planificar un envio")
+	}
+
+	if strings.TrimSpace(solicitud.CiudadOrigen) == "" {
+		return nil, errors.New("la planificacion debe tener una ciudad de origen")
+	}
+
+	if len(solicitud.Pedidos) == 0 {
+		return nil, errors.New("la planificacion debe tener al menos un pedido")
+	}
+
+	//El camion tiene que existir y estar activo
+	filtroCamion := utils.FiltroCamion{Patente: solicitud.PatenteCamion, EstaActivo: true, FiltrarPorEstaActivo: true}
+
+	camiones, err := service.camionRepository.ObtenerCamiones(filtroCamion)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if len(camiones) == 0 {
+		return nil, errors.New("no existe un camion activo con la patente " + solicitud.PatenteCamion)
+	}
+
+	camion := camiones[0]
+
+	//Agrupamos los pedidos por ciudad de destino, ya que cada ciudad es una sola parada
+	var destinos []string
+	pedidosPorCiudad := make(map[string][]string)
+
+	for _, idPedido := range solicitud.Pedidos {
+		pedidoParaBuscar := dto.Pedido{Id: idPedido}
+
+		pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoParaBuscar.GetModel())
+
+		if err != nil {
+			return nil, err
+		}
+
+		if pedido == nil {
+			return nil, errors.New("no se encontró el pedido " + idPedido)
+		}
+
+		if pedido.Estado != model.Aceptado {
+			return nil, errors.New("el pedido " + idPedido + " no se encuentra en estado Aceptado")
+		}
+
+		clave := model.ObtenerClaveCiudad(pedido.CiudadDestino)
+
+		if _, ok := pedidosPorCiudad[clave]; !ok {
+			destinos = append(destinos, strings.TrimSpace(pedido.CiudadDestino))
+		}
+
+		pedidosPorCiudad[clave] = append(pedidosPorCiudad[clave], idPedido)
+	}
+
+	//Buscamos solo las distancias entre las ciudades del recorrido
+	claves := []string{model.ObtenerClaveCiudad(solicitud.CiudadOrigen)}
+	for _, destino := range destinos {
+		claves = append(claves, model.ObtenerClaveCiudad(destino))
+	}
+
+	distancias, err := service.ciudadRepository.ObtenerDistancias(claves)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matriz := model.NewMatrizDistancias(distancias)
+
+	paradas, err := matriz.PlanificarRuta(strings.TrimSpace(solicitud.CiudadOrigen), destinos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	planificacion := &dto.PlanificacionEnvio{
+		PatenteCamion: camion.Patente,
+		CiudadOrigen:  strings.TrimSpace(solicitud.CiudadOrigen),
+		Paradas:       make([]dto.ParadaPlanificada, 0),
+		KmTotales:     model.ObtenerKmTotales(paradas),
+	}
+
+	for _, parada := range paradas {
+		planificacion.Paradas = append(planificacion.Paradas, dto.ParadaPlanificada{
+			Ciudad:       parada.Ciudad,
+			KmRecorridos: parada.KmRecorridos,
+			Pedidos:      pedidosPorCiudad[model.ObtenerClaveCiudad(parada.Ciudad)],
+		})
+	}
+
+	planificacion.CostoEstimado = camion.CostoPorKilometro * float64(planificacion.KmTotales)
+
+	return planificacion, nil
+}
+
+// Reparte todos los pedidos aceptados entre los camiones activos que no estan en un envio y tienen un conductor asignado,
+// y devuelve los envios propuestos sin crearlos
+func (service *EnvioService) ConsolidarEnvios(solicitud *dto.SolicitudConsolidacionEnvios, usuario *dto.User) (*dto.ConsolidacionEnvios, error) {
+	if !service.validarRolPlanificacion(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para consolidar envios")
+	}
+
+	origen := strings.TrimSpace(solicitud.CiudadOrigen)
+
+	if origen == "" {
+		return nil, errors.New("la consolidacion debe tener una ciudad de origen")
+	}
+
+	pedidos, err := service.pedidoRepository.ObtenerPedidos(&utils.FiltroPedido{Estado: model.Aceptado})
+
+	if err != nil {
+		return nil, err
+	}
+
+	camiones, conductores, err := service.obtenerCamionesLibres()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cargas := make([]model.CargaPedido, 0)
+	ciudades := []string{origen}
+
+	for _, pedido := range pedidos {
+		cargas = append(cargas, model.CargaPedido{
+			IdPedido: utils.GetStringIDFromObjectID(pedido.ObjectId),
+			Ciudad:   strings.TrimSpace(pedido.CiudadDestino),
+			Peso:     pedido.ObtenerPesoTotal(),
+			Volumen:  pedido.ObtenerVolumenTotal(),
+		})
+		ciudades = append(ciudades, pedido.CiudadDestino)
+	}
+
+	claves := make([]string, 0)
+	for _, ciudad := range ciudades {
+		claves = append(claves, model.ObtenerClaveCiudad(ciudad))
+	}
+
+	distancias, err := service.ciudadRepository.ObtenerDistancias(claves)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matriz := model.NewMatrizDistancias(distancias)
+
+	//Sin todas las distancias no se pueden comparar los recorridos
+	err = matriz.ValidarDistancias(ciudades)
+
+	if err != nil {
+		return nil, err
+	}
+
+	envios, sinAsignar := matriz.ConsolidarCarga(origen, cargas, camiones)
+
+	consolidacion := &dto.ConsolidacionEnvios{
+		Envios:            make([]dto.EnvioPropuesto, 0),
+		PedidosSinAsignar: make([]dto.PedidoSinAsignar, 0),
 	}
 
-	//descontar stock de productos
-	err = service.descontarStockProductosDeEnvio(envio)
+	for _, envio := range envios {
+		envioPropuesto := dto.NewEnvioPropuesto(&envio)
+		envioPropuesto.CodigoConductor = conductores[envio.Camion.Patente]
+
+		consolidacion.Envios = append(consolidacion.Envios, *envioPropuesto)
+		consolidacion.KmTotales += envio.Km
+		consolidacion.CostoTotal += envio.Costo
+	}
+
+	for _, pedido := range sinAsignar {
+		consolidacion.PedidosSinAsignar = append(consolidacion.PedidosSinAsignar, *dto.NewPedidoSinAsignar(&pedido))
+	}
+
+	return consolidacion, nil
+}
+
+// Devuelve los camiones activos que no tienen un envio a despachar o en ruta y que tienen un conductor asignado,
+// junto con el codigo del conductor de cada uno
+func (service *EnvioService) obtenerCamionesLibres() ([]*model.Camion, map[string]string, error) {
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{EstaActivo: true, FiltrarPorEstaActivo: true})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ocupados := make(map[string]bool)
+
+	for _, estado := range []model.EstadoEnvio{model.ADespachar, model.EnRuta} {
+		envios, err := service.envioRepository.ObtenerEnvios(&utils.FiltroEnvio{Estado: estado})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, envio := range envios {
+			ocupados[envio.PatenteCamion] = true
+		}
+	}
+
+	libres := make([]*model.Camion, 0)
+	conductores := make(map[string]string)
+
+	for _, camion := range camiones {
+		if ocupados[camion.Patente] {
+			continue
+		}
+
+		//Solo se proponen los camiones que CrearEnvio aceptaria
+		err := service.validarCamionPuedeSalir(camion)
+
+		var errorDocumentacion *model.ErrorDocumentacionCamion
+		var errorMantenimiento *model.ErrorMantenimientoCamion
+		if errors.As(err, &errorDocumentacion) || errors.As(err, &errorMantenimiento) {
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		//Sin conductor asignado, el envio no se podria confirmar
+		codigoConductor, err := service.obtenerConductorAsignado(camion.Patente, time.Now())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if codigoConductor == "" {
+			continue
+		}
+
+		libres = append(libres, camion)
+		conductores[camion.Patente] = codigoConductor
+	}
+
+	return libres, conductores, nil
+}
+
+// Crea un envio por cada envio propuesto, con las mismas validaciones que CrearEnvio. Cada envio lo lleva el conductor
+// que tiene asignado el camion en ese momento. Cada envio se crea por separado, asi que si uno falla los demas igual se crean
+func (service *EnvioService) ConfirmarEnvios(enviosPropuestos []dto.EnvioPropuesto, usuario *dto.User) ([]dto.ResultadoConfirmacionEnvio, error) {
+	if !service.validarRolPlanificacion(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para crear un envio")
+	}
+
+	if len(enviosPropuestos) == 0 {
+		return nil, errors.New("no hay envios para confirmar")
+	}
+
+	resultados := make([]dto.ResultadoConfirmacionEnvio, 0)
+
+	for _, envioPropuesto := range enviosPropuestos {
+		envio := dto.Envio{
+			PatenteCamion: envioPropuesto.PatenteCamion,
+			Pedidos:       envioPropuesto.Pedidos,
+		}
+
+		resultado := dto.ResultadoConfirmacionEnvio{PatenteCamion: envioPropuesto.PatenteCamion}
+
+		err := service.confirmarEnvio(&envio, usuario)
+
+		if err != nil {
+			resultado.Error = err.Error()
+		} else {
+			resultado.IdEnvio = envio.Id
+			resultado.CodigoConductor = envio.IdConductor
+		}
+
+		resultados = append(resultados, resultado)
+	}
+
+	return resultados, nil
+}
+
+// Crea el envio propuesto a nombre del conductor que tiene asignado el camion
+func (service *EnvioService) confirmarEnvio(envio *dto.Envio, usuario *dto.User) error {
+	codigoConductor, err := service.obtenerConductorAsignado(envio.PatenteCamion, time.Now())
 
 	if err != nil {
 		return err
 	}
 
-	return service.envioRepository.CrearEnvio(envio.GetModel())
+	if codigoConductor == "" {
+		return errors.New("el camion " + envio.PatenteCamion + " no tiene un conductor asignado")
+	}
+
+	envio.IdCreador = usuario.Codigo
+	envio.IdConductor = codigoConductor
+
+	return service.crearEnvio(envio)
 }
 
-func (service *EnvioService) ObtenerEnvios(filtroEnvio utils.FiltroEnvio) ([]*dto.Envio, error) {
+// Devuelve los envios del filtro, en el orden y con la paginacion que indica. La pagina lleva los mismos envios,
+// junto con el cursor de la siguiente y el total
+func (service *EnvioService) ObtenerEnvios(filtroEnvio utils.FiltroEnvio) ([]*dto.Envio, *dto.Pagina, error) {
 	//Validamos el estado que se paso para filtrar
 	if filtroEnvio.Estado != "" {
 		if !model.EsUnEstadoEnvioValido(filtroEnvio.Estado) {
-			return nil, errors.New("el estado ingresado para filtrar no es válido")
+			return nil, nil, errors.New("el estado ingresado para filtrar no es válido")
 		}
 	}
 
@@ -89,13 +440,13 @@ func (service *EnvioService) ObtenerEnvios(filtroEnvio utils.FiltroEnvio) ([]*dt
 
 	//Valida que la fecha desde sea menor a la fecha hasta, solo si se pasaron ambas para filtrar
 	if fechaDesde.Year() != 1 && fechaHasta.Year() != 1 && fechaDesde.After(fechaHasta) {
-		return nil, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
+		return nil, nil, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
 	}
 
-	enviosDB, err := service.envioRepository.ObtenerEnvios(&filtroEnvio)
+	enviosDB, pagina, err := service.envioRepository.ObtenerPaginaEnvios(&filtroEnvio)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	//Inicializamos el array de envios por si no hay ninguno
@@ -105,8 +456,8 @@ func (service *EnvioService) ObtenerEnvios(filtroEnvio utils.FiltroEnvio) ([]*dt
 		envio := dto.NewEnvio(*envioDB)
 		envios = append(envios, envio)
 	}
-	
-	return envios, nil
+
+	return envios, dto.NewPagina(envios, pagina), nil
 }
 
 func (service *EnvioService) ObtenerEnvioPorId(envioConID *dto.Envio) (*dto.Envio, error) {
@@ -141,8 +492,15 @@ func (service *EnvioService) envioCabeEnCamion(envio *dto.Envio) (bool, error) {
 
 	camion := camiones[0]
 
-	//Obtenemos el peso total de los pedidos
+	err = service.validarCamionPuedeSalir(camion)
+
+	if err != nil {
+		return false, err
+	}
+
+	//Obtenemos el peso y el volumen total de los pedidos
 	var pesoTotal float64 = 0
+	var volumenTotal float64 = 0
 	for _, idPedido := range envio.Pedidos {
 		//Generamos el pedido para buscar
 		pedidoParaBuscar := dto.Pedido{Id: idPedido}
@@ -153,21 +511,20 @@ func (service *EnvioService) envioCabeEnCamion(envio *dto.Envio) (bool, error) {
 			return false, err
 		}
 
-		//Calculo el peso del pedido sumando el peso de cada producto elegido
-		var peso float64 = 0
-		for _, producto := range pedido.ProductosElegidos {
-			peso += producto.ObtenerPesoProductoPedido()
-		}
-
-		pesoTotal += peso
+		//Calculo el peso y el volumen del pedido sumando los de cada producto elegido
+		pesoTotal += pedido.ObtenerPesoTotal()
+		volumenTotal += pedido.ObtenerVolumenTotal()
 	}
 
-	//Verificamos si el peso total de los pedidos es menor o igual al peso maximo del camion
-	if pesoTotal <= float64(camion.PesoMaximo) {
-		return true, nil
-	} else {
-		return false, nil
+	//Verificamos que la carga no supere ni el peso ni el volumen maximo del camion.
+	//Si no entra, el error indica que porcentaje de cada limite ocuparia
+	err = model.NewOcupacionCamion(camion, pesoTotal, volumenTotal).ValidarCapacidad()
+
+	if err != nil {
+		return false, err
 	}
+
+	return true, nil
 }
 
 func (service *EnvioService) enviarPedidosDeEnvio(envio *dto.Envio) error {
@@ -185,7 +542,7 @@ func (service *EnvioService) enviarPedido(pedidoPorEnviar *dto.Pedido) error {
 	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoPorEnviar.GetModel())
 
 	if err != nil {
-		return errors.New("error buscando el pedido en la DB: " + err.Error())
+		return fmt.Errorf("error buscando el pedido en la DB: %w", err)
 	}
 
 	//Valida que el pedido esté en estado Aceptado
@@ -202,292 +559,313 @@ func (service *EnvioService) enviarPedido(pedidoPorEnviar *dto.Pedido) error {
 	err = service.pedidoRepository.ActualizarPedido(pedido)
 
 	if err != nil {
-		return errors.New("error actualizando el pedido en la DB: " + err.Error())
+		return fmt.Errorf("error actualizando el pedido en la DB: %w", err)
 	}
 
 	return nil
 }
 
-func (service *EnvioService) ObtenerBeneficioTemporal(filtro utils.FiltroEnvio) (dto.BeneficioTemporal, error) {
-	//Inicializamos el beneficio temporal
-	beneficioTemporal := dto.BeneficioTemporal{}
-	fechaDesde := filtro.FechaUltimaActualizacionDesde
-	fechaHasta := filtro.FechaUltimaActualizacionHasta
+func (service *EnvioService) ObtenerCantidadEnviosPorEstado() ([]utils.CantidadEstado, error) {
+	//Por cada estado posible de envio, obtengo la cantidad de envios en ese estado
+	cantidadEnviosADespachar, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.ADespachar)
 
-	//Valida que la fecha desde sea menor a la fecha hasta
-	if fechaDesde.After(fechaHasta) {
-		return beneficioTemporal, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
+	if err != nil {
+		return nil, err
 	}
 
-	//Obtiene el beneficio anual
-	//Por cada año en el rango de fechas, obtiene el beneficio
-	for año := fechaDesde.Year(); año <= fechaHasta.Year(); año++ {
-		filtroPorAño := utils.FiltroEnvio{
-			FechaUltimaActualizacionDesde: time.Date(año, 1, 1, 0, 0, 0, 0, time.UTC),
-			FechaUltimaActualizacionHasta: time.Date(año, 12, 31, 23, 59, 59, 999999999, time.UTC),
-		}
-
-		montoBeneficioAnual, err := service.obtenerBeneficioEntreFechas(filtroPorAño)
-
-		if err != nil {
-			return beneficioTemporal, err
-		}
-
-		beneficioAnual := dto.BeneficioAnual{Año: año, Monto: montoBeneficioAnual}
+	cantidadEnviosEnRuta, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.EnRuta)
 
-		beneficioTemporal.BeneficiosAnuales = append(beneficioTemporal.BeneficiosAnuales, beneficioAnual)
+	if err != nil {
+		return nil, err
 	}
 
-	//Hacemos lo mismo con los meses
-	for año, mes := fechaDesde.Year(), fechaDesde.Month(); !((año == fechaHasta.Year()) && (mes > fechaHasta.Month()) || año > fechaHasta.Year()); {
-		filtroPorMes := utils.FiltroEnvio{
-			FechaUltimaActualizacionDesde: time.Date(año, mes, 1, 0, 0, 0, 0, time.UTC),
-			FechaUltimaActualizacionHasta: time.Date(año, mes+1, 0, 23, 59, 59, 999999999, time.UTC),
-		}
-
-		montoBeneficioMensual, err := service.obtenerBeneficioEntreFechas(filtroPorMes)
+	cantidadEnviosDespachados, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.Despachado)
 
-		if err != nil {
-			return beneficioTemporal, err
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		beneficioMensual := dto.BeneficioMensual{Mes: int(mes), Monto: montoBeneficioMensual}
+	cantidadEnviosCancelados, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.EnvioCancelado)
 
-		beneficioTemporal.BeneficiosMensuales = append(beneficioTemporal.BeneficiosMensuales, beneficioMensual)
+	if err != nil {
+		return nil, err
+	}
 
-		//Pasamos al mes siguiente
-		if mes == 12 {
-			año++
-			mes = 1
-		} else {
-			mes++
-		}
+	//Agrego los resultados a un array de CantidadEstado
+	cantidadEnviosPorEstados := []utils.CantidadEstado{
+		{Estado: string(model.ADespachar), Cantidad: cantidadEnviosADespachar},
+		{Estado: string(model.EnRuta), Cantidad: cantidadEnviosEnRuta},
+		{Estado: string(model.Despachado), Cantidad: cantidadEnviosDespachados},
+		{Estado: string(model.EnvioCancelado), Cantidad: cantidadEnviosCancelados},
 	}
 
-	return beneficioTemporal, nil
+	return cantidadEnviosPorEstados, nil
 }
 
-func (service *EnvioService) obtenerBeneficioEntreFechas(filtro utils.FiltroEnvio) (float64, error) {
-	//Le agrega el estado despachado al filtro, ya que el beneficio lo tienen los despachados
-	filtro.Estado = model.Despachado
-
-	//Obtengo los envios despachados entre las dos fechas pasadas como parametro
-	envios, err := service.ObtenerEnvios(filtro)
+func (service *EnvioService) AgregarParada(parada *dto.NuevaParada, usuario *dto.User) (bool, error) {
+	//Recibimos la parada con el id del envioSoloId a ingresarla
+	envioSoloId := dto.Envio{Id: parada.IdEnvio}
 
-	if err != nil {
-		return 0, err
+	//Validamos el rol del usuario
+	if !service.validarRol(usuario) {
+		return false, errors.New("el usuario no tiene permisos para agregar una parada")
 	}
 
-	//Valido que haya envios despachados
-	// if len(envios) == 0 {
-	// 	return 0, errors.New("no hay envios despachados entre las fechas ingresadas")
-	// }
+	//La parada y los cambios de estado de sus pedidos se guardan juntos
+	err := service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
 
-	//Suma el precio de los pedidos de cada envio
-	var beneficioBruto float64 = 0
-	for _, envio := range envios {
-		precioTotal, err := service.obtenerPrecioTotalProductosDeEnvio(envio)
+		//Buscamos el envio dentro de la transaccion, para que si se reintenta se parta del envio tal como esta guardado
+		envioDB, err := transaccion.envioRepository.ObtenerEnvioPorId(envioSoloId.GetModel())
 
 		if err != nil {
-			return 0, err
+			return err
 		}
 
-		beneficioBruto += precioTotal
-	}
-
-	//Suma el costo de los envios
-	var costoEnvios float64 = 0
-	for _, envio := range envios {
-		costoEnvio, err := service.obtenerCostoEnvio(envio)
+		//Solo el conductor que lleva el envio puede informar paradas
+		err = validarConductorDelEnvio(transaccion.conductorRepository, envioDB, usuario)
 
 		if err != nil {
-			return 0, err
+			return err
 		}
 
-		costoEnvios += costoEnvio
-	}
+		//Validamos que el envio esté en estado EnRuta
+		if envioDB.Estado != model.EnRuta {
+			return errors.New("el envio no esta en ruta")
+		}
 
-	beneficioNeto := beneficioBruto - costoEnvios
+		//Si el cliente indico la version que leyo, el envio no puede haber cambiado desde entonces
+		err = validarVersion(parada.VersionEnvio, envioDB.Version)
 
-	return beneficioNeto, nil
-}
+		if err != nil {
+			return err
+		}
 
-func (service *EnvioService) obtenerPrecioTotalProductosDeEnvio(envio *dto.Envio) (float64, error) {
-	var precioTotal float64 = 0
+		//Validamos que los pedidos informados sean del envio y no se hayan resuelto en otra parada
+		err = transaccion.validarPedidosDeParada(envioDB, parada)
 
-	for _, idPedido := range envio.Pedidos {
-		//Generamos el pedido para buscar
-		pedidoParaBuscar := dto.Pedido{Id: idPedido}
+		if err != nil {
+			return err
+		}
 
-		pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoParaBuscar.GetModel())
+		//Los pedidos entregados pasan a Enviado
+		for _, idPedido := range parada.PedidosEntregados {
+			err := transaccion.entregarPedido(&dto.Pedido{Id: idPedido}, parada, usuario)
 
-		if err != nil {
-			return 0, err
+			if err != nil {
+				return err
+			}
 		}
 
-		//Convierto el pedido a dto
-		pedidoDTO := dto.NewPedido(pedido)
+		//Los pedidos que no se pudieron entregar vuelven a Aceptado, para poder enviarlos de nuevo
+		for _, idPedido := range parada.PedidosNoEntregados {
+			err := transaccion.devolverPedidoAAceptado(dto.NewEnvio(*envioDB), &dto.Pedido{Id: idPedido}, usuario)
 
-		precioTotal += pedidoDTO.ObtenerPecioTotal()
-	}
+			if err != nil {
+				return err
+			}
+		}
 
-	return precioTotal, nil
-}
+		//Agregamos la nueva parada al envio
+		envioDB.Paradas = append(envioDB.Paradas, parada.GetParada().GetModel())
 
-func (service *EnvioService) obtenerCostoEnvio(envio *dto.Envio) (float64, error) {
-	//Obtiene el camion del envio para conocer el costoPorKilometro
-	//Para el costo no es necesario filtrar por activo, ya que el camion puede estar dado de baja y el envio ya creado
-	filtroPorPatente := utils.FiltroCamion{Patente: envio.PatenteCamion, FiltrarPorEstaActivo: false}
-	camiones, err := service.camionRepository.ObtenerCamiones(filtroPorPatente)
+		//Actualizamos el envio en la base de datos, que ahora tiene la nueva parada
+		return transaccion.envioRepository.ActualizarEnvio(envioDB)
+	})
 
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 
-	//Si no existe el camion, devolvemos un error
-	if len(camiones) == 0 {
-		return 0, errors.New("no existe el camion")
+	return true, nil
+}
+
+func (service *EnvioService) validarPedidosDeParada(envio *model.Envio, parada *dto.NuevaParada) error {
+	//Solo se pueden informar pedidos del envio que sigan pendientes de entrega
+	pendientes := make(map[string]bool)
+	for _, idPedido := range envio.ObtenerPedidosPendientesDeEntrega() {
+		pendientes[idPedido] = true
 	}
 
-	camion := camiones[0]
+	informados := append(append([]string{}, parada.PedidosEntregados...), parada.PedidosNoEntregados...)
 
-	//Suma los kilometros de cada parada
-	var kilometrosRecorridos int = 0
-	for i := 0; i < len(envio.Paradas)-1; i++ {
-		//Obtiene la distancia entre la parada i y la parada i+1
-		kilometrosRecorridos += envio.Paradas[i].KmRecorridos
-	}
+	for _, idPedido := range informados {
+		if !pendientes[idPedido] {
+			return errors.New("el pedido " + idPedido + " no pertenece al envio, ya fue informado en otra parada o esta repetido")
+		}
 
-	costoEnvio := camion.CostoPorKilometro * float64(kilometrosRecorridos)
+		//Lo quitamos para detectar si se informa dos veces en la misma parada
+		delete(pendientes, idPedido)
+	}
 
-	return costoEnvio, nil
+	return nil
 }
 
-func (service *EnvioService) ObtenerCantidadEnviosPorEstado() ([]utils.CantidadEstado, error) {
-	//Por cada estado posible de envio, obtengo la cantidad de envios en ese estado
-	cantidadEnviosADespachar, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.ADespachar)
+func (service *EnvioService) CambiarEstadoEnvio(envio *dto.Envio, usuario *dto.User) (bool, error) {
+	//El estado deseado es el que se pasa con el objeto envio como parametro
+	estadoDeseado := envio.Estado
 
-	if err != nil {
-		return nil, err
+	//Validamos el estado deseado
+	if !model.EsUnEstadoEnvioValido(estadoDeseado) {
+		return false, errors.New("el estado ingresado no es válido")
 	}
 
-	cantidadEnviosEnRuta, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.EnRuta)
-
-	if err != nil {
-		return nil, err
+	//Validamos el rol del usuario
+	if !service.validarRol(usuario) {
+		return false, errors.New("el usuario no tiene permisos para cambiar el estado del envio")
 	}
 
-	cantidadEnviosDespachados, err := service.envioRepository.ObtenerCantidadEnviosPorEstado(model.Despachado)
+	//El cambio de estado del envio y el de sus pedidos se guardan juntos
+	err := service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
 
-	if err != nil {
-		return nil, err
-	}
+		//Buscamos el envio dentro de la transaccion para conocer el estado real, tambien si se reintenta
+		envioDB, err := transaccion.envioRepository.ObtenerEnvioPorId(envio.GetModel())
 
-	//Agrego los resultados a un array de CantidadEstado
-	cantidadEnviosPorEstados := []utils.CantidadEstado{
-		{Estado: string(model.ADespachar), Cantidad: cantidadEnviosADespachar},
-		{Estado: string(model.EnRuta), Cantidad: cantidadEnviosEnRuta},
-		{Estado: string(model.Despachado), Cantidad: cantidadEnviosDespachados},
-	}
+		if err != nil {
+			return err
+		}
 
-	return cantidadEnviosPorEstados, nil
-}
+		//Solo el conductor que lleva el envio puede cambiar su estado
+		err = validarConductorDelEnvio(transaccion.conductorRepository, envioDB, usuario)
 
-func (service *EnvioService) AgregarParada(parada *dto.NuevaParada, usuario *dto.User) (bool, error) {
-	//Recibimos la parada con el id del envioSoloId a ingresarla
-	envioSoloId := dto.Envio{Id: parada.IdEnvio}
+		if err != nil {
+			return err
+		}
 
-	//Primero buscamos el envio por id
-	envioDB, err := service.envioRepository.ObtenerEnvioPorId(envioSoloId.GetModel())
+		//Si la tabla de transiciones no permite pasar del estado actual al deseado, devolvemos un error
+		err = model.ValidarTransicionEnvio(envioDB.Estado, estadoDeseado)
 
-	if err != nil {
-		return false, err
-	}
+		if err != nil {
+			return err
+		}
 
-	//Validamos el rol del usuario
-	if !service.validarRol(usuario) {
-		return false, errors.New("el usuario no tiene permisos para agregar una parada")
-	}
+		//Si el cliente indico la version que leyo, el envio no puede haber cambiado desde entonces
+		err = validarVersion(envio.Version, envioDB.Version)
 
-	//Validamos que el envio esté en estado EnRuta
-	if envioDB.Estado != model.EnRuta {
-		return false, errors.New("el envio no esta en ruta")
-	}
+		if err != nil {
+			return err
+		}
 
-	//Agregamos la nueva parada al envio
-	envioDB.Paradas = append(envioDB.Paradas, parada.GetParada().GetModel())
+		//El camion no puede salir a la ruta con documentos vencidos
+		if estadoDeseado == model.EnRuta {
+			err = transaccion.validarDocumentacionCamion(envioDB.PatenteCamion)
 
-	//Actualizamos el envio en la base de datos, que ahora tiene la nueva parada
-	return true, service.envioRepository.ActualizarEnvio(envioDB)
-}
+			if err != nil {
+				return err
+			}
+		}
 
-func (service *EnvioService) CambiarEstadoEnvio(envio *dto.Envio, usuario *dto.User) (bool, error) {
-	//El estado deseado es el que se pasa con el objeto envio como parametro
-	estadoDeseado := envio.Estado
+		//Actualizamos el envio en la base de datos
+		envioDB.Estado = estadoDeseado
 
-	//Validamos el estado deseado
-	if !model.EsUnEstadoEnvioValido(estadoDeseado) {
-		return false, errors.New("el estado ingresado no es válido")
-	}
+		//El beneficio se imputa al momento del despacho, que no cambia aunque el envio se edite despues
+		if estadoDeseado == model.Despachado {
+			envioDB.FechaDespacho = time.Now()
+		}
 
-	//Buscamos el envio en la base de datos para conocer el estado real
-	envioDB, err := service.envioRepository.ObtenerEnvioPorId(envio.GetModel())
+		err = transaccion.envioRepository.ActualizarEnvio(envioDB)
+
+		if err != nil {
+			return err
+		}
+
+		//Si el envio pasa a estado Despachado, finaliza el viaje, por lo que hay que hacer otras operaciones
+		if estadoDeseado == model.Despachado {
+			_, err = transaccion.finalizarViaje(dto.NewEnvio(*envioDB))
+		}
+
+		//Si el envio se cancela, sus pedidos vuelven a estar aceptados y se repone el stock
+		if estadoDeseado == model.EnvioCancelado {
+			err = transaccion.cancelarViaje(dto.NewEnvio(*envioDB), usuario)
+		}
+
+		return err
+	})
 
 	if err != nil {
 		return false, err
 	}
 
-	//Validamos el rol del usuario
-	if !service.validarRol(usuario) {
-		return false, errors.New("el usuario no tiene permisos para cambiar el estado del envio")
+	return true, nil
+}
+
+func (service *EnvioService) finalizarViaje(envio *dto.Envio) (bool, error) {
+	//Los pedidos se entregan en las paradas, asi que al finalizar no puede quedar ninguno pendiente
+	pendientes := envio.GetModel().ObtenerPedidosPendientesDeEntrega()
+
+	if len(pendientes) > 0 {
+		return false, errors.New("el envio no puede finalizar: los pedidos " + strings.Join(pendientes, ", ") + " no fueron entregados ni marcados como no entregables")
 	}
 
-	//Si el estado del envio no es compatible con el deseado, devolvemos un error
-	if (estadoDeseado == model.EnRuta && envioDB.Estado != model.ADespachar) || (estadoDeseado == model.Despachado && envioDB.Estado != model.EnRuta) {
-		return false, errors.New("el envio no puede pasar al estado " + fmt.Sprint(estadoDeseado) + " si esta en estado " + fmt.Sprint(envioDB.Estado))
+	return true, nil
+}
+
+func (service *EnvioService) cancelarViaje(envio *dto.Envio, usuario *dto.User) error {
+	//Los pedidos que ya se resolvieron en alguna parada no se tocan
+	for _, idPedido := range envio.GetModel().ObtenerPedidosPendientesDeEntrega() {
+		err := service.devolverPedidoAAceptado(envio, &dto.Pedido{Id: idPedido}, usuario)
+
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	//Actualizamos el envio en la base de datos
-	envioDB.Estado = estadoDeseado
-	err = service.envioRepository.ActualizarEnvio(envioDB)
+// Deshace lo que hizo CrearEnvio con el pedido: lo vuelve a Aceptado y repone el stock que se desconto
+func (service *EnvioService) devolverPedidoAAceptado(envio *dto.Envio, pedidoPorDevolver *dto.Pedido, usuario *dto.User) error {
+	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoPorDevolver.GetModel())
 
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	//Si el envio pasa a estado Despachado, finaliza el viaje, por lo que hay que hacer otras operaciones
-	if estadoDeseado == model.Despachado {
-		service.finalizarViaje(dto.NewEnvio(*envioDB))
+	//Valida que el pedido siga en el envio, es decir, en estado Para enviar
+	if pedido.Estado != model.ParaEnviar {
+		return errors.New("el pedido " + pedidoPorDevolver.Id + " no se encuentra en estado Para Enviar")
 	}
 
-	return true, nil
-}
+	pedido.Estado = model.Aceptado
 
-func (service *EnvioService) finalizarViaje(envio *dto.Envio) (bool, error) {
-	//pasar pedidos a estado enviado
-	err := service.entregarPedidosDeEnvio(envio)
+	err = service.pedidoRepository.ActualizarPedido(pedido)
 
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	return true, nil
-}
+	for _, producto := range pedido.ProductosElegidos {
+		//El stock vuelve a quedar reservado para el pedido aceptado
+		dtoProductoConId := dto.Producto{CodigoProducto: producto.CodigoProducto}
 
-func (service *EnvioService) entregarPedidosDeEnvio(envio *dto.Envio) error {
-	for _, idPedido := range envio.Pedidos {
+		err = service.productoRepository.ReponerStockReservado(dtoProductoConId.GetModel(), producto.Cantidad)
 
-		//Descuenta el stock de los productos
-		err := service.entregarPedido(&dto.Pedido{Id: idPedido})
+		if err != nil {
+			return err
+		}
+
+		err = service.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+			CodigoProducto:    producto.CodigoProducto,
+			Cantidad:          producto.Cantidad,
+			CantidadReservada: producto.Cantidad,
+			Motivo:            model.CancelacionEnvio,
+			IdPedido:          pedidoPorDevolver.Id,
+			IdEnvio:           envio.Id,
+			IdCreador:         usuario.Codigo,
+		})
 
 		if err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
-func (service *EnvioService) entregarPedido(pedidoPorEntregar *dto.Pedido) error {
+// Pasa a Enviado un pedido que se entrego en la parada, validando que esa sea su ciudad de destino
+func (service *EnvioService) entregarPedido(pedidoPorEntregar *dto.Pedido, parada *dto.NuevaParada, usuario *dto.User) error {
+	ciudad := parada.Ciudad
+
 	//Primero buscamos el pedido a entregar
 	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoPorEntregar.GetModel())
 
@@ -497,12 +875,22 @@ func (service *EnvioService) entregarPedido(pedidoPorEntregar *dto.Pedido) error
 
 	//Valida que el pedido esté en estado Para enviar
 	if pedido.Estado != model.ParaEnviar {
-		return nil
+		return errors.New("el pedido " + pedidoPorEntregar.Id + " no se encuentra en estado Para Enviar")
 	}
 
-	//Cambia el estado del pedido a Enviado, si es que no estaba ya en ese estado
-	if pedido.Estado != model.Enviado {
-		pedido.Estado = model.Enviado
+	//Valida que el pedido se este entregando en su ciudad de destino
+	if !strings.EqualFold(strings.TrimSpace(pedido.CiudadDestino), strings.TrimSpace(ciudad)) {
+		return errors.New("el pedido " + pedidoPorEntregar.Id + " tiene como destino " + pedido.CiudadDestino + ", no " + ciudad)
+	}
+
+	pedido.Estado = model.Enviado
+
+	//Dejamos asentado donde y cuando se entrego; el receptor y la firma se cargan despues
+	pedido.Entrega = &model.EntregaPedido{
+		IdEnvio:      parada.IdEnvio,
+		Ciudad:       parada.Ciudad,
+		FechaEntrega: time.Now(),
+		IdCreador:    usuario.Codigo,
 	}
 
 	//Actualiza el pedido en la base de datos
@@ -524,6 +912,20 @@ func (service *EnvioService) descontarStockProductosDeEnvio(envio *dto.Envio) er
 			if err != nil {
 				return err
 			}
+
+			//Dejamos registrada la salida del stock en los movimientos del producto
+			err = service.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+				CodigoProducto:    producto.CodigoProducto,
+				Cantidad:          -producto.Cantidad,
+				CantidadReservada: -producto.Cantidad,
+				Motivo:            model.EnvioDeStock,
+				IdPedido:          idPedido,
+				IdEnvio:           envio.Id,
+				IdCreador:         envio.IdCreador,
+			})
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -533,20 +935,121 @@ func (service *EnvioService) descontarStockProducto(productoPedido dto.ProductoP
 	//Generamos un producto con el codigo del producto del pedido
 	dtoProductoConId := dto.Producto{CodigoProducto: productoPedido.CodigoProducto}
 
-	//Buscamos el producto del que hay que descontar la cantidad
-	producto, err := service.productoRepository.ObtenerProductoPorCodigo(dtoProductoConId.GetModel())
+	//El stock ya estaba reservado desde que se acepto el pedido, ahora se descuenta de verdad
+	return service.productoRepository.DescontarStockReservado(dtoProductoConId.GetModel(), productoPedido.Cantidad)
+}
+
+// Valida que el camion tenga los documentos al dia y que no este en mantenimiento ni tenga uno vencido
+func (service *EnvioService) validarCamionPuedeSalir(camion *model.Camion) error {
+	//Un camion con el seguro, la VTV o algun permiso vencido no puede circular
+	err := camion.ValidarDocumentacion(time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	//Un camion que esta en mantenimiento o que tiene un mantenimiento vencido no puede salir
+	return validarCamionSinMantenimientoPendiente(service.mantenimientoRepository, service.envioRepository, camion.Patente)
+}
+
+func (service *EnvioService) validarDocumentacionCamion(patente string) error {
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{Patente: patente})
+
+	if err != nil {
+		return err
+	}
+
+	if len(camiones) == 0 {
+		return errors.New("no existe el camion")
+	}
+
+	return camiones[0].ValidarDocumentacion(time.Now())
+}
+
+// Valida que el usuario sea un conductor activo y que tenga asignado el camion en este momento
+func (service *EnvioService) validarConductorAsignado(patente string, usuario *dto.User) error {
+	filtroConductor := utils.FiltroConductor{CodigoUsuario: usuario.Codigo, EstaActivo: true, FiltrarPorEstaActivo: true}
+
+	conductores, err := service.conductorRepository.ObtenerConductores(filtroConductor)
+
+	if err != nil {
+		return err
+	}
+
+	if len(conductores) == 0 {
+		return errors.New("el usuario no esta registrado como conductor, o bien ha sido dado de baja")
+	}
+
+	filtroAsignacion := utils.FiltroAsignacionCamion{PatenteCamion: patente, CodigoConductor: usuario.Codigo, VigenteEn: time.Now()}
+
+	asignaciones, err := service.conductorRepository.ObtenerAsignaciones(filtroAsignacion)
+
+	if err != nil {
+		return err
+	}
+
+	if len(asignaciones) == 0 {
+		return errors.New("el conductor no tiene asignado el camion " + patente)
+	}
+
+	return nil
+}
+
+// Devuelve el codigo del conductor activo que tiene asignado el camion en la fecha, o "" si no tiene ninguno
+func (service *EnvioService) obtenerConductorAsignado(patente string, fecha time.Time) (string, error) {
+	asignaciones, err := service.conductorRepository.ObtenerAsignaciones(utils.FiltroAsignacionCamion{PatenteCamion: patente, VigenteEn: fecha})
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, asignacion := range asignaciones {
+		filtroConductor := utils.FiltroConductor{CodigoUsuario: asignacion.CodigoConductor, EstaActivo: true, FiltrarPorEstaActivo: true}
+
+		conductores, err := service.conductorRepository.ObtenerConductores(filtroConductor)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(conductores) > 0 {
+			return asignacion.CodigoConductor, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Valida que el usuario sea el conductor que lleva el envio, aunque su asignacion al camion haya terminado durante el viaje.
+// Los envios que no indican el conductor se autorizan con la asignacion del camion vigente cuando se crearon
+func validarConductorDelEnvio(conductorRepository repositories.ConductorRepositoryInterface, envio *model.Envio, usuario *dto.User) error {
+	if envio.IdConductor != "" {
+		if envio.IdConductor != usuario.Codigo {
+			return errors.New("el usuario no es el conductor del envio")
+		}
+		return nil
+	}
+
+	filtroAsignacion := utils.FiltroAsignacionCamion{PatenteCamion: envio.PatenteCamion, CodigoConductor: usuario.Codigo, VigenteEn: envio.FechaCreacion}
+
+	asignaciones, err := conductorRepository.ObtenerAsignaciones(filtroAsignacion)
 
 	if err != nil {
 		return err
 	}
 
-	//Modificamos el stock
-	producto.StockActual = producto.StockActual - productoPedido.Cantidad
+	if len(asignaciones) == 0 {
+		return errors.New("el usuario no es el conductor del envio")
+	}
 
-	//Actualizamos la base de datos
-	return service.productoRepository.ActualizarProducto(producto)
+	return nil
 }
 
 func (service *EnvioService) validarRol(usuario *dto.User) bool {
 	return usuario.Rol == string(utils.Conductor)
 }
+
+// La consolidacion reparte los pedidos entre los camiones de todos los conductores, asi que la hace un administrador
+func (service *EnvioService) validarRolPlanificacion(usuario *dto.User) bool {
+	return usuario.Rol == string(utils.Administrador)
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/model"
+	"TPIntegrador/repositories"
+	"TPIntegrador/utils"
+	"errors"
+	"strings"
+	"time"
+)
+
+type MantenimientoServiceInterface interface {
+	ObtenerMantenimientosCamion(*dto.Camion) (*dto.MantenimientosCamion, error)
+	ObtenerMantenimientosProximos(dias int) ([]*dto.MantenimientosCamion, error)
+	ProgramarMantenimiento(*dto.Mantenimiento, *dto.User) error
+	CambiarEstadoMantenimiento(*dto.Mantenimiento, *dto.User) error
+}
+
+type MantenimientoService struct {
+	mantenimientoRepository repositories.MantenimientoRepositoryInterface
+	camionRepository        repositories.CamionRepositoryInterface
+	envioRepository         repositories.EnvioRepositoryInterface
+}
+
+func NewMantenimientoService(mantenimientoRepository repositories.MantenimientoRepositoryInterface, camionRepository repositories.CamionRepositoryInterface, envioRepository repositories.EnvioRepositoryInterface) *MantenimientoService {
+	return &MantenimientoService{
+		mantenimientoRepository: mantenimientoRepository,
+		camionRepository:        camionRepository,
+		envioRepository:         envioRepository,
+	}
+}
+
+// Devuelve todos los mantenimientos del camion, programados y realizados, con los km que lleva acumulados
+func (service *MantenimientoService) ObtenerMantenimientosCamion(camion *dto.Camion) (*dto.MantenimientosCamion, error) {
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{Patente: camion.Patente})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(camiones) == 0 {
+		return nil, errors.New("no existe el camion")
+	}
+
+	mantenimientos, err := service.mantenimientoRepository.ObtenerMantenimientos(utils.FiltroMantenimiento{PatenteCamion: camion.Patente})
+
+	if err != nil {
+		return nil, err
+	}
+
+	kmAcumulados, err := obtenerKmAcumuladosCamion(service.envioRepository, camion.Patente)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newMantenimientosCamion(camion.Patente, kmAcumulados, mantenimientos), nil
+}
+
+// Devuelve los camiones activos que estan en mantenimiento o que tienen un mantenimiento que vence en los proximos dias.
+// Los mantenimientos por km se informan cuando el camion ya llego a los km programados
+func (service *MantenimientoService) ObtenerMantenimientosProximos(dias int) ([]*dto.MantenimientosCamion, error) {
+	if dias < 0 {
+		return nil, errors.New("la cantidad de dias no puede ser negativa")
+	}
+
+	fechaLimite := time.Now().AddDate(0, 0, dias)
+
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{EstaActivo: true, FiltrarPorEstaActivo: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Buscamos una sola vez los mantenimientos pendientes de todos los camiones y los agrupamos por patente
+	filtro := utils.FiltroMantenimiento{Estados: []model.EstadoMantenimiento{model.MantenimientoProgramado, model.MantenimientoEnCurso}}
+
+	pendientes, err := service.mantenimientoRepository.ObtenerMantenimientos(filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pendientesPorPatente := make(map[string][]*model.Mantenimiento)
+	for _, mantenimiento := range pendientes {
+		pendientesPorPatente[mantenimiento.PatenteCamion] = append(pendientesPorPatente[mantenimiento.PatenteCamion], mantenimiento)
+	}
+
+	//Inicializo la lista por si no hay ningun camion con mantenimientos proximos
+	proximos := make([]*dto.MantenimientosCamion, 0)
+
+	for _, camion := range camiones {
+		mantenimientosCamion := pendientesPorPatente[camion.Patente]
+
+		if len(mantenimientosCamion) == 0 {
+			continue
+		}
+
+		kmAcumulados, err := obtenerKmAcumuladosCamion(service.envioRepository, camion.Patente)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mantenimientosProximos := make([]*model.Mantenimiento, 0)
+		for _, mantenimiento := range mantenimientosCamion {
+			if mantenimiento.BloqueaCamion(fechaLimite, kmAcumulados) {
+				mantenimientosProximos = append(mantenimientosProximos, mantenimiento)
+			}
+		}
+
+		if len(mantenimientosProximos) > 0 {
+			proximos = append(proximos, newMantenimientosCamion(camion.Patente, kmAcumulados, mantenimientosProximos))
+		}
+	}
+
+	return proximos, nil
+}
+
+// Programa un mantenimiento para una fecha y/o para una cantidad de km acumulados.
+// Tambien permite registrar un mantenimiento que ya se realizo, indicando el estado Realizado
+func (service *MantenimientoService) ProgramarMantenimiento(mantenimiento *dto.Mantenimiento, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para programar mantenimientos")
+	}
+
+	camiones, err := service.camionRepository.ObtenerCamiones(utils.FiltroCamion{Patente: mantenimiento.PatenteCamion, EstaActivo: true, FiltrarPorEstaActivo: true})
+
+	if err != nil {
+		return err
+	}
+
+	if len(camiones) == 0 {
+		return errors.New("no existe el camion, o bien ha sido dado de baja")
+	}
+
+	if strings.TrimSpace(mantenimiento.Descripcion) == "" {
+		return errors.New("el mantenimiento debe tener una descripcion")
+	}
+
+	if mantenimiento.KmProgramados < 0 || mantenimiento.KmAlRealizar < 0 || mantenimiento.Costo < 0 {
+		return errors.New("los km y el costo del mantenimiento no pueden ser negativos")
+	}
+
+	//Si no se indica el estado, el mantenimiento queda programado
+	if mantenimiento.Estado == "" {
+		mantenimiento.Estado = model.MantenimientoProgramado
+	}
+
+	switch mantenimiento.Estado {
+	case model.MantenimientoProgramado:
+		if mantenimiento.FechaProgramada == nil && mantenimiento.KmProgramados == 0 {
+			return errors.New("el mantenimiento debe programarse para una fecha o para una cantidad de km")
+		}
+	case model.MantenimientoRealizado:
+		err = service.completarRealizacion(mantenimiento)
+
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("el mantenimiento solo se puede crear en estado Programado o Realizado")
+	}
+
+	//Le agregamos el codigo del usuario que lo creo
+	mantenimiento.IdCreador = usuario.Codigo
+
+	mantenimientoDB := mantenimiento.GetModel()
+
+	err = service.mantenimientoRepository.CrearMantenimiento(mantenimientoDB)
+
+	if err != nil {
+		return err
+	}
+
+	mantenimiento.Id = utils.GetStringIDFromObjectID(mantenimientoDB.ObjectId)
+
+	return nil
+}
+
+// Pasa el mantenimiento a En Curso, Realizado o Cancelado. Al realizarlo se registran la fecha, los km del camion,
+// el costo y las observaciones que mande el cliente
+func (service *MantenimientoService) CambiarEstadoMantenimiento(mantenimiento *dto.Mantenimiento, usuario *dto.User) error {
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para modificar mantenimientos")
+	}
+
+	if !model.EsUnEstadoMantenimientoValido(mantenimiento.Estado) {
+		return errors.New("el estado ingresado no es válido")
+	}
+
+	mantenimientoDB, err := service.mantenimientoRepository.ObtenerMantenimientoPorId(mantenimiento.GetModel())
+
+	if err != nil {
+		return err
+	}
+
+	//El mantenimiento tiene que ser del camion de la ruta
+	if mantenimientoDB.PatenteCamion != mantenimiento.PatenteCamion {
+		return errors.New("el mantenimiento no pertenece al camion " + mantenimiento.PatenteCamion)
+	}
+
+	//Si el cliente indico la version que leyo, el mantenimiento no puede haber cambiado desde entonces
+	err = validarVersion(mantenimiento.Version, mantenimientoDB.Version)
+
+	if err != nil {
+		return err
+	}
+
+	if !model.PuedeCambiarEstadoMantenimiento(mantenimientoDB.Estado, mantenimiento.Estado) {
+		return errors.New("el mantenimiento no puede pasar al estado " + string(mantenimiento.Estado) + " si esta en estado " + string(mantenimientoDB.Estado))
+	}
+
+	switch mantenimiento.Estado {
+	case model.MantenimientoEnCurso:
+		//Un camion que esta haciendo un envio no puede entrar al taller
+		tieneEnvios, err := camionTieneEnviosEnCurso(service.envioRepository, mantenimientoDB.PatenteCamion)
+
+		if err != nil {
+			return err
+		}
+
+		if tieneEnvios {
+			return errors.New("el camion tiene envios actualmente")
+		}
+
+		mantenimientoDB.FechaInicio = time.Now()
+	case model.MantenimientoRealizado:
+		err = service.completarRealizacion(mantenimiento)
+
+		if err != nil {
+			return err
+		}
+
+		mantenimientoDB.FechaRealizacion = *mantenimiento.FechaRealizacion
+		mantenimientoDB.KmAlRealizar = mantenimiento.KmAlRealizar
+		mantenimientoDB.Costo = mantenimiento.Costo
+	}
+
+	if mantenimiento.Observaciones != "" {
+		mantenimientoDB.Observaciones = mantenimiento.Observaciones
+	}
+
+	mantenimientoDB.Estado = mantenimiento.Estado
+
+	return service.mantenimientoRepository.ActualizarMantenimiento(mantenimientoDB)
+}
+
+// Si no se indican, la fecha de realizacion es la actual y los km son los que el camion lleva acumulados
+func (service *MantenimientoService) completarRealizacion(mantenimiento *dto.Mantenimiento) error {
+	if mantenimiento.Costo < 0 || mantenimiento.KmAlRealizar < 0 {
+		return errors.New("los km y el costo del mantenimiento no pueden ser negativos")
+	}
+
+	if mantenimiento.FechaRealizacion == nil {
+		ahora := time.Now()
+		mantenimiento.FechaRealizacion = &ahora
+	}
+
+	if mantenimiento.KmAlRealizar == 0 {
+		kmAcumulados, err := obtenerKmAcumuladosCamion(service.envioRepository, mantenimiento.PatenteCamion)
+
+		if err != nil {
+			return err
+		}
+
+		mantenimiento.KmAlRealizar = kmAcumulados
+	}
+
+	return nil
+}
+
+func (service *MantenimientoService) validarRol(usuario *dto.User) bool {
+	return usuario.Rol == string(utils.Administrador)
+}
+
+// Suma los km de las paradas de todos los envios del camion que ya finalizaron
+func obtenerKmAcumuladosCamion(envioRepository repositories.EnvioRepositoryInterface, patente string) (int, error) {
+	envios, err := envioRepository.ObtenerEnvios(&utils.FiltroEnvio{PatenteCamion: patente, Estado: model.Despachado})
+
+	if err != nil {
+		return 0, err
+	}
+
+	kmAcumulados := 0
+	for _, envio := range envios {
+		kmAcumulados += envio.ObtenerKmRecorridos()
+	}
+
+	return kmAcumulados, nil
+}
+
+// Devuelve un error si el camion esta en mantenimiento o tiene un mantenimiento vencido
+func validarCamionSinMantenimientoPendiente(mantenimientoRepository repositories.MantenimientoRepositoryInterface, envioRepository repositories.EnvioRepositoryInterface, patente string) error {
+	mantenimiento, err := obtenerMantenimientoQueBloqueaCamion(mantenimientoRepository, envioRepository, patente)
+
+	if err != nil {
+		return err
+	}
+
+	if mantenimiento == nil {
+		return nil
+	}
+
+	return &model.ErrorMantenimientoCamion{Patente: patente, Mantenimiento: mantenimiento}
+}
+
+// Devuelve el mantenimiento en curso o vencido del camion, o nil si el camion se puede usar
+func obtenerMantenimientoQueBloqueaCamion(mantenimientoRepository repositories.MantenimientoRepositoryInterface, envioRepository repositories.EnvioRepositoryInterface, patente string) (*model.Mantenimiento, error) {
+	filtro := utils.FiltroMantenimiento{PatenteCamion: patente, Estados: []model.EstadoMantenimiento{model.MantenimientoProgramado, model.MantenimientoEnCurso}}
+
+	pendientes, err := mantenimientoRepository.ObtenerMantenimientos(filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Si no hay nada pendiente no hace falta calcular los km
+	if len(pendientes) == 0 {
+		return nil, nil
+	}
+
+	kmAcumulados, err := obtenerKmAcumuladosCamion(envioRepository, patente)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+
+	for _, mantenimiento := range pendientes {
+		if mantenimiento.BloqueaCamion(ahora, kmAcumulados) {
+			return mantenimiento, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newMantenimientosCamion(patente string, kmAcumulados int, mantenimientos []*model.Mantenimiento) *dto.MantenimientosCamion {
+	mantenimientosCamion := &dto.MantenimientosCamion{
+		PatenteCamion:  patente,
+		KmAcumulados:   kmAcumulados,
+		Mantenimientos: make([]*dto.Mantenimiento, 0),
+	}
+
+	for _, mantenimiento := range mantenimientos {
+		mantenimientosCamion.Mantenimientos = append(mantenimientosCamion.Mantenimientos, dto.NewMantenimiento(mantenimiento, kmAcumulados))
+	}
+
+	return mantenimientosCamion
+}
@@ -6,59 +6,152 @@ import (
 	"TPIntegrador/repositories"
 	"TPIntegrador/utils"
 	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Tamaño maximo de la firma o foto de una entrega
+const TamañoMaximoArchivoEntrega = 5 << 20
+
 type PedidoService struct {
-	pedidoRepository   repositories.PedidoRepositoryInterface
-	envioRepository    repositories.EnvioRepositoryInterface
-	productoRepository repositories.ProductoRepositoryInterface
+	pedidoRepository          repositories.PedidoRepositoryInterface
+	envioRepository           repositories.EnvioRepositoryInterface
+	productoRepository        repositories.ProductoRepositoryInterface
+	movimientoStockRepository repositories.MovimientoStockRepositoryInterface
+	archivoRepository         repositories.ArchivoRepositoryInterface
+	conductorRepository       repositories.ConductorRepositoryInterface
+	unidadDeTrabajo           repositories.UnidadDeTrabajoInterface
 }
 
 type PedidoServiceInterface interface {
-	CrearPedido(*dto.Pedido, *dto.User) error
-	ObtenerPedidos(utils.FiltroPedido) ([]*dto.Pedido, error)
+	CrearPedido(*dto.NuevoPedido, *dto.User) error
+	ObtenerPedidos(utils.FiltroPedido) ([]*dto.Pedido, *dto.Pagina, error)
 	ObtenerPedidoPorId(*dto.Pedido) (*dto.Pedido, error)
+	EditarPedido(*dto.Pedido, *dto.NuevoPedido, *dto.User) error
+	ObtenerRevisionesPedido(*dto.Pedido) ([]dto.RevisionPedido, error)
+	ObtenerRevisionPedido(*dto.Pedido, int) (*dto.RevisionPedido, error)
 	ObtenerCantidadPedidosPorEstado() ([]utils.CantidadEstado, error)
 	AceptarPedido(*dto.Pedido, *dto.User) error
 	CancelarPedido(*dto.Pedido, *dto.User) error
+	RegistrarEntrega(*dto.Pedido, *dto.EntregaPedido, *dto.Archivo, *dto.User) error
+	RegistrarDevolucion(*dto.Pedido, *dto.NuevaDevolucion, *dto.User) error
+	ReevaluarPedidosHijos(*dto.Producto, *dto.User) error
+	ObtenerEntrega(*dto.Pedido) (*dto.EntregaPedido, error)
+	ObtenerArchivoEntrega(*dto.Pedido) (*dto.Archivo, error)
+}
+
+func NewPedidoService(pedidoRepository repositories.PedidoRepositoryInterface, envioRepository repositories.EnvioRepositoryInterface, productoRepository repositories.ProductoRepositoryInterface, movimientoStockRepository repositories.MovimientoStockRepositoryInterface, archivoRepository repositories.ArchivoRepositoryInterface, conductorRepository repositories.ConductorRepositoryInterface, unidadDeTrabajo repositories.UnidadDeTrabajoInterface) *PedidoService {
+	return &PedidoService{
+		pedidoRepository:          pedidoRepository,
+		envioRepository:           envioRepository,
+		productoRepository:        productoRepository,
+		movimientoStockRepository: movimientoStockRepository,
+		archivoRepository:         archivoRepository,
+		conductorRepository:       conductorRepository,
+		unidadDeTrabajo:           unidadDeTrabajo,
+	}
 }
 
-func NewPedidoService(pedidoRepository repositories.PedidoRepositoryInterface, envioRepository repositories.EnvioRepositoryInterface, productoRepository repositories.ProductoRepositoryInterface) *PedidoService {
+// Devuelve una copia del service que trabaja con los repositorios de una transaccion
+func (service *PedidoService) conRepositorios(repositorios *repositories.Repositorios) *PedidoService {
 	return &PedidoService{
-		pedidoRepository:   pedidoRepository,
-		envioRepository:    envioRepository,
-		productoRepository: productoRepository,
+		pedidoRepository:          repositorios.Pedido,
+		envioRepository:           repositorios.Envio,
+		productoRepository:        repositorios.Producto,
+		movimientoStockRepository: repositorios.MovimientoStock,
+		archivoRepository:         service.archivoRepository,
+		conductorRepository:       repositorios.Conductor,
+		unidadDeTrabajo:           service.unidadDeTrabajo,
 	}
 }
 
-func (service *PedidoService) CrearPedido(pedido *dto.Pedido, usuario *dto.User) error {
+func (service *PedidoService) CrearPedido(nuevoPedido *dto.NuevoPedido, usuario *dto.User) error {
 	//Validamos el rol del usuario
 	if !service.validarRol(usuario) {
 		return errors.New("el usuario no tiene permisos para crear un pedido")
 	}
 
 	//Aseguramos que el pedido tenga productos
-	if len(pedido.ProductosElegidos) == 0 {
+	if len(nuevoPedido.ProductosElegidos) == 0 {
 		return errors.New("el pedido debe tener al menos un producto")
 	}
 
 	//Aseguramos que el pedido tenga destino
-	if pedido.CiudadDestino == "" {
+	if nuevoPedido.CiudadDestino == "" {
 		return errors.New("el pedido debe tener un destino")
 	}
 
-	//Obligamos a que el estado del pedido sea Pendiente
-	if pedido.Estado != model.Pendiente {
-		pedido.Estado = model.Pendiente
+	//Los datos de cada producto se toman de la base de datos, no del cliente
+	productosElegidos, err := service.armarProductosPedido(nuevoPedido.ProductosElegidos, nil)
+
+	if err != nil {
+		return err
 	}
 
-	//Le agregamos el codigo del usuario que lo creo
-	pedido.IdCreador = usuario.Codigo
+	pedido := dto.Pedido{
+		ProductosElegidos: productosElegidos,
+		CiudadDestino:     nuevoPedido.CiudadDestino,
+		//Obligamos a que el estado del pedido sea Pendiente
+		Estado: model.Pendiente,
+		//Le agregamos el codigo del usuario que lo creo
+		IdCreador: usuario.Codigo,
+	}
 
 	return service.pedidoRepository.CrearPedido(pedido.GetModel())
 }
 
-func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([]*dto.Pedido, error) {
+// Arma las lineas del pedido guardando el precio, peso y nombre que tiene cada producto en este momento.
+// Los productos que ya estaban en el pedido conservan los datos que se guardaron cuando se agregaron
+func (service *PedidoService) armarProductosPedido(nuevosProductos []dto.NuevoProductoPedido, productosActuales []model.ProductoPedido) ([]dto.ProductoPedido, error) {
+	productosElegidos := make([]dto.ProductoPedido, 0)
+
+	productosPorCodigo := make(map[string]model.ProductoPedido)
+	for _, productoActual := range productosActuales {
+		productosPorCodigo[productoActual.CodigoProducto] = productoActual
+	}
+
+	//Guardamos los codigos ya cargados para no repetir productos
+	codigosCargados := make(map[string]bool)
+
+	for _, nuevoProducto := range nuevosProductos {
+		if nuevoProducto.Cantidad <= 0 {
+			return nil, errors.New("la cantidad del producto " + nuevoProducto.CodigoProducto + " debe ser mayor a cero")
+		}
+
+		if codigosCargados[nuevoProducto.CodigoProducto] {
+			return nil, errors.New("el producto " + nuevoProducto.CodigoProducto + " esta repetido en el pedido")
+		}
+
+		codigosCargados[nuevoProducto.CodigoProducto] = true
+
+		if productoActual, ok := productosPorCodigo[nuevoProducto.CodigoProducto]; ok {
+			productoActual.Cantidad = nuevoProducto.Cantidad
+			productosElegidos = append(productosElegidos, *dto.NewProductoPedido(&productoActual))
+			continue
+		}
+
+		//Armo un objeto producto con el ID para buscar en la base de datos
+		dtoProductoParaBuscar := dto.Producto{CodigoProducto: nuevoProducto.CodigoProducto}
+
+		producto, err := service.productoRepository.ObtenerProductoPorCodigo(dtoProductoParaBuscar.GetModel())
+
+		//Si el producto no existe o fue eliminado, rechazamos el pedido
+		if err != nil {
+			return nil, errors.New("el producto " + nuevoProducto.CodigoProducto + " no existe")
+		}
+
+		productosElegidos = append(productosElegidos, *dto.NewProductoPedidoFromProducto(producto, nuevoProducto.Cantidad))
+	}
+
+	return productosElegidos, nil
+}
+
+// Devuelve los pedidos del filtro, en el orden y con la paginacion que indica. La pagina lleva los mismos pedidos,
+// junto con el cursor de la siguiente y el total
+func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([]*dto.Pedido, *dto.Pagina, error) {
 	//Obtenemos el id del envio, si es que se filtró por el mismo
 	idEnvio := filtroPedido.IdEnvio
 
@@ -67,7 +160,7 @@ func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([
 
 	//Valida que la fecha desde sea menor a la fecha hasta, solo si se pasaron ambas para filtrar
 	if fechaDesde.Year() != 1 && fechaHasta.Year() != 1 && fechaDesde.After(fechaHasta) {
-		return nil, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
+		return nil, nil, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
 	}
 
 	var idPedidos []string
@@ -81,7 +174,7 @@ func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([
 		envio, err := service.envioRepository.ObtenerEnvioPorId(envioParaBuscar.GetModel())
 
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		//Si el envio existe, obtenemos la lista de pedidos del mismo
@@ -89,7 +182,7 @@ func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([
 
 		if idPedidos == nil {
 			//Si no hay pedidos, devolvemos un array vacio
-			return []*dto.Pedido{}, nil
+			return []*dto.Pedido{}, dto.NewPagina([]*dto.Pedido{}, &model.Pagina{}), nil
 		}
 	}
 
@@ -98,12 +191,12 @@ func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([
 
 	//Validamos el estado del pedido
 	if !model.EsUnEstadoPedidoValido(filtroPedido.Estado) && filtroPedido.Estado != "" {
-		return nil, errors.New("el estado ingresado para filtrar no es válido")
+		return nil, nil, errors.New("el estado ingresado para filtrar no es válido")
 	}
 
-	pedidos, err := service.pedidoRepository.ObtenerPedidos(&filtroPedido)
+	pedidos, pagina, err := service.pedidoRepository.ObtenerPaginaPedidos(&filtroPedido)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	//Inicializamos el array de pedidosDTO por si esta vacio
@@ -114,7 +207,7 @@ func (service *PedidoService) ObtenerPedidos(filtroPedido utils.FiltroPedido) ([
 		pedidosDTO = append(pedidosDTO, pedidoDTO)
 	}
 
-	return pedidosDTO, nil
+	return pedidosDTO, dto.NewPagina(pedidosDTO, pagina), nil
 }
 
 func (service *PedidoService) ObtenerPedidoPorId(pedidoConId *dto.Pedido) (*dto.Pedido, error) {
@@ -132,38 +225,300 @@ func (service *PedidoService) ObtenerPedidoPorId(pedidoConId *dto.Pedido) (*dto.
 	}
 }
 
-func (service *PedidoService) AceptarPedido(pedidoPorAceptar *dto.Pedido, usuario *dto.User) error {
+// Reemplaza los productos y el destino de un pedido pendiente. El estado anterior queda guardado como una revision
+func (service *PedidoService) EditarPedido(pedidoConId *dto.Pedido, edicion *dto.NuevoPedido, usuario *dto.User) error {
 	//Validamos el rol del usuario
 	if !service.validarRol(usuario) {
-		return errors.New("el usuario no tiene permisos para aceptar el pedido")
+		return errors.New("el usuario no tiene permisos para editar un pedido")
+	}
+
+	if len(edicion.ProductosElegidos) == 0 {
+		return errors.New("el pedido debe tener al menos un producto")
 	}
 
-	//Primero buscamos el pedido a aceptar
-	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoPorAceptar.GetModel())
+	if edicion.CiudadDestino == "" {
+		return errors.New("el pedido debe tener un destino")
+	}
+
+	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoConId.GetModel())
 
 	if err != nil {
 		return err
 	}
 
-	//Valida que el pedido esté en estado Pendiente
+	if pedido == nil {
+		return errors.New("el pedido no existe")
+	}
+
+	//Una vez aceptado, el pedido ya tiene stock reservado y no se puede cambiar
 	if pedido.Estado != model.Pendiente {
-		return errors.New("el pedido no se encuentra en estado Pendiente")
+		return errors.New("solo se pueden editar pedidos en estado Pendiente")
+	}
+
+	//Si el cliente indico la version que leyo, el pedido no puede haber cambiado desde entonces
+	err = validarVersion(pedidoConId.Version, pedido.Version)
+
+	if err != nil {
+		return err
+	}
+
+	productosElegidos, err := service.armarProductosPedido(edicion.ProductosElegidos, pedido.ProductosElegidos)
+
+	if err != nil {
+		return err
+	}
+
+	revisiones := pedido.ObtenerRevisiones()
+
+	pedido.ProductosElegidos = nil
+	for _, productoElegido := range productosElegidos {
+		pedido.ProductosElegidos = append(pedido.ProductosElegidos, productoElegido.GetModel())
+	}
+	pedido.CiudadDestino = edicion.CiudadDestino
+	pedido.Revisiones = append(revisiones, model.RevisionPedido{
+		Numero:            len(revisiones) + 1,
+		ProductosElegidos: pedido.ProductosElegidos,
+		CiudadDestino:     pedido.CiudadDestino,
+		FechaCreacion:     time.Now(),
+		IdCreador:         usuario.Codigo,
+	})
+
+	return service.pedidoRepository.EditarPedido(pedido)
+}
+
+func (service *PedidoService) ObtenerRevisionesPedido(pedidoConId *dto.Pedido) ([]dto.RevisionPedido, error) {
+	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoConId.GetModel())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pedido == nil {
+		return nil, errors.New("el pedido no existe")
 	}
 
-	//Verifica que haya stock disponible para aceptar el pedido
-	if !service.hayStockDisponiblePedido(pedido) {
+	return dto.NewRevisionesPedido(pedido.ObtenerRevisiones()), nil
+}
+
+func (service *PedidoService) ObtenerRevisionPedido(pedidoConId *dto.Pedido, numero int) (*dto.RevisionPedido, error) {
+	revisiones, err := service.ObtenerRevisionesPedido(pedidoConId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, revision := range revisiones {
+		if revision.Numero == numero {
+			return &revision, nil
+		}
+	}
+
+	return nil, errors.New("el pedido no tiene la revision " + strconv.Itoa(numero))
+}
+
+func (service *PedidoService) AceptarPedido(pedidoPorAceptar *dto.Pedido, usuario *dto.User) error {
+	//Validamos el rol del usuario
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para aceptar el pedido")
+	}
+
+	//La reserva del stock, el cambio de estado y el pedido hijo se guardan juntos
+	return service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		//Leemos el pedido dentro de la transaccion, para que si se reintenta se parta del pedido tal como esta guardado
+		pedido, err := transaccion.pedidoRepository.ObtenerPedidoPorId(pedidoPorAceptar.GetModel())
+
+		if err != nil {
+			return err
+		}
+
+		if pedido == nil {
+			return errors.New("no se encontró el pedido")
+		}
+
+		//Valida que el pedido esté en estado Pendiente
+		if pedido.Estado != model.Pendiente {
+			return errors.New("el pedido no se encuentra en estado Pendiente")
+		}
+
+		//Si el cliente indico la version que leyo, el pedido no puede haber cambiado desde entonces
+		err = validarVersion(pedidoPorAceptar.Version, pedido.Version)
+
+		if err != nil {
+			return err
+		}
+
+		return transaccion.aceptarPedidoParcialmente(pedido, usuario)
+	})
+}
+
+// Acepta los productos del pedido que tienen stock. Los que no tienen stock se pasan a un pedido hijo en estado Pendiente,
+// que se vuelve a evaluar cuando se repone el stock
+func (service *PedidoService) aceptarPedidoParcialmente(pedido *model.Pedido, usuario *dto.User) error {
+	var productosAceptados []model.ProductoPedido
+	var productosSinStock []model.ProductoPedido
+
+	for _, productoPedido := range pedido.ProductosElegidos {
+		err := service.reservarStockProductoPedido(pedido, productoPedido, usuario)
+
+		if errors.Is(err, repositories.ErrSinStockDisponible) {
+			productosSinStock = append(productosSinStock, productoPedido)
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		productosAceptados = append(productosAceptados, productoPedido)
+	}
+
+	//Si no hay stock de ningun producto, el pedido sigue pendiente
+	if len(productosAceptados) == 0 {
 		return errors.New("no hay stock disponible para aceptar el pedido")
 	}
 
-	//Cambia el estado del pedido a Aceptado, si es que no estaba ya en ese estado
-	if pedido.Estado != model.Aceptado {
-		pedido.Estado = model.Aceptado
+	if len(productosSinStock) > 0 {
+		pedidoHijo := &model.Pedido{
+			ProductosElegidos: productosSinStock,
+			CiudadDestino:     pedido.CiudadDestino,
+			Estado:            model.Pendiente,
+			IdCreador:         pedido.IdCreador,
+			IdPedidoPadre:     utils.GetStringIDFromObjectID(pedido.ObjectId),
+		}
+
+		err := service.pedidoRepository.CrearPedido(pedidoHijo)
+
+		if err != nil {
+			return err
+		}
+
+		//El pedido original se queda solo con los productos que se pudieron aceptar
+		pedido.ProductosElegidos = productosAceptados
+		pedido.IdPedidoHijo = utils.GetStringIDFromObjectID(pedidoHijo.ObjectId)
 	}
 
+	pedido.Estado = model.Aceptado
+
 	//Actualiza el pedido en la base de datos
 	return service.pedidoRepository.ActualizarPedido(pedido)
 }
 
+// Vuelve a intentar aceptar los pedidos hijos pendientes que tienen el producto, empezando por los mas antiguos.
+// Un pedido hijo solo se acepta si hay stock de todos sus productos
+func (service *PedidoService) ReevaluarPedidosHijos(productoConCodigo *dto.Producto, usuario *dto.User) error {
+	filtro := utils.FiltroPedido{
+		CodigoProducto:   productoConCodigo.CodigoProducto,
+		Estado:           model.Pendiente,
+		SoloPedidosHijos: true,
+	}
+
+	pedidos, err := service.pedidoRepository.ObtenerPedidos(&filtro)
+
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(pedidos, func(i, j int) bool {
+		return pedidos[i].FechaCreacion.Before(pedidos[j].FechaCreacion)
+	})
+
+	for _, pedido := range pedidos {
+		err := service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+			transaccion := service.conRepositorios(repositorios)
+
+			//Lo volvemos a leer dentro de la transaccion, por si cambio mientras tanto
+			pedidoDB, err := transaccion.pedidoRepository.ObtenerPedidoPorId(pedido)
+
+			if err != nil {
+				return err
+			}
+
+			if pedidoDB == nil || pedidoDB.Estado != model.Pendiente || !transaccion.hayStockDisponiblePedido(pedidoDB) {
+				return nil
+			}
+
+			err = transaccion.reservarStockPedido(pedidoDB, usuario)
+
+			if err != nil {
+				return err
+			}
+
+			pedidoDB.Estado = model.Aceptado
+
+			return transaccion.pedidoRepository.ActualizarPedido(pedidoDB)
+		})
+
+		//Si otro pedido se llevo el stock mientras tanto, este queda pendiente
+		if err != nil && !errors.Is(err, repositories.ErrSinStockDisponible) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *PedidoService) reservarStockPedido(pedido *model.Pedido, usuario *dto.User) error {
+	for _, productoPedido := range pedido.ProductosElegidos {
+		err := service.reservarStockProductoPedido(pedido, productoPedido, usuario)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *PedidoService) reservarStockProductoPedido(pedido *model.Pedido, productoPedido model.ProductoPedido, usuario *dto.User) error {
+	//Armo un objeto producto con el ID para reservar
+	dtoProductoParaReservar := dto.Producto{CodigoProducto: productoPedido.CodigoProducto}
+
+	err := service.productoRepository.ReservarStock(dtoProductoParaReservar.GetModel(), productoPedido.Cantidad)
+
+	if err != nil {
+		return err
+	}
+
+	//Dejamos registrada la reserva en los movimientos del producto
+	return service.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+		CodigoProducto:    productoPedido.CodigoProducto,
+		CantidadReservada: productoPedido.Cantidad,
+		Motivo:            model.Reserva,
+		IdPedido:          utils.GetStringIDFromObjectID(pedido.ObjectId),
+		IdCreador:         usuario.Codigo,
+	})
+}
+
+func (service *PedidoService) liberarStockPedido(pedido *model.Pedido, usuario *dto.User) error {
+	for _, productoPedido := range pedido.ProductosElegidos {
+		//Armo un objeto producto con el ID para liberar la reserva
+		dtoProductoParaLiberar := dto.Producto{CodigoProducto: productoPedido.CodigoProducto}
+
+		err := service.productoRepository.LiberarStockReservado(dtoProductoParaLiberar.GetModel(), productoPedido.Cantidad)
+
+		if err != nil {
+			return err
+		}
+
+		//Dejamos registrada la liberacion en los movimientos del producto
+		err = service.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+			CodigoProducto:    productoPedido.CodigoProducto,
+			CantidadReservada: -productoPedido.Cantidad,
+			Motivo:            model.LiberacionReserva,
+			IdPedido:          utils.GetStringIDFromObjectID(pedido.ObjectId),
+			IdCreador:         usuario.Codigo,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (service *PedidoService) hayStockDisponiblePedido(pedido *model.Pedido) bool {
 	//Busco los productos del pedido
 	productosPedido := pedido.ProductosElegidos
@@ -181,8 +536,8 @@ func (service *PedidoService) hayStockDisponiblePedido(pedido *model.Pedido) boo
 			return false
 		}
 
-		//Verifico que haya stock disponible para el producto
-		if productoPedido.Cantidad > producto.StockActual {
+		//Verifico que haya stock disponible para el producto, sin contar el reservado por otros pedidos
+		if productoPedido.Cantidad > producto.ObtenerStockDisponible() {
 			return false
 		}
 	}
@@ -223,6 +578,12 @@ func (service *PedidoService) ObtenerCantidadPedidosPorEstado() ([]utils.Cantida
 		return nil, err
 	}
 
+	cantidadPedidosDevueltos, err := service.pedidoRepository.ObtenerCantidadPedidosPorEstado(model.Devuelto)
+
+	if err != nil {
+		return nil, err
+	}
+
 	//Armo el array de CantidadEstado
 	cantidadPedidosPorEstados := []utils.CantidadEstado{
 		{Estado: string(model.Pendiente), Cantidad: cantidadPedidosPendientes},
@@ -230,6 +591,7 @@ func (service *PedidoService) ObtenerCantidadPedidosPorEstado() ([]utils.Cantida
 		{Estado: string(model.Cancelado), Cantidad: cantidadPedidosCancelados},
 		{Estado: string(model.ParaEnviar), Cantidad: cantidadPedidosParaEnviar},
 		{Estado: string(model.Enviado), Cantidad: cantidadPedidosEnviados},
+		{Estado: string(model.Devuelto), Cantidad: cantidadPedidosDevueltos},
 	}
 
 	return cantidadPedidosPorEstados, nil
@@ -241,25 +603,340 @@ func (service *PedidoService) CancelarPedido(pedidoPorCancelar *dto.Pedido, usua
 		return errors.New("el usuario no tiene permisos para cancelar un pedido")
 	}
 
-	//Primero buscamos el pedido a cancelar
-	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoPorCancelar.GetModel())
+	//La liberacion del stock y el cambio de estado se guardan juntos
+	return service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		//Buscamos el pedido dentro de la transaccion, para que si se reintenta se parta del pedido tal como esta guardado
+		pedido, err := transaccion.pedidoRepository.ObtenerPedidoPorId(pedidoPorCancelar.GetModel())
+
+		if err != nil {
+			return err
+		}
+
+		if pedido == nil {
+			return errors.New("no se encontró el pedido")
+		}
+
+		//Valida que el pedido esté en estado Pendiente o Aceptado
+		if pedido.Estado != model.Pendiente && pedido.Estado != model.Aceptado {
+			return errors.New("el pedido no se encuentra en estado Pendiente o Aceptado")
+		}
+
+		//Si el cliente indico la version que leyo, el pedido no puede haber cambiado desde entonces
+		err = validarVersion(pedidoPorCancelar.Version, pedido.Version)
+
+		if err != nil {
+			return err
+		}
+
+		//Si el pedido estaba aceptado, tiene stock reservado que hay que liberar
+		teniaStockReservado := pedido.Estado == model.Aceptado
+
+		pedido.Estado = model.Cancelado
+
+		if teniaStockReservado {
+			err := transaccion.liberarStockPedido(pedido, usuario)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		//Actualiza el pedido en la base de datos
+		return transaccion.pedidoRepository.ActualizarPedido(pedido)
+	})
+}
+
+// Completa el comprobante de entrega de un pedido ya entregado, con el receptor, observaciones y opcionalmente una firma o foto
+func (service *PedidoService) RegistrarEntrega(pedidoConId *dto.Pedido, entrega *dto.EntregaPedido, archivo *dto.Archivo, usuario *dto.User) error {
+	//Las entregas las registra el conductor que las hizo
+	if usuario.Rol != string(utils.Conductor) {
+		return errors.New("el usuario no tiene permisos para registrar una entrega")
+	}
+
+	if strings.TrimSpace(entrega.Receptor) == "" {
+		return errors.New("la entrega debe indicar quien recibio el pedido")
+	}
+
+	//Validamos el archivo antes de guardar nada
+	if archivo != nil {
+		if !strings.HasPrefix(archivo.Tipo, "image/") {
+			return errors.New("el archivo de la entrega debe ser una imagen")
+		}
+
+		if len(archivo.Contenido) > TamañoMaximoArchivoEntrega {
+			return errors.New("el archivo de la entrega no puede superar los 5 MB")
+		}
+	}
+
+	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoConId.GetModel())
 
 	if err != nil {
 		return err
 	}
 
-	//Valida que el pedido esté en estado Pendiente
-	if pedido.Estado != model.Pendiente {
-		return errors.New("el pedido no se encuentra en estado Pendiente")
+	if pedido == nil {
+		return errors.New("no se encontró el pedido")
 	}
 
-	//Cambia el estado del pedido a Cancelado, si es que no estaba ya en ese estado
-	if pedido.Estado != model.Cancelado {
-		pedido.Estado = model.Cancelado
+	//Solo tienen entrega los pedidos que se entregaron en alguna parada y no se devolvieron
+	if pedido.Estado != model.Enviado || pedido.Entrega == nil {
+		return errors.New("el pedido no fue entregado")
 	}
 
-	//Actualiza el pedido en la base de datos
-	return service.pedidoRepository.ActualizarPedido(pedido)
+	//La entrega solo la puede completar el conductor del envio que entrego el pedido
+	envioConId := dto.Envio{Id: pedido.Entrega.IdEnvio}
+
+	envio, err := service.envioRepository.ObtenerEnvioPorId(envioConId.GetModel())
+
+	if err != nil {
+		return err
+	}
+
+	if envio.ObjectId.IsZero() {
+		return errors.New("no se encontró el envio que entrego el pedido")
+	}
+
+	err = validarConductorDelEnvio(service.conductorRepository, envio, usuario)
+
+	if err != nil {
+		return err
+	}
+
+	//Si el cliente indico la version que leyo, el pedido no puede haber cambiado desde entonces
+	err = validarVersion(pedidoConId.Version, pedido.Version)
+
+	if err != nil {
+		return err
+	}
+
+	pedido.Entrega.Receptor = entrega.Receptor
+	pedido.Entrega.Observaciones = entrega.Observaciones
+	pedido.Entrega.IdCreador = usuario.Codigo
+
+	//Si no se indica la fecha, se mantiene la de la parada
+	if !entrega.FechaEntrega.IsZero() {
+		pedido.Entrega.FechaEntrega = entrega.FechaEntrega
+	}
+
+	idArchivoAnterior := pedido.Entrega.IdArchivo
+
+	if archivo != nil {
+		idArchivo, err := service.archivoRepository.GuardarArchivo(archivo.Nombre, archivo.Tipo, archivo.Contenido)
+
+		if err != nil {
+			return err
+		}
+
+		pedido.Entrega.IdArchivo = idArchivo
+		pedido.Entrega.NombreArchivo = archivo.Nombre
+		pedido.Entrega.TipoArchivo = archivo.Tipo
+	}
+
+	err = service.pedidoRepository.ActualizarPedido(pedido)
+
+	if err != nil {
+		//Si no se pudo guardar el pedido, el archivo nuevo queda huerfano y lo borramos
+		if archivo != nil {
+			service.archivoRepository.EliminarArchivo(pedido.Entrega.IdArchivo)
+		}
+		return err
+	}
+
+	//Si se reemplazo el archivo, borramos el anterior
+	if archivo != nil && idArchivoAnterior != "" {
+		return service.archivoRepository.EliminarArchivo(idArchivoAnterior)
+	}
+
+	return nil
+}
+
+func (service *PedidoService) ObtenerEntrega(pedidoConId *dto.Pedido) (*dto.EntregaPedido, error) {
+	pedido, err := service.pedidoRepository.ObtenerPedidoPorId(pedidoConId.GetModel())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pedido == nil {
+		return nil, errors.New("no se encontró el pedido")
+	}
+
+	if pedido.Entrega == nil {
+		return nil, errors.New("el pedido no fue entregado")
+	}
+
+	return dto.NewEntregaPedido(pedido.Entrega), nil
+}
+
+func (service *PedidoService) ObtenerArchivoEntrega(pedidoConId *dto.Pedido) (*dto.Archivo, error) {
+	entrega, err := service.ObtenerEntrega(pedidoConId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if entrega.IdArchivo == "" {
+		return nil, errors.New("la entrega del pedido no tiene archivo adjunto")
+	}
+
+	contenido, err := service.archivoRepository.ObtenerArchivo(entrega.IdArchivo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//El tipo se deduce del contenido guardado, sin confiar en el que declaro el cliente al subirlo
+	return &dto.Archivo{
+		Nombre:    entrega.NombreArchivo,
+		Tipo:      http.DetectContentType(contenido),
+		Contenido: contenido,
+	}, nil
+}
+
+// Registra los productos de un pedido entregado que fueron rechazados o devueltos, y los vuelve a sumar al stock.
+// Si se devuelve todo lo que quedaba del pedido, el pedido pasa a Devuelto
+func (service *PedidoService) RegistrarDevolucion(pedidoConId *dto.Pedido, nuevaDevolucion *dto.NuevaDevolucion, usuario *dto.User) error {
+	//Las devoluciones las puede registrar el conductor que recibe el rechazo o un operador
+	if usuario.Rol != string(utils.Conductor) && usuario.Rol != string(utils.Operador) {
+		return errors.New("el usuario no tiene permisos para registrar una devolucion")
+	}
+
+	if len(nuevaDevolucion.Productos) == 0 {
+		return errors.New("la devolucion debe tener al menos un producto")
+	}
+
+	return service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		pedido, err := transaccion.pedidoRepository.ObtenerPedidoPorId(pedidoConId.GetModel())
+
+		if err != nil {
+			return err
+		}
+
+		if pedido == nil {
+			return errors.New("no se encontró el pedido")
+		}
+
+		//Solo se puede devolver lo que se entrego
+		if pedido.Estado != model.Enviado {
+			return errors.New("el pedido no se encuentra en estado Enviado")
+		}
+
+		//Si el cliente indico la version que leyo, el pedido no puede haber cambiado desde entonces
+		err = validarVersion(pedidoConId.Version, pedido.Version)
+
+		if err != nil {
+			return err
+		}
+
+		devolucion, err := armarDevolucion(pedido, nuevaDevolucion)
+
+		if err != nil {
+			return err
+		}
+
+		devolucion.IdCreador = usuario.Codigo
+		devolucion.FechaCreacion = time.Now()
+
+		pedido.Devoluciones = append(pedido.Devoluciones, *devolucion)
+
+		//Si ya no queda ningun producto entregado, el pedido queda devuelto
+		if pedidoDevueltoPorCompleto(pedido) {
+			pedido.Estado = model.Devuelto
+		}
+
+		err = transaccion.pedidoRepository.ActualizarPedido(pedido)
+
+		if err != nil {
+			return err
+		}
+
+		//El envio del pedido queda en los movimientos para saber de donde volvio la mercaderia
+		idEnvio := ""
+		if pedido.Entrega != nil {
+			idEnvio = pedido.Entrega.IdEnvio
+		}
+
+		for _, producto := range devolucion.Productos {
+			productoParaReponer := dto.Producto{CodigoProducto: producto.CodigoProducto}
+
+			err = transaccion.productoRepository.ReponerStock(productoParaReponer.GetModel(), producto.Cantidad)
+
+			if err != nil {
+				return err
+			}
+
+			err = transaccion.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+				CodigoProducto: producto.CodigoProducto,
+				Cantidad:       producto.Cantidad,
+				Motivo:         model.DevolucionDeStock,
+				IdPedido:       utils.GetStringIDFromObjectID(pedido.ObjectId),
+				IdEnvio:        idEnvio,
+				IdCreador:      usuario.Codigo,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Arma la devolucion con los datos de cada linea del pedido, validando que no se devuelva mas de lo que se entrego
+func armarDevolucion(pedido *model.Pedido, nuevaDevolucion *dto.NuevaDevolucion) (*model.Devolucion, error) {
+	//Indexamos las lineas del pedido por codigo de producto
+	lineasPedido := make(map[string]model.ProductoPedido)
+	for _, productoPedido := range pedido.ProductosElegidos {
+		lineasPedido[productoPedido.CodigoProducto] = productoPedido
+	}
+
+	devolucion := &model.Devolucion{Motivo: nuevaDevolucion.Motivo}
+
+	//Guardamos los codigos ya cargados para no repetir productos
+	codigosCargados := make(map[string]bool)
+
+	for _, productoDevuelto := range nuevaDevolucion.Productos {
+		lineaPedido, existe := lineasPedido[productoDevuelto.CodigoProducto]
+
+		if !existe {
+			return nil, errors.New("el producto " + productoDevuelto.CodigoProducto + " no forma parte del pedido")
+		}
+
+		if codigosCargados[productoDevuelto.CodigoProducto] {
+			return nil, errors.New("el producto " + productoDevuelto.CodigoProducto + " esta repetido en la devolucion")
+		}
+
+		if productoDevuelto.Cantidad <= 0 {
+			return nil, errors.New("la cantidad devuelta del producto " + productoDevuelto.CodigoProducto + " debe ser mayor a cero")
+		}
+
+		cantidadPendiente := lineaPedido.Cantidad - pedido.ObtenerCantidadDevuelta(productoDevuelto.CodigoProducto)
+
+		if productoDevuelto.Cantidad > cantidadPendiente {
+			return nil, errors.New("no se pueden devolver mas unidades del producto " + productoDevuelto.CodigoProducto + " de las que se entregaron")
+		}
+
+		//La linea devuelta conserva el precio y el peso del pedido
+		lineaPedido.Cantidad = productoDevuelto.Cantidad
+		devolucion.Productos = append(devolucion.Productos, lineaPedido)
+		codigosCargados[productoDevuelto.CodigoProducto] = true
+	}
+
+	return devolucion, nil
+}
+
+func pedidoDevueltoPorCompleto(pedido *model.Pedido) bool {
+	for _, productoPedido := range pedido.ProductosElegidos {
+		if pedido.ObtenerCantidadDevuelta(productoPedido.CodigoProducto) < productoPedido.Cantidad {
+			return false
+		}
+	}
+	return true
 }
 
 // valida el rol del usuario
@@ -6,25 +6,46 @@ import (
 	"TPIntegrador/repositories"
 	"TPIntegrador/utils"
 	"errors"
+	"log"
 )
 
 type ProductoService struct {
-	productoRepository repositories.ProductoRepositoryInterface
-	pedidoRepository   repositories.PedidoRepositoryInterface
+	productoRepository        repositories.ProductoRepositoryInterface
+	pedidoRepository          repositories.PedidoRepositoryInterface
+	movimientoStockRepository repositories.MovimientoStockRepositoryInterface
+	unidadDeTrabajo           repositories.UnidadDeTrabajoInterface
+	pedidoService             PedidoServiceInterface
 }
 
 type ProductoServiceInterface interface {
 	CrearProducto(*dto.Producto, *dto.User) error
-	ObtenerProductos(utils.FiltroProducto) ([]dto.Producto, error)
+	ObtenerProductos(utils.FiltroProducto) ([]dto.Producto, *dto.Pagina, error)
 	ObtenerProductoPorCodigo(*dto.Producto) (*dto.Producto, error)
 	ActualizarProducto(*dto.Producto, *dto.User) error
+	RegistrarInventario(*dto.Inventario, *dto.User) error
 	EliminarProducto(*dto.Producto, *dto.User) error
+	ObtenerMovimientosStock(utils.FiltroMovimientoStock) ([]*dto.MovimientoStock, error)
+	ConciliarStock(*dto.Producto) (*dto.ConciliacionStock, error)
 }
 
-func NewProductoService(productoRepository repositories.ProductoRepositoryInterface, pedidoRepository repositories.PedidoRepositoryInterface) *ProductoService {
+func NewProductoService(productoRepository repositories.ProductoRepositoryInterface, pedidoRepository repositories.PedidoRepositoryInterface, movimientoStockRepository repositories.MovimientoStockRepositoryInterface, unidadDeTrabajo repositories.UnidadDeTrabajoInterface, pedidoService PedidoServiceInterface) *ProductoService {
 	return &ProductoService{
-		productoRepository: productoRepository,
-		pedidoRepository:   pedidoRepository,
+		productoRepository:        productoRepository,
+		pedidoRepository:          pedidoRepository,
+		movimientoStockRepository: movimientoStockRepository,
+		unidadDeTrabajo:           unidadDeTrabajo,
+		pedidoService:             pedidoService,
+	}
+}
+
+// Devuelve una copia del service que trabaja con los repositorios de una transaccion
+func (service *ProductoService) conRepositorios(repositorios *repositories.Repositorios) *ProductoService {
+	return &ProductoService{
+		productoRepository:        repositorios.Producto,
+		pedidoRepository:          repositorios.Pedido,
+		movimientoStockRepository: repositorios.MovimientoStock,
+		unidadDeTrabajo:           service.unidadDeTrabajo,
+		pedidoService:             service.pedidoService,
 	}
 }
 
@@ -44,22 +65,51 @@ func (service *ProductoService) CrearProducto(producto *dto.Producto, usuario *d
 		return errors.New("el tipo de producto ingresado no es válido")
 	}
 
+	err := completarVolumenProducto(producto)
+
+	if err != nil {
+		return err
+	}
+
 	//Le agregamos el codigo del usuario que lo creo
 	producto.IdCreador = usuario.Codigo
 
-	return service.productoRepository.CrearProducto(producto.GetModel())
+	//El producto nace sin reservas
+	producto.StockReservado = 0
+
+	//El producto y su movimiento de stock inicial se guardan juntos
+	return service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		productoDB := producto.GetModel()
+
+		err := transaccion.productoRepository.CrearProducto(productoDB)
+
+		if err != nil {
+			return err
+		}
+
+		return transaccion.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+			CodigoProducto: utils.GetStringIDFromObjectID(productoDB.ObjectId),
+			Cantidad:       productoDB.StockActual,
+			Motivo:         model.StockInicial,
+			IdCreador:      usuario.Codigo,
+		})
+	})
 }
 
-func (service *ProductoService) ObtenerProductos(filtro utils.FiltroProducto) ([]dto.Producto, error) {
+// Devuelve los productos del filtro, en el orden y con la paginacion que indica. La pagina lleva los mismos productos,
+// junto con el cursor de la siguiente y el total
+func (service *ProductoService) ObtenerProductos(filtro utils.FiltroProducto) ([]dto.Producto, *dto.Pagina, error) {
 	//Valido el tipo de producto que usa para filtrar
 	if !model.EsUnTipoProductoValido(filtro.TipoProducto) && filtro.TipoProducto != "" {
-		return nil, errors.New("el tipo de producto ingresado no es válido")
+		return nil, nil, errors.New("el tipo de producto ingresado no es válido")
 	}
 
-	productos, err := service.productoRepository.ObtenerProductos(filtro)
+	productos, pagina, err := service.productoRepository.ObtenerPaginaProductos(filtro)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	//Inicializamos el slice de productosDTO por si no hay productos
@@ -69,7 +119,7 @@ func (service *ProductoService) ObtenerProductos(filtro utils.FiltroProducto) ([
 		productosDTO = append(productosDTO, *dto.NewProducto(producto))
 	}
 
-	return productosDTO, nil
+	return productosDTO, dto.NewPagina(productosDTO, pagina), nil
 }
 
 func (service *ProductoService) ObtenerProductoPorCodigo(productoConCodigo *dto.Producto) (*dto.Producto, error) {
@@ -103,7 +153,167 @@ func (service *ProductoService) ActualizarProducto(producto *dto.Producto, usuar
 		return errors.New("el usuario no tiene permisos para actualizar un producto")
 	}
 
-	return service.productoRepository.ActualizarProducto(producto.GetModel())
+	err := completarVolumenProducto(producto)
+
+	if err != nil {
+		return err
+	}
+
+	err = service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		return service.conRepositorios(repositorios).actualizarStockProducto(producto.GetModel(), model.AjusteManual, usuario)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	//Si se repuso stock, puede que ahora se puedan aceptar pedidos que habian quedado pendientes
+	service.reevaluarPedidosHijos(producto, usuario)
+
+	return nil
+}
+
+func (service *ProductoService) reevaluarPedidosHijos(producto *dto.Producto, usuario *dto.User) {
+	err := service.pedidoService.ReevaluarPedidosHijos(producto, usuario)
+
+	//El stock ya quedo guardado, asi que si falla la reevaluacion solo se registra y los pedidos siguen pendientes
+	if err != nil {
+		log.Printf("[service:ProductoService][método:ReevaluarPedidosHijos][producto:%s][error:%s][user:%s]", producto.CodigoProducto, err.Error(), usuario.Codigo)
+	}
+}
+
+// Registra un conteo fisico del producto, ajustando el stock actual a lo que se conto
+func (service *ProductoService) RegistrarInventario(inventario *dto.Inventario, usuario *dto.User) error {
+	//valido el usuario
+	if !service.validarRol(usuario) {
+		return errors.New("el usuario no tiene permisos para registrar un inventario")
+	}
+
+	if inventario.StockContado < 0 {
+		return errors.New("el stock contado no puede ser negativo")
+	}
+
+	err := service.unidadDeTrabajo.Ejecutar(func(repositorios *repositories.Repositorios) error {
+		transaccion := service.conRepositorios(repositorios)
+
+		//Buscamos el producto para conservar el resto de sus datos
+		productoConCodigo := dto.Producto{CodigoProducto: inventario.CodigoProducto}
+
+		producto, err := transaccion.productoRepository.ObtenerProductoPorCodigo(productoConCodigo.GetModel())
+
+		if err != nil {
+			return err
+		}
+
+		//Si el cliente indico la version que leyo, el producto no puede haber cambiado desde entonces
+		err = validarVersion(inventario.Version, producto.Version)
+
+		if err != nil {
+			return err
+		}
+
+		producto.StockActual = inventario.StockContado
+
+		return transaccion.actualizarStockProducto(producto, model.Inventario, usuario)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	service.reevaluarPedidosHijos(&dto.Producto{CodigoProducto: inventario.CodigoProducto}, usuario)
+
+	return nil
+}
+
+// Actualiza el producto y deja registrada en los movimientos la diferencia de stock con lo que habia guardado
+func (service *ProductoService) actualizarStockProducto(producto *model.Producto, motivo model.MotivoMovimientoStock, usuario *dto.User) error {
+	//Buscamos el stock que tenia el producto antes del cambio
+	productoDB, err := service.productoRepository.ObtenerProductoPorCodigo(producto)
+
+	if err != nil {
+		return err
+	}
+
+	//Si el cliente indico la version que leyo, el producto no puede haber cambiado desde entonces
+	err = validarVersion(producto.Version, productoDB.Version)
+
+	if err != nil {
+		return err
+	}
+
+	producto.Version = productoDB.Version
+
+	//No se puede dejar menos stock del que ya esta reservado por pedidos aceptados
+	if producto.StockActual < productoDB.StockReservado {
+		return errors.New("el stock actual no puede ser menor al stock reservado por pedidos aceptados")
+	}
+
+	err = service.productoRepository.ActualizarProducto(producto)
+
+	if err != nil {
+		return err
+	}
+
+	diferencia := producto.StockActual - productoDB.StockActual
+
+	//Si el stock no cambio, no hay movimiento que registrar
+	if diferencia == 0 {
+		return nil
+	}
+
+	return service.movimientoStockRepository.RegistrarMovimiento(&model.MovimientoStock{
+		CodigoProducto: utils.GetStringIDFromObjectID(productoDB.ObjectId),
+		Cantidad:       diferencia,
+		Motivo:         motivo,
+		IdCreador:      usuario.Codigo,
+	})
+}
+
+func (service *ProductoService) ObtenerMovimientosStock(filtro utils.FiltroMovimientoStock) ([]*dto.MovimientoStock, error) {
+	//Valida que la fecha desde sea menor a la fecha hasta, solo si se pasaron ambas para filtrar
+	if !filtro.FechaDesde.IsZero() && !filtro.FechaHasta.IsZero() && filtro.FechaDesde.After(filtro.FechaHasta) {
+		return nil, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
+	}
+
+	movimientosDB, err := service.movimientoStockRepository.ObtenerMovimientos(&filtro)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Inicializamos el slice de movimientos por si no hay ninguno
+	movimientos := make([]*dto.MovimientoStock, 0)
+
+	for _, movimientoDB := range movimientosDB {
+		movimientos = append(movimientos, dto.NewMovimientoStock(movimientoDB))
+	}
+
+	return movimientos, nil
+}
+
+// Recalcula el stock del producto sumando todos sus movimientos y lo compara con el guardado
+func (service *ProductoService) ConciliarStock(productoConCodigo *dto.Producto) (*dto.ConciliacionStock, error) {
+	producto, err := service.productoRepository.ObtenerProductoPorCodigo(productoConCodigo.GetModel())
+
+	if err != nil {
+		return nil, err
+	}
+
+	stockSegunMovimientos, reservadoSegunMovimientos, err := service.movimientoStockRepository.ObtenerTotalesMovimientos(productoConCodigo.CodigoProducto)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ConciliacionStock{
+		CodigoProducto:                 productoConCodigo.CodigoProducto,
+		StockActual:                    producto.StockActual,
+		StockActualSegunMovimientos:    stockSegunMovimientos,
+		StockReservado:                 producto.StockReservado,
+		StockReservadoSegunMovimientos: reservadoSegunMovimientos,
+		Coincide:                       producto.StockActual == stockSegunMovimientos && producto.StockReservado == reservadoSegunMovimientos,
+	}, nil
 }
 
 func (service *ProductoService) EliminarProducto(producto *dto.Producto, usuario *dto.User) error {
@@ -112,14 +322,27 @@ func (service *ProductoService) EliminarProducto(producto *dto.Producto, usuario
 		return errors.New("el usuario no tiene permisos para eliminar un producto")
 	}
 
+	productoDB, err := service.productoRepository.ObtenerProductoPorCodigo(producto.GetModel())
+
+	if err != nil {
+		return err
+	}
+
+	//Si el cliente indico la version que leyo, el producto no puede haber cambiado desde entonces
+	err = validarVersion(producto.Version, productoDB.Version)
+
+	if err != nil {
+		return err
+	}
+
 	//Valido que el producto a eliminar no tenga pedidos asociados en estado pendiente o aceptado
-	err := service.productoTienePedidosEnCurso(producto)
+	err = service.productoTienePedidosEnCurso(producto)
 
 	if err != nil {
 		return err
 	}
 
-	return service.productoRepository.EliminarProducto(producto.GetModel())
+	return service.productoRepository.EliminarProducto(productoDB)
 }
 
 func (service *ProductoService) productoTienePedidosEnCurso(producto *dto.Producto) error {
@@ -156,6 +379,20 @@ func (service *ProductoService) productoTienePedidosEnCurso(producto *dto.Produc
 	return nil
 }
 
+// Valida las medidas del producto y, si estan todas, calcula el volumen a partir de ellas.
+// Si no se indican medidas, se usa el volumen que se haya cargado directamente
+func completarVolumenProducto(producto *dto.Producto) error {
+	if producto.AltoUnitario < 0 || producto.AnchoUnitario < 0 || producto.LargoUnitario < 0 || producto.VolumenUnitario < 0 {
+		return errors.New("las medidas y el volumen del producto no pueden ser negativos")
+	}
+
+	if volumen := producto.GetModel().CalcularVolumenUnitario(); volumen > 0 {
+		producto.VolumenUnitario = volumen
+	}
+
+	return nil
+}
+
 func (service *ProductoService) productoTieneCamposCompletos(producto *dto.Producto) bool {
 	return producto.Nombre != "" &&
 		producto.TipoDeProducto != "" &&
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"TPIntegrador/dto"
+	"TPIntegrador/model"
+	"TPIntegrador/repositories"
+	"TPIntegrador/utils"
+	"errors"
+	"sort"
+	"time"
+)
+
+type ReporteServiceInterface interface {
+	ObtenerRentabilidadCamiones(utils.FiltroReporte, *dto.User) ([]*dto.RentabilidadCamion, error)
+	ObtenerBeneficioTemporal(utils.FiltroReporte) (dto.BeneficioTemporal, error)
+	ObtenerRentabilidadAgrupada(utils.FiltroReporte, *dto.User) ([]*dto.RentabilidadAgrupada, error)
+}
+
+type ReporteService struct {
+	reporteRepository repositories.ReporteRepositoryInterface
+	calendario        model.CalendarioFiscal
+}
+
+func NewReporteService(reporteRepository repositories.ReporteRepositoryInterface, calendario model.CalendarioFiscal) *ReporteService {
+	return &ReporteService{
+		reporteRepository: reporteRepository,
+		calendario:        calendario,
+	}
+}
+
+// Devuelve la utilizacion y la rentabilidad de cada camion en el periodo
+func (service *ReporteService) ObtenerRentabilidadCamiones(filtro utils.FiltroReporte, usuario *dto.User) ([]*dto.RentabilidadCamion, error) {
+	if !service.validarRol(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para ver los reportes")
+	}
+
+	err := validarPeriodoReporte(filtro)
+	if err != nil {
+		return nil, err
+	}
+
+	rentabilidadesDB, err := service.reporteRepository.ObtenerRentabilidadCamiones(service.aplicarZonaHoraria(filtro))
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Inicializo la lista por si no hay camiones
+	rentabilidades := make([]*dto.RentabilidadCamion, 0)
+
+	for _, rentabilidadDB := range rentabilidadesDB {
+		rentabilidades = append(rentabilidades, dto.NewRentabilidadCamion(rentabilidadDB))
+	}
+
+	return rentabilidades, nil
+}
+
+// Devuelve los ingresos, el costo de envio asignado y el margen del periodo, agrupados por tipo de producto, producto,
+// ciudad de destino, camion o conductor
+func (service *ReporteService) ObtenerRentabilidadAgrupada(filtro utils.FiltroReporte, usuario *dto.User) ([]*dto.RentabilidadAgrupada, error) {
+	if !service.validarRol(usuario) {
+		return nil, errors.New("el usuario no tiene permisos para ver los reportes")
+	}
+
+	if !model.EsUnaDimensionRentabilidadValida(filtro.AgruparPor) {
+		return nil, errors.New("el reporte se puede agrupar por tipo_producto, producto, ciudad_destino, camion o conductor")
+	}
+
+	err := validarPeriodoReporte(filtro)
+	if err != nil {
+		return nil, err
+	}
+
+	rentabilidadesDB, err := service.reporteRepository.ObtenerRentabilidadAgrupada(service.aplicarZonaHoraria(filtro))
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Inicializo la lista por si no hay envios
+	rentabilidades := make([]*dto.RentabilidadAgrupada, 0)
+
+	for _, rentabilidadDB := range rentabilidadesDB {
+		rentabilidades = append(rentabilidades, dto.NewRentabilidadAgrupada(rentabilidadDB))
+	}
+
+	return rentabilidades, nil
+}
+
+// Devuelve el beneficio neto de cada ejercicio, mes, semana y dia entre las fechas, en la zona horaria del calendario.
+// Los ejercicios y los meses se toman completos, y los periodos sin envios despachados aparecen con monto 0
+func (service *ReporteService) ObtenerBeneficioTemporal(filtro utils.FiltroReporte) (dto.BeneficioTemporal, error) {
+	//Inicializamos el beneficio temporal
+	beneficioTemporal := dto.BeneficioTemporal{}
+	calendario := service.calendario
+
+	//Las fechas del filtro son dias del calendario, sin zona horaria
+	sinFechaDesde := filtro.FechaDesde.IsZero()
+	fechaDesde := calendario.AplicarZonaHoraria(filtro.FechaDesde)
+	fechaHasta := calendario.AplicarZonaHoraria(filtro.FechaHasta)
+
+	//Valida que la fecha desde sea menor a la fecha hasta
+	if fechaDesde.After(fechaHasta) {
+		return beneficioTemporal, errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
+	}
+
+	ejercicioDesde := calendario.ObtenerEjercicio(fechaDesde)
+	ejercicioHasta := calendario.ObtenerEjercicio(fechaHasta)
+
+	//Traemos de una vez todo lo que cubren los ejercicios y las semanas del periodo
+	filtroBeneficios := utils.FiltroReporte{
+		FechaDesde: calendario.ObtenerInicioEjercicio(ejercicioDesde),
+		FechaHasta: calendario.ObtenerInicioEjercicio(ejercicioHasta + 1).Add(-time.Nanosecond),
+	}
+	if inicioSemana := model.ObtenerInicioSemana(fechaDesde); inicioSemana.Before(filtroBeneficios.FechaDesde) {
+		filtroBeneficios.FechaDesde = inicioSemana
+	}
+	if finSemana := model.ObtenerInicioSemana(fechaHasta).AddDate(0, 0, 7).Add(-time.Nanosecond); finSemana.After(filtroBeneficios.FechaHasta) {
+		filtroBeneficios.FechaHasta = finSemana
+	}
+
+	beneficios, err := service.reporteRepository.ObtenerBeneficiosPorPeriodo(filtroBeneficios, calendario)
+
+	if err != nil {
+		return beneficioTemporal, err
+	}
+
+	//Por cada ejercicio en el rango de fechas, obtiene el beneficio
+	for ejercicio := ejercicioDesde; ejercicio <= ejercicioHasta; ejercicio++ {
+		clave := model.ObtenerClaveEjercicio(ejercicio)
+		beneficioTemporal.BeneficiosAnuales = append(beneficioTemporal.BeneficiosAnuales, dto.BeneficioAnual{Año: ejercicio, Monto: beneficios.Anuales[clave]})
+	}
+
+	//Hacemos lo mismo con los meses
+	for mes := time.Date(fechaDesde.Year(), fechaDesde.Month(), 1, 0, 0, 0, 0, calendario.ZonaHoraria); !mes.After(fechaHasta); mes = mes.AddDate(0, 1, 0) {
+		clave := model.ObtenerClaveMes(mes)
+		beneficioTemporal.BeneficiosMensuales = append(beneficioTemporal.BeneficiosMensuales, dto.BeneficioMensual{Mes: int(mes.Month()), Monto: beneficios.Mensuales[clave]})
+	}
+
+	//Sin fecha desde, las semanas y los dias arrancan en el primer dia con envios, para no listar desde el año 1
+	inicioDetalle := fechaDesde
+	if sinFechaDesde {
+		inicioDetalle = obtenerPrimerDiaConBeneficio(beneficios, fechaHasta)
+	}
+
+	//Las semanas van de lunes a domingo, y se incluye completa la semana de la fecha desde
+	for semana := model.ObtenerInicioSemana(inicioDetalle); !semana.After(fechaHasta); semana = semana.AddDate(0, 0, 7) {
+		clave := model.ObtenerClaveSemana(semana)
+		beneficioTemporal.BeneficiosSemanales = append(beneficioTemporal.BeneficiosSemanales, dto.BeneficioSemanal{Semana: clave, Monto: beneficios.Semanales[clave]})
+	}
+
+	for dia := inicioDetalle; !dia.After(fechaHasta); dia = dia.AddDate(0, 0, 1) {
+		clave := model.ObtenerClaveDia(dia)
+		beneficioTemporal.BeneficiosDiarios = append(beneficioTemporal.BeneficiosDiarios, dto.BeneficioDiario{Dia: clave, Monto: beneficios.Diarios[clave]})
+	}
+
+	return beneficioTemporal, nil
+}
+
+// Devuelve el primer dia que tuvo envios despachados. Si no hubo ninguno, devuelve un dia posterior a la fecha hasta
+func obtenerPrimerDiaConBeneficio(beneficios *model.BeneficiosPorPeriodo, fechaHasta time.Time) time.Time {
+	dias := make([]string, 0, len(beneficios.Diarios))
+	for dia := range beneficios.Diarios {
+		dias = append(dias, dia)
+	}
+
+	//Las claves de los dias ordenan igual como texto que como fecha
+	sort.Strings(dias)
+
+	for _, dia := range dias {
+		fecha, err := time.ParseInLocation("2006-01-02", dia, fechaHasta.Location())
+		if err == nil {
+			return fecha
+		}
+	}
+
+	return fechaHasta.AddDate(0, 0, 1)
+}
+
+// Pasa las fechas del filtro, que se leyeron sin zona horaria, a la zona del calendario
+func (service *ReporteService) aplicarZonaHoraria(filtro utils.FiltroReporte) utils.FiltroReporte {
+	if !filtro.FechaDesde.IsZero() {
+		filtro.FechaDesde = service.calendario.AplicarZonaHoraria(filtro.FechaDesde)
+	}
+	if !filtro.FechaHasta.IsZero() {
+		filtro.FechaHasta = service.calendario.AplicarZonaHoraria(filtro.FechaHasta)
+	}
+	return filtro
+}
+
+func validarPeriodoReporte(filtro utils.FiltroReporte) error {
+	if !filtro.FechaDesde.IsZero() && !filtro.FechaHasta.IsZero() && filtro.FechaDesde.After(filtro.FechaHasta) {
+		return errors.New("la fecha desde debe ser menor o igual a la fecha hasta")
+	}
+	return nil
+}
+
+func (service *ReporteService) validarRol(usuario *dto.User) bool {
+	return usuario.Rol == string(utils.Administrador)
+}
@@ -0,0 +1,13 @@
+package services
+
+import "TPIntegrador/model"
+
+// Si el cliente indica la version que leyo, tiene que coincidir con la que esta guardada.
+// La version 0 significa que el cliente no exige ninguna
+func validarVersion(versionEsperada int, versionActual int) error {
+	if versionEsperada != 0 && versionEsperada != versionActual {
+		return model.ErrConflictoDeVersion
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"TPIntegrador/model"
+	"errors"
+	"os"
+	"strconv"
+)
+
+const (
+	zonaHorariaPorDefecto        = "America/Argentina/Buenos_Aires"
+	mesInicioEjercicioPorDefecto = 1
+)
+
+// Arma el calendario de los reportes con las variables de entorno ZONA_HORARIA (nombre IANA)
+// y MES_INICIO_EJERCICIO (de 1 a 12). Si no estan definidas, usa la hora de Argentina y el año calendario
+func LeerCalendarioFiscal() (model.CalendarioFiscal, error) {
+	zonaHoraria := os.Getenv("ZONA_HORARIA")
+	if zonaHoraria == "" {
+		zonaHoraria = zonaHorariaPorDefecto
+	}
+
+	mesInicioEjercicio := mesInicioEjercicioPorDefecto
+	if mes := os.Getenv("MES_INICIO_EJERCICIO"); mes != "" {
+		var err error
+		mesInicioEjercicio, err = strconv.Atoi(mes)
+		if err != nil {
+			return model.CalendarioFiscal{}, errors.New("el mes de inicio del ejercicio debe ser un numero")
+		}
+	}
+
+	return model.NewCalendarioFiscal(zonaHoraria, mesInicioEjercicio)
+}
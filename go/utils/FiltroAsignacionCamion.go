@@ -0,0 +1,10 @@
+package utils
+
+import "time"
+
+type FiltroAsignacionCamion struct {
+	PatenteCamion   string
+	CodigoConductor string
+	//Si se indica, solo devuelve las asignaciones vigentes en esa fecha
+	VigenteEn time.Time
+}
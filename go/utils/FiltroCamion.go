@@ -4,4 +4,5 @@ type FiltroCamion struct {
 	Patente              string
 	EstaActivo           bool
 	FiltrarPorEstaActivo bool
+	Paginacion
 }
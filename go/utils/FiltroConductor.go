@@ -0,0 +1,7 @@
+package utils
+
+type FiltroConductor struct {
+	CodigoUsuario        string
+	EstaActivo           bool
+	FiltrarPorEstaActivo bool
+}
@@ -13,4 +13,5 @@ type FiltroEnvio struct {
 	FechaCreacionHasta            time.Time
 	FechaUltimaActualizacionDesde time.Time
 	FechaUltimaActualizacionHasta time.Time
+	Paginacion
 }
@@ -0,0 +1,9 @@
+package utils
+
+import "TPIntegrador/model"
+
+type FiltroMantenimiento struct {
+	PatenteCamion string
+	//Si tiene estados, solo devuelve los mantenimientos que esten en alguno de ellos
+	Estados []model.EstadoMantenimiento
+}
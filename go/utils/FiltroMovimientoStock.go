@@ -0,0 +1,9 @@
+package utils
+
+import "time"
+
+type FiltroMovimientoStock struct {
+	CodigoProducto string
+	FechaDesde     time.Time
+	FechaHasta     time.Time
+}
@@ -12,4 +12,6 @@ type FiltroPedido struct {
 	Estado                model.EstadoPedido
 	FechaCreacionComienzo time.Time
 	FechaCreacionFin      time.Time
+	SoloPedidosHijos      bool
+	Paginacion
 }
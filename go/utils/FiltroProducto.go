@@ -7,4 +7,5 @@ import (
 type FiltroProducto struct {
 	FiltrarPorStockMinimo bool
 	TipoProducto          model.TipoProducto
+	Paginacion
 }
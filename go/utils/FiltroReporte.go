@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"TPIntegrador/model"
+	"time"
+)
+
+// Periodo de los reportes. Una fecha en cero indica que el periodo no tiene limite de ese lado
+type FiltroReporte struct {
+	FechaDesde time.Time
+	FechaHasta time.Time
+	//Solo lo usa el reporte de rentabilidad agrupada
+	AgruparPor model.DimensionRentabilidad
+}
@@ -0,0 +1,15 @@
+package utils
+
+// Pagina pedida de un listado. Sin limite ni cursor se devuelve el listado completo, como siempre.
+// El cursor es el que devolvio la pagina anterior, y solo sirve con el mismo orden
+type Paginacion struct {
+	Limite      int
+	Cursor      string
+	OrdenarPor  string
+	Descendente bool
+}
+
+// Indica si se pidio una pagina, en cuyo caso la respuesta lleva el cursor siguiente y el total
+func (paginacion Paginacion) EsPaginada() bool {
+	return paginacion.Limite > 0 || paginacion.Cursor != ""
+}
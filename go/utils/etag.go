@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Arma el ETag que representa la version de un documento
+func GetETagFromVersion(version int) string {
+	return "\"" + strconv.Itoa(version) + "\""
+}
+
+// Devuelve la version que indica el header If-Match. Si no viene o es "*", devuelve 0, que significa que no se exige ninguna version
+func GetVersionFromIfMatch(c *gin.Context) (int, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	//Aceptamos el ETag con o sin comillas, y tambien en su forma debil
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	ifMatch = strings.Trim(ifMatch, "\"")
+
+	version, err := strconv.Atoi(ifMatch)
+
+	if err != nil || version < 0 {
+		return 0, errors.New("el header If-Match no es una version válida")
+	}
+
+	return version, nil
+}
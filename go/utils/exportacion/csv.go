@@ -0,0 +1,72 @@
+package exportacion
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// Genera el CSV del documento. Si tiene varias tablas, cada una va precedida de su titulo y separada por una linea vacia
+func generarCSV(documento Documento) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	//El BOM hace que Excel lea bien los acentos
+	buffer.WriteString("\uFEFF")
+
+	escritor := csv.NewWriter(&buffer)
+
+	for i, tabla := range documento.Tablas {
+		if len(documento.Tablas) > 1 {
+			if i > 0 {
+				escritor.Write([]string{})
+			}
+			escritor.Write([]string{tabla.Titulo})
+		}
+
+		titulos := make([]string, 0, len(tabla.Columnas))
+		for _, columna := range tabla.Columnas {
+			titulos = append(titulos, columna.Titulo)
+		}
+		escritor.Write(titulos)
+
+		for _, fila := range tabla.Filas {
+			escritor.Write(formatearFila(fila))
+		}
+
+		if tabla.tieneTotales() {
+			escritor.Write(formatearFila(tabla.obtenerFilaTotales()))
+		}
+	}
+
+	escritor.Flush()
+
+	if err := escritor.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func formatearFila(fila []interface{}) []string {
+	valores := make([]string, 0, len(fila))
+	for _, valor := range fila {
+		celda := formatearCelda(valor)
+
+		//Los textos los cargan los usuarios, asi que no pueden llegar a la planilla como formulas
+		if _, esTexto := valor.(string); esTexto {
+			celda = neutralizarFormula(celda)
+		}
+
+		valores = append(valores, celda)
+	}
+	return valores
+}
+
+// Excel ejecuta como formula la celda que empieza con =, +, - o @ (y tambien con tab o retorno de carro),
+// asi que a esos textos se les antepone una comilla para que se muestren tal cual
+func neutralizarFormula(celda string) string {
+	if celda != "" && strings.ContainsRune("=+-@\t\r", rune(celda[0])) {
+		return "'" + celda
+	}
+	return celda
+}
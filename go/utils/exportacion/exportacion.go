@@ -0,0 +1,198 @@
+package exportacion
+
+import (
+	"TPIntegrador/dto"
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formato en el que se exporta un reporte o un listado. El formato vacio es la respuesta JSON de siempre
+type Formato string
+
+const (
+	JSON Formato = ""
+	CSV  Formato = "csv"
+	XLSX Formato = "xlsx"
+	PDF  Formato = "pdf"
+)
+
+const (
+	tipoCSV  = "text/csv"
+	tipoXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	tipoPDF  = "application/pdf"
+)
+
+// Columna de una tabla. Si se totaliza, la tabla termina con una fila con la suma de sus valores
+type Columna struct {
+	Titulo    string
+	Totalizar bool
+}
+
+// Tabla a exportar. Las celdas pueden ser string, int, float64 o time.Time
+type Tabla struct {
+	Titulo   string
+	Columnas []Columna
+	Filas    [][]interface{}
+}
+
+// Documento a exportar, con una o mas tablas. El nombre es el del archivo, sin la extension
+type Documento struct {
+	Nombre string
+	Titulo string
+	Tablas []Tabla
+}
+
+// Obtiene el formato pedido en el parametro formato o, si no viene, en el header Accept
+func ObtenerFormato(formato string, accept string) (Formato, error) {
+	switch strings.ToLower(strings.TrimSpace(formato)) {
+	case "", "json":
+	case string(CSV):
+		return CSV, nil
+	case string(XLSX):
+		return XLSX, nil
+	case string(PDF):
+		return PDF, nil
+	default:
+		return JSON, errors.New("el formato debe ser json, csv, xlsx o pdf")
+	}
+
+	//Tomamos el primer tipo del Accept que sepamos generar. Cualquier otro se responde en JSON
+	for _, tipo := range strings.Split(accept, ",") {
+		tipoMedio, _, err := mime.ParseMediaType(strings.TrimSpace(tipo))
+		if err != nil {
+			continue
+		}
+
+		switch tipoMedio {
+		case tipoCSV:
+			return CSV, nil
+		case tipoXLSX:
+			return XLSX, nil
+		case tipoPDF:
+			return PDF, nil
+		case "application/json", "*/*":
+			return JSON, nil
+		}
+	}
+
+	return JSON, nil
+}
+
+// Genera el archivo del documento en el formato indicado
+func Exportar(documento Documento, formato Formato) (*dto.Archivo, error) {
+	var contenido []byte
+	var tipo string
+	var err error
+
+	switch formato {
+	case CSV:
+		contenido, err = generarCSV(documento)
+		tipo = tipoCSV + "; charset=utf-8"
+	case XLSX:
+		contenido, err = generarXLSX(documento)
+		tipo = tipoXLSX
+	case PDF:
+		contenido, err = generarPDF(documento)
+		tipo = tipoPDF
+	default:
+		return nil, errors.New("el formato no se puede exportar a un archivo")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.Archivo{
+		Nombre:    documento.Nombre + "." + string(formato),
+		Tipo:      tipo,
+		Contenido: contenido,
+	}, nil
+}
+
+// Indica si la tabla tiene alguna columna totalizada
+func (tabla Tabla) tieneTotales() bool {
+	for _, columna := range tabla.Columnas {
+		if columna.Totalizar {
+			return true
+		}
+	}
+	return false
+}
+
+// Arma la fila de totales: la suma de las columnas totalizadas y "Total" en la primera columna si no se totaliza
+func (tabla Tabla) obtenerFilaTotales() []interface{} {
+	fila := make([]interface{}, len(tabla.Columnas))
+
+	for i, columna := range tabla.Columnas {
+		if !columna.Totalizar {
+			fila[i] = ""
+			continue
+		}
+
+		var total float64 = 0
+		esEntero := true
+		for _, filaDatos := range tabla.Filas {
+			if i >= len(filaDatos) {
+				continue
+			}
+			switch valor := filaDatos[i].(type) {
+			case int:
+				total += float64(valor)
+			case float64:
+				total += valor
+				esEntero = false
+			}
+		}
+
+		if esEntero {
+			fila[i] = int(total)
+		} else {
+			fila[i] = total
+		}
+	}
+
+	if len(fila) > 0 && !tabla.Columnas[0].Totalizar {
+		fila[0] = "Total"
+	}
+
+	return fila
+}
+
+// Devuelve el valor de la celda como texto
+func formatearCelda(valor interface{}) string {
+	switch valor := valor.(type) {
+	case nil:
+		return ""
+	case string:
+		return valor
+	case int:
+		return strconv.Itoa(valor)
+	case float64:
+		return strconv.FormatFloat(valor, 'f', 2, 64)
+	case time.Time:
+		if valor.IsZero() {
+			return ""
+		}
+		return valor.Format("2006-01-02 15:04")
+	case bool:
+		if valor {
+			return "Si"
+		}
+		return "No"
+	default:
+		return ""
+	}
+}
+
+// Indica si la celda es un numero, para alinearla o guardarla como tal
+func esNumerica(valor interface{}) bool {
+	switch valor.(type) {
+	case int, float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,258 @@
+package exportacion
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hoja A4 apaisada, en puntos
+const (
+	anchoPaginaPDF   = 842.0
+	altoPaginaPDF    = 595.0
+	margenPDF        = 36.0
+	tamañoLetraPDF   = 9.0
+	altoLineaPDF     = 14.0
+	rellenoCeldaPDF  = 3.0
+	anchoLetraPDF    = 0.55 //Ancho promedio de un caracter de Helvetica, en proporcion al tamaño de la letra
+	fuenteNormalPDF  = "F1"
+	fuenteNegritaPDF = "F2"
+)
+
+// Arma las paginas del PDF, de arriba hacia abajo
+type generadorPDF struct {
+	titulo   string
+	paginas  []*strings.Builder
+	actual   *strings.Builder
+	y        float64
+	columnas []float64
+}
+
+// Genera el PDF del documento, con los titulos de las columnas en cada pagina y los totales al final de cada tabla
+func generarPDF(documento Documento) ([]byte, error) {
+	generador := &generadorPDF{titulo: documento.Titulo}
+	generador.nuevaPagina()
+
+	for i, tabla := range documento.Tablas {
+		//El titulo de la tabla no queda solo al final de una pagina
+		if i > 0 && generador.y-4*altoLineaPDF < margenPDF+altoLineaPDF {
+			generador.nuevaPagina()
+		}
+
+		generador.columnas = calcularAnchosColumnas(tabla)
+
+		if len(documento.Tablas) > 1 || tabla.Titulo != documento.Titulo {
+			generador.escribirTexto(margenPDF, generador.y, tabla.Titulo, fuenteNegritaPDF, 11)
+			generador.y -= altoLineaPDF + 4
+		}
+
+		generador.escribirTitulosColumnas(tabla)
+
+		for _, fila := range tabla.Filas {
+			if generador.y-altoLineaPDF < margenPDF+altoLineaPDF {
+				generador.nuevaPagina()
+				generador.escribirTitulosColumnas(tabla)
+			}
+			generador.escribirFila(fila, fuenteNormalPDF)
+		}
+
+		if tabla.tieneTotales() {
+			if generador.y-altoLineaPDF < margenPDF+altoLineaPDF {
+				generador.nuevaPagina()
+				generador.escribirTitulosColumnas(tabla)
+			}
+			generador.escribirLinea(generador.y + altoLineaPDF - 3)
+			generador.escribirFila(tabla.obtenerFilaTotales(), fuenteNegritaPDF)
+		}
+
+		generador.y -= altoLineaPDF
+	}
+
+	//Al final sabemos cuantas paginas hay, para numerarlas
+	for i, pagina := range generador.paginas {
+		generador.actual = pagina
+		texto := "Pagina " + strconv.Itoa(i+1) + " de " + strconv.Itoa(len(generador.paginas))
+		generador.escribirTexto(anchoPaginaPDF-margenPDF-anchoTextoPDF(texto, tamañoLetraPDF), margenPDF/2, texto, fuenteNormalPDF, tamañoLetraPDF)
+	}
+
+	return generador.armarArchivo(), nil
+}
+
+func (generador *generadorPDF) nuevaPagina() {
+	generador.actual = &strings.Builder{}
+	generador.paginas = append(generador.paginas, generador.actual)
+	generador.y = altoPaginaPDF - margenPDF
+
+	generador.escribirTexto(margenPDF, generador.y, generador.titulo, fuenteNegritaPDF, 13)
+	generador.y -= 2 * altoLineaPDF
+}
+
+func (generador *generadorPDF) escribirTitulosColumnas(tabla Tabla) {
+	titulos := make([]interface{}, 0, len(tabla.Columnas))
+	for _, columna := range tabla.Columnas {
+		titulos = append(titulos, columna.Titulo)
+	}
+
+	generador.escribirFila(titulos, fuenteNegritaPDF)
+	generador.escribirLinea(generador.y + altoLineaPDF - 3)
+}
+
+// Escribe la fila en la altura actual y baja una linea. Los numeros se alinean a la derecha
+func (generador *generadorPDF) escribirFila(fila []interface{}, fuente string) {
+	x := margenPDF
+
+	for i, ancho := range generador.columnas {
+		if i < len(fila) {
+			texto := recortarTextoPDF(formatearCelda(fila[i]), ancho-2*rellenoCeldaPDF)
+			xTexto := x + rellenoCeldaPDF
+			if esNumerica(fila[i]) {
+				xTexto = x + ancho - rellenoCeldaPDF - anchoTextoPDF(texto, tamañoLetraPDF)
+			}
+			generador.escribirTexto(xTexto, generador.y, texto, fuente, tamañoLetraPDF)
+		}
+		x += ancho
+	}
+
+	generador.y -= altoLineaPDF
+}
+
+func (generador *generadorPDF) escribirTexto(x float64, y float64, texto string, fuente string, tamaño float64) {
+	fmt.Fprintf(generador.actual, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", fuente, formatearNumeroPDF(tamaño), formatearNumeroPDF(x), formatearNumeroPDF(y), escaparTextoPDF(texto))
+}
+
+func (generador *generadorPDF) escribirLinea(y float64) {
+	fmt.Fprintf(generador.actual, "0.5 w %s %s m %s %s l S\n", formatearNumeroPDF(margenPDF), formatearNumeroPDF(y), formatearNumeroPDF(anchoPaginaPDF-margenPDF), formatearNumeroPDF(y))
+}
+
+// Arma el archivo con sus objetos: el catalogo, el arbol de paginas, las dos fuentes y cada pagina con su contenido
+func (generador *generadorPDF) armarArchivo() []byte {
+	var archivo bytes.Buffer
+	posiciones := make([]int, 0)
+
+	agregarObjeto := func(contenido string) {
+		posiciones = append(posiciones, archivo.Len())
+		fmt.Fprintf(&archivo, "%d 0 obj\n%s\nendobj\n", len(posiciones), contenido)
+	}
+
+	archivo.WriteString("%PDF-1.4\n")
+
+	hijos := make([]string, 0, len(generador.paginas))
+	for i := range generador.paginas {
+		hijos = append(hijos, strconv.Itoa(5+2*i)+" 0 R")
+	}
+
+	agregarObjeto("<< /Type /Catalog /Pages 2 0 R >>")
+	agregarObjeto(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(hijos, " "), len(generador.paginas)))
+	agregarObjeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	agregarObjeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, pagina := range generador.paginas {
+		agregarObjeto(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			formatearNumeroPDF(anchoPaginaPDF), formatearNumeroPDF(altoPaginaPDF), fuenteNormalPDF, fuenteNegritaPDF, 6+2*i))
+		contenido := pagina.String()
+		agregarObjeto(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(contenido), contenido))
+	}
+
+	inicioReferencias := archivo.Len()
+	fmt.Fprintf(&archivo, "xref\n0 %d\n0000000000 65535 f \n", len(posiciones)+1)
+	for _, posicion := range posiciones {
+		fmt.Fprintf(&archivo, "%010d 00000 n \n", posicion)
+	}
+	fmt.Fprintf(&archivo, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(posiciones)+1, inicioReferencias)
+
+	return archivo.Bytes()
+}
+
+// Reparte el ancho de la pagina entre las columnas segun el largo de su contenido, con un minimo para cada una
+func calcularAnchosColumnas(tabla Tabla) []float64 {
+	largos := make([]float64, len(tabla.Columnas))
+
+	medir := func(fila []interface{}) {
+		for i := range largos {
+			if i < len(fila) {
+				largo := float64(len([]rune(formatearCelda(fila[i]))))
+				if largo > largos[i] {
+					largos[i] = largo
+				}
+			}
+		}
+	}
+
+	titulos := make([]interface{}, 0, len(tabla.Columnas))
+	for _, columna := range tabla.Columnas {
+		titulos = append(titulos, columna.Titulo)
+	}
+	medir(titulos)
+	for _, fila := range tabla.Filas {
+		medir(fila)
+	}
+	if tabla.tieneTotales() {
+		medir(tabla.obtenerFilaTotales())
+	}
+
+	var total float64 = 0
+	for i := range largos {
+		//Ninguna columna es demasiado angosta ni se come toda la pagina
+		if largos[i] < 4 {
+			largos[i] = 4
+		}
+		if largos[i] > 40 {
+			largos[i] = 40
+		}
+		total += largos[i]
+	}
+
+	anchoDisponible := anchoPaginaPDF - 2*margenPDF
+	anchos := make([]float64, len(largos))
+	for i, largo := range largos {
+		anchos[i] = anchoDisponible * largo / total
+	}
+
+	return anchos
+}
+
+// Estimacion del ancho del texto, suficiente para alinear y recortar
+func anchoTextoPDF(texto string, tamaño float64) float64 {
+	return float64(len([]rune(texto))) * tamaño * anchoLetraPDF
+}
+
+// Recorta el texto con puntos suspensivos si no entra en el ancho
+func recortarTextoPDF(texto string, ancho float64) string {
+	if anchoTextoPDF(texto, tamañoLetraPDF) <= ancho {
+		return texto
+	}
+
+	runas := []rune(texto)
+	maximo := int(ancho/(tamañoLetraPDF*anchoLetraPDF)) - 3
+	if maximo <= 0 {
+		return ""
+	}
+	return string(runas[:maximo]) + "..."
+}
+
+// Convierte el texto a WinAnsi, la codificacion de las fuentes estandar, y escapa los caracteres especiales
+func escaparTextoPDF(texto string) string {
+	var escapado strings.Builder
+
+	for _, runa := range texto {
+		switch {
+		case runa == '(' || runa == ')' || runa == '\\':
+			escapado.WriteByte('\\')
+			escapado.WriteByte(byte(runa))
+		case runa >= 32 && runa < 127:
+			escapado.WriteByte(byte(runa))
+		case runa >= 160 && runa < 256:
+			//Los acentos y la ñ coinciden en Latin-1 y WinAnsi
+			fmt.Fprintf(&escapado, "\\%03o", runa)
+		default:
+			escapado.WriteByte('?')
+		}
+	}
+
+	return escapado.String()
+}
+
+func formatearNumeroPDF(numero float64) string {
+	return strconv.FormatFloat(numero, 'f', 2, 64)
+}
@@ -0,0 +1,213 @@
+package exportacion
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estilos de las celdas: 0 normal, 1 negrita (titulos y totales), 2 fecha
+const (
+	estiloNormal    = 0
+	estiloNegrita   = 1
+	estiloFecha     = 2
+	maximoLargoHoja = 31
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
+
+// Genera el libro de Excel del documento, con una hoja por tabla
+func generarXLSX(documento Documento) ([]byte, error) {
+	var buffer bytes.Buffer
+	archivoZip := zip.NewWriter(&buffer)
+
+	nombresHojas := obtenerNombresHojas(documento.Tablas)
+
+	var tiposHojas, hojasLibro, relacionesLibro strings.Builder
+	for i, nombre := range nombresHojas {
+		fmt.Fprintf(&tiposHojas, "<Override PartName=\"/xl/worksheets/sheet%d.xml\" ContentType=\"application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml\"/>\n", i+1)
+		fmt.Fprintf(&hojasLibro, "<sheet name=\"%s\" sheetId=\"%d\" r:id=\"rId%d\"/>", escaparXML(nombre), i+1, i+1)
+		fmt.Fprintf(&relacionesLibro, "<Relationship Id=\"rId%d\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet\" Target=\"worksheets/sheet%d.xml\"/>", i+1, i+1)
+	}
+	fmt.Fprintf(&relacionesLibro, "<Relationship Id=\"rId%d\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles\" Target=\"styles.xml\"/>", len(nombresHojas)+1)
+
+	partes := []struct {
+		nombre    string
+		contenido string
+	}{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, tiposHojas.String())},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + hojasLibro.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + relacionesLibro.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for i, tabla := range documento.Tablas {
+		partes = append(partes, struct {
+			nombre    string
+			contenido string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), generarHojaXLSX(tabla)})
+	}
+
+	for _, parte := range partes {
+		escritor, err := archivoZip.Create(parte.nombre)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = escritor.Write([]byte(parte.contenido))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := archivoZip.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Genera la hoja de la tabla: la fila de titulos, los datos y, si corresponde, los totales
+func generarHojaXLSX(tabla Tabla) string {
+	var hoja strings.Builder
+
+	hoja.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	numeroFila := 1
+
+	titulos := make([]interface{}, 0, len(tabla.Columnas))
+	for _, columna := range tabla.Columnas {
+		titulos = append(titulos, columna.Titulo)
+	}
+	escribirFilaXLSX(&hoja, numeroFila, titulos, estiloNegrita)
+
+	for _, fila := range tabla.Filas {
+		numeroFila++
+		escribirFilaXLSX(&hoja, numeroFila, fila, estiloNormal)
+	}
+
+	if tabla.tieneTotales() {
+		numeroFila++
+		escribirFilaXLSX(&hoja, numeroFila, tabla.obtenerFilaTotales(), estiloNegrita)
+	}
+
+	hoja.WriteString(`</sheetData></worksheet>`)
+
+	return hoja.String()
+}
+
+func escribirFilaXLSX(hoja *strings.Builder, numeroFila int, fila []interface{}, estilo int) {
+	fmt.Fprintf(hoja, "<row r=\"%d\">", numeroFila)
+
+	for i, valor := range fila {
+		referencia := obtenerLetraColumna(i) + strconv.Itoa(numeroFila)
+
+		switch valor := valor.(type) {
+		case int, float64:
+			fmt.Fprintf(hoja, "<c r=\"%s\" s=\"%d\"><v>%s</v></c>", referencia, estilo, formatearNumeroXLSX(valor))
+		case time.Time:
+			if valor.IsZero() {
+				continue
+			}
+			//Excel guarda las fechas como dias desde el 30/12/1899
+			dias := valor.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, valor.Location())).Hours() / 24
+			fmt.Fprintf(hoja, "<c r=\"%s\" s=\"%d\"><v>%s</v></c>", referencia, estiloFecha, strconv.FormatFloat(dias, 'f', -1, 64))
+		default:
+			texto := formatearCelda(valor)
+			if texto == "" {
+				continue
+			}
+			fmt.Fprintf(hoja, "<c r=\"%s\" s=\"%d\" t=\"inlineStr\"><is><t xml:space=\"preserve\">%s</t></is></c>", referencia, estilo, escaparXML(texto))
+		}
+	}
+
+	hoja.WriteString("</row>")
+}
+
+func formatearNumeroXLSX(valor interface{}) string {
+	if entero, ok := valor.(int); ok {
+		return strconv.Itoa(entero)
+	}
+	return strconv.FormatFloat(valor.(float64), 'f', -1, 64)
+}
+
+// Devuelve la letra de la columna, empezando en A para la columna 0
+func obtenerLetraColumna(indice int) string {
+	letras := ""
+	for indice >= 0 {
+		letras = string(rune('A'+indice%26)) + letras
+		indice = indice/26 - 1
+	}
+	return letras
+}
+
+// Excel no acepta hojas con nombre repetido, de mas de 31 caracteres o con algunos simbolos
+func obtenerNombresHojas(tablas []Tabla) []string {
+	reemplazos := strings.NewReplacer("[", "", "]", "", ":", "", "*", "", "?", "", "/", "-", "\\", "-")
+
+	nombres := make([]string, 0, len(tablas))
+	usados := make(map[string]bool)
+
+	for i, tabla := range tablas {
+		nombre := strings.TrimSpace(reemplazos.Replace(tabla.Titulo))
+		if nombre == "" {
+			nombre = "Hoja " + strconv.Itoa(i+1)
+		}
+		nombre = recortarRunas(nombre, maximoLargoHoja)
+
+		//Si se repite, le agregamos el numero de hoja
+		if usados[strings.ToLower(nombre)] {
+			sufijo := " " + strconv.Itoa(i+1)
+			nombre = recortarRunas(nombre, maximoLargoHoja-len(sufijo)) + sufijo
+		}
+
+		usados[strings.ToLower(nombre)] = true
+		nombres = append(nombres, nombre)
+	}
+
+	return nombres
+}
+
+func recortarRunas(texto string, largo int) string {
+	runas := []rune(texto)
+	if len(runas) <= largo {
+		return texto
+	}
+	return string(runas[:largo])
+}
+
+func escaparXML(texto string) string {
+	var buffer bytes.Buffer
+	xml.EscapeText(&buffer, []byte(texto))
+	return buffer.String()
+}
@@ -2,7 +2,10 @@ package logging
 
 import (
 	"TPIntegrador/dto"
+	"TPIntegrador/model"
+	"errors"
 	"log"
+	"mime"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +14,29 @@ import (
 func LoggearErrorYResponder(c *gin.Context, handler string, metodo string, err error, user *dto.User) {
 	log.Printf("[handler:%s][método:%s][error:%s][user:%s]", handler, metodo, err.Error(), user.Codigo)
 
+	//Si el envio no puede pasar al estado pedido, indicamos tambien a que estados si puede pasar
+	var errorTransicion *model.ErrorTransicionEnvio
+	if errors.As(err, &errorTransicion) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "estados_permitidos": errorTransicion.EstadosPermitidos})
+		return
+	}
+
+	//Si el envio no cabe en el camion, indicamos que porcentaje de cada limite ocuparia
+	var errorCapacidad *model.ErrorCapacidadCamion
+	if errors.As(err, &errorCapacidad) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "ocupacion": gin.H{
+			"porcentaje_peso":    errorCapacidad.Ocupacion.ObtenerPorcentajePeso(),
+			"porcentaje_volumen": errorCapacidad.Ocupacion.ObtenerPorcentajeVolumen(),
+		}})
+		return
+	}
+
+	//Si otro modifico el documento desde que el cliente lo leyo, el cliente tiene que volver a obtenerlo
+	if errors.Is(err, model.ErrConflictoDeVersion) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 }
 
@@ -25,3 +51,17 @@ func LoggearResultadoYResponder(c *gin.Context, handler string, metodo string, r
 
 	c.JSON(http.StatusOK, result)
 }
+
+func LoggearArchivoYResponder(c *gin.Context, handler string, metodo string, archivo *dto.Archivo, user *dto.User) {
+	log.Printf("[handler:%s][método:%s][exitoso][archivo:%s][user:%s]", handler, metodo, archivo.Nombre, user.Codigo)
+
+	//El nombre puede venir del cliente, asi que se escapa para que no rompa el header
+	disposicion := mime.FormatMediaType("attachment", map[string]string{"filename": archivo.Nombre})
+	if disposicion == "" {
+		disposicion = "attachment"
+	}
+
+	c.Header("Content-Disposition", disposicion)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, archivo.Tipo, archivo.Contenido)
+}
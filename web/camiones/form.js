@@ -50,6 +50,7 @@ function guardarCamion(event) {
   const data = {
     patente: document.getElementById("Patente").value,
     peso_maximo: parseInt(document.getElementById("PesoMaximo").value),
+    volumen_maximo: parseFloat(document.getElementById("VolumenMaximo").value) || 0,
     fecha_creacion: "2023-10-14T12:00:00Z",
     fecha_ultima_actualizacion: "2023-10-14T12:00:00Z",
     id_creador: "",
@@ -86,6 +87,7 @@ function actualizarCamion(event) {
   const data = {
     patente: document.getElementById("Patente").value,
     peso_maximo: parseInt(document.getElementById("PesoMaximo").value),
+    volumen_maximo: parseFloat(document.getElementById("VolumenMaximo").value) || 0,
     fecha_creacion: "2023-10-14T12:00:00Z",
     fecha_ultima_actualizacion: "2023-10-14T12:00:00Z",
     id_creador: "",
@@ -140,6 +142,7 @@ function obtenerCamionPorId(patente) {
 function exitoObtenerCamion(data) {
   document.getElementById("Patente").value = data.patente;
   document.getElementById("PesoMaximo").value = data.peso_maximo;
+  document.getElementById("VolumenMaximo").value = data.volumen_maximo;
   document.getElementById("CostoPorKm").value = data.costo_por_kilometro;
 }
 
@@ -50,6 +50,9 @@ function guardarProducto() {
     tipo_producto: document.getElementById("TipoProducto").value,
     nombre: document.getElementById("Nombre").value,
     peso_unitario: parseFloat(document.getElementById("PesoUnitario").value),
+    alto_unitario: parseFloat(document.getElementById("AltoUnitario").value) || 0,
+    ancho_unitario: parseFloat(document.getElementById("AnchoUnitario").value) || 0,
+    largo_unitario: parseFloat(document.getElementById("LargoUnitario").value) || 0,
     precio_unitario: parseFloat(
       document.getElementById("PrecioUnitario").value
     ),
@@ -77,6 +80,9 @@ function actualizarProducto(codProducto) {
     tipo_producto: document.getElementById("TipoProducto").value,
     nombre: document.getElementById("Nombre").value,
     peso_unitario: parseFloat(document.getElementById("PesoUnitario").value),
+    alto_unitario: parseFloat(document.getElementById("AltoUnitario").value) || 0,
+    ancho_unitario: parseFloat(document.getElementById("AnchoUnitario").value) || 0,
+    largo_unitario: parseFloat(document.getElementById("LargoUnitario").value) || 0,
     precio_unitario: parseFloat(
       document.getElementById("PrecioUnitario").value
     ),
@@ -139,6 +145,9 @@ function exitoObtenerProducto(data) {
   document.getElementById("TipoProducto").value = data.tipo_producto;
   document.getElementById("Nombre").value = data.nombre;
   document.getElementById("PesoUnitario").value = data.peso_unitario;
+  document.getElementById("AltoUnitario").value = data.alto_unitario;
+  document.getElementById("AnchoUnitario").value = data.ancho_unitario;
+  document.getElementById("LargoUnitario").value = data.largo_unitario;
   document.getElementById("PrecioUnitario").value = data.precio_unitario;
   document.getElementById("StockMinimo").value = data.stock_minimo;
   document.getElementById("StockActual").value = data.stock_actual;